@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+// ResponseError response struct representing an error.
+type ResponseError struct {
+	Message string `json:"message"`
+}
+
+// CreatedAPIKey response struct representing a created API key, the only one
+// carrying its token.
+type CreatedAPIKey struct {
+	*models.APIKey
+	Token string `json:"token"`
+}
+
+// APIKeyHandler http handler for API key use cases.
+type APIKeyHandler struct {
+	Usecase apikey.Usecase
+}
+
+// NewAPIKeyHTTPHandler API key http handler constructor, running the given
+// middleware, such as authentication, on every API key route.
+func NewAPIKeyHTTPHandler(e *echo.Echo, us apikey.Usecase, m ...echo.MiddlewareFunc) {
+	handler := &APIKeyHandler{
+		Usecase: us,
+	}
+	e.GET("/apikey", handler.FetchAPIKey, m...)
+	e.POST("/apikey", handler.Store, m...)
+	e.DELETE("/apikey/:id", handler.Revoke, m...)
+}
+
+// FetchAPIKey handles listing the API keys of the caller's organisation.
+func (h *APIKeyHandler) FetchAPIKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	list, err := h.Usecase.Fetch(ctx)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+// Store handles the API key creation requests. Keys are created for the
+// caller's organisation unless the request names one.
+func (h *APIKeyHandler) Store(c echo.Context) error {
+	var key models.APIKey
+
+	if err := c.Bind(&key); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if key.Organisation == "" {
+		key.Organisation = tenant.FromContext(ctx)
+	}
+	if err := key.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	token, err := h.Usecase.Create(ctx, &key)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, CreatedAPIKey{APIKey: &key, Token: token})
+}
+
+// Revoke handles API key revocation requests.
+func (h *APIKeyHandler) Revoke(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err = h.Usecase.Revoke(ctx, id); err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// getStatusCode based on the usecase output error calculates the http response
+// status code.
+func getStatusCode(ctx context.Context, err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	status := http.StatusInternalServerError
+	if _, ok := err.(*auth.PermissionError); ok {
+		status = http.StatusForbidden
+	}
+	switch err {
+	case models.ErrNotFound:
+		status = http.StatusNotFound
+	case models.ErrConflict:
+		status = http.StatusConflict
+	case models.ErrScopeNotGranted:
+		status = http.StatusForbidden
+	}
+
+	// Client errors are expected, only server ones are logged as errors.
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error(err)
+	} else {
+		logging.FromContext(ctx).Debug(err)
+	}
+
+	return status
+}
@@ -0,0 +1,104 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apikeyHttp "github.com/adriacidre/go-clean-arch/apikey/delivery/http"
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	apikeyUcase "github.com/adriacidre/go-clean-arch/apikey/usecase"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	paymentHttp "github.com/adriacidre/go-clean-arch/payment/delivery/http"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	paymentUcase "github.com/adriacidre/go-clean-arch/payment/usecase"
+	"github.com/adriacidre/go-clean-arch/testutil"
+	"github.com/adriacidre/go-clean-arch/transaction"
+)
+
+// newTestServer serves the API key and payment http handlers, authenticated
+// with bearer tokens signed by testutil.Bearer or API keys, backed by in-memory
+// repositories.
+func newTestServer() *httptest.Server {
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = testutil.Verifier()
+	ku := apikeyUcase.NewAPIKey(apikeyRepo.NewMemoryAPIKey(), time.Second*2)
+	m.APIKeys = ku
+
+	e := echo.New()
+	pu := paymentUcase.NewPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), transaction.NewMemoryManager(), time.Second*2)
+	paymentHttp.NewPaymentHTTPHandler(e, pu, m.APIKey, m.JWT, m.Tenant,
+		m.RequireScope(models.ScopePaymentsRead, models.ScopePaymentsWrite))
+	apikeyHttp.NewAPIKeyHTTPHandler(e, ku, m.APIKey, m.JWT, m.Tenant,
+		m.RequireScope(models.ScopeAPIKeysRead, models.ScopeAPIKeysWrite))
+
+	return httptest.NewServer(e)
+}
+
+// accessToken authenticates with the given API key token.
+func accessToken(token string) map[string]string {
+	return map[string]string{middleware.AccessTokenKey: token}
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	orgA, orgB := testutil.Bearer(t, "org-a"), testutil.Bearer(t, "org-b")
+
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"ci","scopes":["payments:admin"]}`, orgA, nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	var reader apikeyHttp.CreatedAPIKey
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"reporting","scopes":["payments:read"]}`, orgA, &reader)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "org-a", reader.Organisation)
+	assert.NotEmpty(t, reader.Token)
+
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", accessToken(reader.Token), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/payment", `{}`, accessToken(reader.Token), nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/apikey", "", accessToken(reader.Token), nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", accessToken(reader.Token+"x"), nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Keys managing keys can only grant the scopes they hold.
+	var manager apikeyHttp.CreatedAPIKey
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"ops","scopes":["apikeys:write","payments:read"]}`, orgA, &manager)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"ci","scopes":["payments:write"]}`, accessToken(manager.Token), nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"ci","scopes":["payments:read"]}`, accessToken(manager.Token), nil)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/apikey", `{"name":"ci","organisation_id":"org-b","scopes":["payments:read"]}`, accessToken(manager.Token), nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var list []models.APIKey
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/apikey", "", orgB, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 0)
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/apikey", "", orgA, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 3)
+
+	url := srv.URL + "/apikey/" + strconv.Itoa(int(reader.ID))
+	res = testutil.DoJSON(t, echo.DELETE, url, "", orgB, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = testutil.DoJSON(t, echo.DELETE, url, "", orgA, nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res = testutil.DoJSON(t, echo.DELETE, url, "", orgA, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", accessToken(reader.Token), nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
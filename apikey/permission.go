@@ -0,0 +1,41 @@
+package apikey
+
+import (
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// Permissions authorising the API key use cases.
+const (
+	PermissionRead  = "apikeys:read"
+	PermissionWrite = "apikeys:write"
+)
+
+// Permissions every permission authorising an API key use case.
+func Permissions() []string {
+	return []string{PermissionRead, PermissionWrite}
+}
+
+// ScopePermissions permissions of the use cases the given scope opens, which
+// a caller must be granted to grant the scope to an API key. Reading deleted
+// payments and restoring them are left out, as they need their own
+// permission whatever the scope.
+func ScopePermissions(scope string) []string {
+	switch scope {
+	case models.ScopePaymentsRead:
+		return []string{payment.PermissionRead}
+	case models.ScopePaymentsWrite:
+		return payment.WritePermissions()
+	case models.ScopeAPIKeysRead:
+		return []string{PermissionRead}
+	case models.ScopeAPIKeysWrite:
+		return []string{PermissionWrite}
+	case models.ScopeWebhooksRead:
+		return []string{webhook.PermissionRead}
+	case models.ScopeWebhooksWrite:
+		return []string{webhook.PermissionWrite}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,17 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Repository repository interface to interact with stored API keys. Every
+// method but GetByPrefix is restricted to the organisation of the caller.
+type Repository interface {
+	Fetch(ctx context.Context) ([]*models.APIKey, error)
+	GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	Store(ctx context.Context, k *models.APIKey) (int64, error)
+	Revoke(ctx context.Context, id int64, at time.Time) error
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+// organisationFilter restricts a statement to the organisation of the caller,
+// taking it twice as arguments, an empty one matching every organisation.
+const organisationFilter = `(? = '' OR organisation = ?)`
+
+// apiKeyColumns columns selected when fetching API keys, in scan order.
+const apiKeyColumns = `id,organisation,name,prefix,scopes,salt,hash,created_at,revoked_at`
+
+// scopeSeparator separates the scopes of an API key in its scopes column.
+const scopeSeparator = ","
+
+type mysqlAPIKey struct {
+	Conn *sql.DB
+}
+
+// NewMysqlAPIKey mysql API keys constructor.
+func NewMysqlAPIKey(Conn *sql.DB) apikey.Repository {
+	return &mysqlAPIKey{Conn}
+}
+
+// fetchAPIKeys runs the given API keys query, scanning apiKeyColumns rows.
+func fetchAPIKeys(ctx context.Context, conn *sql.DB, query string, args ...interface{}) ([]*models.APIKey, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.APIKey, 0)
+	for rows.Next() {
+		k := new(models.APIKey)
+		var scopes string
+		err = rows.Scan(
+			&k.ID,
+			&k.Organisation,
+			&k.Name,
+			&k.Prefix,
+			&scopes,
+			&k.Salt,
+			&k.Hash,
+			&k.CreatedAt,
+			&k.RevokedAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil, err
+		}
+		k.Scopes = strings.Split(scopes, scopeSeparator)
+		result = append(result, k)
+	}
+
+	return result, nil
+}
+
+// firstAPIKey returns the first of the given API keys, ErrNotFound if none.
+func firstAPIKey(list []*models.APIKey, err error) (*models.APIKey, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+// revoked checks a revocation changed a single API key, ErrNotFound otherwise.
+func revoked(res sql.Result) error {
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affect != 1 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// Fetch lists the API keys of the caller's organisation.
+func (m *mysqlAPIKey) Fetch(ctx context.Context) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE ` + organisationFilter + ` ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchAPIKeys(ctx, m.Conn, query, org, org)
+}
+
+// GetByPrefix gets the API key with the given prefix, whatever its
+// organisation.
+func (m *mysqlAPIKey) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE prefix = ?`
+
+	return firstAPIKey(fetchAPIKeys(ctx, m.Conn, query, prefix))
+}
+
+// Store stores the given API key.
+func (m *mysqlAPIKey) Store(ctx context.Context, k *models.APIKey) (int64, error) {
+	query := `INSERT api_key SET organisation=? , name=? , prefix=? , scopes=? , salt=? , hash=? , created_at=?`
+
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, k.Organisation, k.Name, k.Prefix,
+		strings.Join(k.Scopes, scopeSeparator), k.Salt, k.Hash, k.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Revoke revokes the given, not yet revoked, API key of the caller's
+// organisation.
+func (m *mysqlAPIKey) Revoke(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE api_key SET revoked_at=? WHERE id = ? AND revoked_at IS NULL AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, at, id, org, org)
+	if err != nil {
+		return err
+	}
+
+	return revoked(res)
+}
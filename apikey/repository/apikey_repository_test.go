@@ -0,0 +1,124 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+var columns = []string{"id", "organisation", "name", "prefix", "scopes", "salt", "hash", "created_at", "revoked_at"}
+
+func TestFetch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows(columns).
+		AddRow(1, "org-a", "ci", "0123456789abcdef", "payments:read,payments:write", "salt", "hash", now, nil).
+		AddRow(2, "org-a", "reporting", "fedcba9876543210", "payments:read", "salt", "hash", now, now)
+
+	query := "SELECT (.+) FROM api_key WHERE \\(\\? = '' OR organisation = \\?\\) ORDER BY id"
+
+	mock.ExpectQuery(query).WithArgs("org-a", "org-a").WillReturnRows(rows)
+	a := apikeyRepo.NewMysqlAPIKey(db)
+
+	list, err := a.Fetch(tenant.NewContext(context.TODO(), "org-a"))
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, []string{models.ScopePaymentsRead, models.ScopePaymentsWrite}, list[0].Scopes)
+		assert.False(t, list[0].Revoked())
+		assert.True(t, list[1].Revoked())
+	}
+}
+
+func TestGetByPrefix(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows(columns).
+		AddRow(1, "org-a", "ci", "0123456789abcdef", "payments:read", "salt", "hash", time.Now(), nil)
+
+	query := "SELECT (.+) FROM api_key WHERE prefix = \\?"
+
+	mock.ExpectQuery(query).WithArgs("0123456789abcdef").WillReturnRows(rows)
+	a := apikeyRepo.NewMysqlAPIKey(db)
+
+	k, err := a.GetByPrefix(context.TODO(), "0123456789abcdef")
+	assert.NoError(t, err)
+	assert.Equal(t, "org-a", k.Organisation)
+	assert.Equal(t, "hash", k.Hash)
+}
+
+func TestGetByPrefixNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM api_key").WillReturnRows(sqlmock.NewRows(columns))
+	a := apikeyRepo.NewMysqlAPIKey(db)
+
+	k, err := a.GetByPrefix(context.TODO(), "0123456789abcdef")
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, k)
+}
+
+func TestStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	k := &models.APIKey{
+		Organisation: "org-a",
+		Name:         "ci",
+		Prefix:       "0123456789abcdef",
+		Scopes:       []string{models.ScopePaymentsRead, models.ScopePaymentsWrite},
+		Salt:         "salt",
+		Hash:         "hash",
+		CreatedAt:    time.Now(),
+	}
+
+	query := "INSERT api_key SET organisation=\\? , name=\\? , prefix=\\? , scopes=\\? , salt=\\? , hash=\\? , created_at=\\?"
+	mock.ExpectExec(query).WithArgs(k.Organisation, k.Name, k.Prefix, "payments:read,payments:write", k.Salt, k.Hash, k.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	a := apikeyRepo.NewMysqlAPIKey(db)
+
+	id, err := a.Store(context.TODO(), k)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+
+	query := "UPDATE api_key SET revoked_at=\\? WHERE id = \\? AND revoked_at IS NULL AND \\(\\? = '' OR organisation = \\?\\)"
+	mock.ExpectExec(query).WithArgs(now, 7, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(now, 7, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a := apikeyRepo.NewMysqlAPIKey(db)
+	ctx := tenant.NewContext(context.TODO(), "org-a")
+
+	assert.NoError(t, a.Revoke(ctx, 7, now))
+	assert.Equal(t, models.ErrNotFound, a.Revoke(ctx, 7, now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+type memoryAPIKey struct {
+	mu     sync.RWMutex
+	lastID int64
+	keys   map[int64]models.APIKey
+}
+
+// NewMemoryAPIKey in-memory API keys constructor, meant for local development
+// and tests as nothing is persisted across restarts.
+func NewMemoryAPIKey() apikey.Repository {
+	return &memoryAPIKey{
+		keys: make(map[int64]models.APIKey),
+	}
+}
+
+// Fetch lists the API keys of the caller's organisation.
+func (m *memoryAPIKey) Fetch(ctx context.Context) ([]*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*models.APIKey, 0)
+	for _, k := range m.keys {
+		if tenant.Allows(ctx, k.Organisation) {
+			k := k
+			result = append(result, &k)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+// GetByPrefix gets the API key with the given prefix, whatever its
+// organisation.
+func (m *memoryAPIKey) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, k := range m.keys {
+		if k.Prefix == prefix {
+			return &k, nil
+		}
+	}
+
+	return nil, models.ErrNotFound
+}
+
+// Store stores the given API key.
+func (m *memoryAPIKey) Store(ctx context.Context, k *models.APIKey) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.keys {
+		if existing.Prefix == k.Prefix {
+			return 0, models.ErrConflict
+		}
+	}
+
+	m.lastID++
+	stored := *k
+	stored.ID = m.lastID
+	stored.Scopes = append([]string(nil), k.Scopes...)
+	m.keys[stored.ID] = stored
+
+	return stored.ID, nil
+}
+
+// Revoke revokes the given, not yet revoked, API key of the caller's
+// organisation.
+func (m *memoryAPIKey) Revoke(ctx context.Context, id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k, ok := m.keys[id]
+	if !ok || k.Revoked() || !tenant.Allows(ctx, k.Organisation) {
+		return models.ErrNotFound
+	}
+
+	k.RevokedAt = &at
+	m.keys[id] = k
+
+	return nil
+}
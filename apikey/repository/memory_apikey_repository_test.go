@@ -0,0 +1,47 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestMemoryStoreAndRevoke(t *testing.T) {
+	a := apikeyRepo.NewMemoryAPIKey()
+	orgA := tenant.NewContext(context.TODO(), "org-a")
+	orgB := tenant.NewContext(context.TODO(), "org-b")
+
+	_, err := a.GetByPrefix(context.TODO(), "0123456789abcdef")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	k := &models.APIKey{Organisation: "org-a", Name: "ci", Prefix: "0123456789abcdef", Scopes: []string{models.ScopePaymentsRead}}
+	id, err := a.Store(orgA, k)
+	require.NoError(t, err)
+	_, err = a.Store(orgA, k)
+	assert.Equal(t, models.ErrConflict, err)
+
+	stored, err := a.GetByPrefix(context.TODO(), k.Prefix)
+	require.NoError(t, err)
+	assert.Equal(t, id, stored.ID)
+
+	list, err := a.Fetch(orgB)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+	assert.Equal(t, models.ErrNotFound, a.Revoke(orgB, id, time.Now()))
+
+	assert.NoError(t, a.Revoke(orgA, id, time.Now()))
+	assert.Equal(t, models.ErrNotFound, a.Revoke(orgA, id, time.Now()))
+
+	list, err = a.Fetch(context.TODO())
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.True(t, list[0].Revoked())
+	}
+}
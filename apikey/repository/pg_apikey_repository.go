@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type pgAPIKey struct {
+	Conn *sql.DB
+}
+
+// NewPgAPIKey postgres API keys constructor.
+func NewPgAPIKey(Conn *sql.DB) apikey.Repository {
+	return &pgAPIKey{Conn}
+}
+
+// Fetch lists the API keys of the caller's organisation.
+func (m *pgAPIKey) Fetch(ctx context.Context) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE ($1::text = '' OR organisation = $1::text) ORDER BY id`
+
+	return fetchAPIKeys(ctx, m.Conn, query, tenant.FromContext(ctx))
+}
+
+// GetByPrefix gets the API key with the given prefix, whatever its
+// organisation.
+func (m *pgAPIKey) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE prefix = $1`
+
+	return firstAPIKey(fetchAPIKeys(ctx, m.Conn, query, prefix))
+}
+
+// Store stores the given API key.
+func (m *pgAPIKey) Store(ctx context.Context, k *models.APIKey) (int64, error) {
+	query := `INSERT INTO api_key (organisation, name, prefix, scopes, salt, hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var id int64
+	tracing.Statement(ctx, query)
+	err := m.Conn.QueryRowContext(ctx, query, k.Organisation, k.Name, k.Prefix,
+		strings.Join(k.Scopes, scopeSeparator), k.Salt, k.Hash, k.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Revoke revokes the given, not yet revoked, API key of the caller's
+// organisation.
+func (m *pgAPIKey) Revoke(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE api_key SET revoked_at=$1 WHERE id = $2 AND revoked_at IS NULL
+		AND ($3::text = '' OR organisation = $3::text)`
+
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, at, id, tenant.FromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return revoked(res)
+}
@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestPgStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	k := &models.APIKey{
+		Organisation: "org-a",
+		Name:         "ci",
+		Prefix:       "0123456789abcdef",
+		Scopes:       []string{models.ScopePaymentsRead},
+		Salt:         "salt",
+		Hash:         "hash",
+		CreatedAt:    time.Now(),
+	}
+
+	query := "INSERT INTO api_key (.+) VALUES \\(\\$1, \\$2, \\$3, \\$4, \\$5, \\$6, \\$7\\) RETURNING id"
+	mock.ExpectQuery(query).WithArgs(k.Organisation, k.Name, k.Prefix, "payments:read", k.Salt, k.Hash, k.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	a := apikeyRepo.NewPgAPIKey(db)
+
+	id, err := a.Store(context.TODO(), k)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgRevoke(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+
+	query := "UPDATE api_key SET revoked_at=\\$1 WHERE id = \\$2 AND revoked_at IS NULL\\s+AND \\(\\$3::text = '' OR organisation = \\$3::text\\)"
+	mock.ExpectExec(query).WithArgs(now, 7, "org-a").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a := apikeyRepo.NewPgAPIKey(db)
+
+	err = a.Revoke(tenant.NewContext(context.TODO(), "org-a"), 7, now)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
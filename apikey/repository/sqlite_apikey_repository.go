@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type sqliteAPIKey struct {
+	Conn *sql.DB
+}
+
+// NewSqliteAPIKey sqlite API keys constructor.
+func NewSqliteAPIKey(Conn *sql.DB) apikey.Repository {
+	return &sqliteAPIKey{Conn}
+}
+
+// Fetch lists the API keys of the caller's organisation.
+func (m *sqliteAPIKey) Fetch(ctx context.Context) ([]*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE ` + organisationFilter + ` ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchAPIKeys(ctx, m.Conn, query, org, org)
+}
+
+// GetByPrefix gets the API key with the given prefix, whatever its
+// organisation.
+func (m *sqliteAPIKey) GetByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + `
+  						FROM api_key WHERE prefix = ?`
+
+	return firstAPIKey(fetchAPIKeys(ctx, m.Conn, query, prefix))
+}
+
+// Store stores the given API key.
+func (m *sqliteAPIKey) Store(ctx context.Context, k *models.APIKey) (int64, error) {
+	query := `INSERT INTO api_key (organisation, name, prefix, scopes, salt, hash, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, k.Organisation, k.Name, k.Prefix,
+		strings.Join(k.Scopes, scopeSeparator), k.Salt, k.Hash, k.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Revoke revokes the given, not yet revoked, API key of the caller's
+// organisation.
+func (m *sqliteAPIKey) Revoke(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE api_key SET revoked_at=? WHERE id = ? AND revoked_at IS NULL AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, at, id, org, org)
+	if err != nil {
+		return err
+	}
+
+	return revoked(res)
+}
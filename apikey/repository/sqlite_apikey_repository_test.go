@@ -0,0 +1,57 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/testutil"
+)
+
+func TestSqliteStoreAndRevoke(t *testing.T) {
+	db := testutil.OpenSqlite(t, ":memory:")
+
+	a := apikeyRepo.NewSqliteAPIKey(db)
+	orgA := tenant.NewContext(context.TODO(), "org-a")
+	orgB := tenant.NewContext(context.TODO(), "org-b")
+
+	id, err := a.Store(orgA, &models.APIKey{
+		Organisation: "org-a",
+		Name:         "ci",
+		Prefix:       "0123456789abcdef",
+		Scopes:       []string{models.ScopePaymentsRead, models.ScopePaymentsWrite},
+		Salt:         "salt",
+		Hash:         "hash",
+		CreatedAt:    time.Now(),
+	})
+	require.NoError(t, err)
+
+	_, err = a.Store(orgA, &models.APIKey{Organisation: "org-a", Name: "dup", Prefix: "0123456789abcdef",
+		Scopes: []string{models.ScopePaymentsRead}, CreatedAt: time.Now()})
+	assert.Error(t, err, "prefixes are unique")
+
+	k, err := a.GetByPrefix(context.TODO(), "0123456789abcdef")
+	require.NoError(t, err)
+	assert.Equal(t, id, k.ID)
+	assert.Equal(t, []string{models.ScopePaymentsRead, models.ScopePaymentsWrite}, k.Scopes)
+
+	list, err := a.Fetch(orgB)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+	assert.Equal(t, models.ErrNotFound, a.Revoke(orgB, id, time.Now()))
+
+	assert.NoError(t, a.Revoke(orgA, id, time.Now()))
+	assert.Equal(t, models.ErrNotFound, a.Revoke(orgA, id, time.Now()))
+
+	list, err = a.Fetch(orgA)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.True(t, list[0].Revoked())
+	}
+}
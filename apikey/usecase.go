@@ -0,0 +1,15 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Usecase API key usecase interface
+type Usecase interface {
+	Create(ctx context.Context, k *models.APIKey) (string, error)
+	Fetch(ctx context.Context) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	Authenticate(ctx context.Context, token string) (*models.APIKey, error)
+}
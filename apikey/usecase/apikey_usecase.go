@@ -0,0 +1,158 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+// Random bytes of the prefix identifying an API key, of its secret and of
+// the salt its secret is hashed with.
+const (
+	prefixLength = 8
+	secretLength = 32
+	saltLength   = 16
+)
+
+// tokenSeparator separates the prefix of an API key token from its secret.
+const tokenSeparator = "."
+
+type apiKeyUsecase struct {
+	repo           apikey.Repository
+	contextTimeout time.Duration
+}
+
+// NewAPIKey constructor for the API key use case.
+func NewAPIKey(r apikey.Repository, timeout time.Duration) apikey.Usecase {
+	return &apiKeyUsecase{
+		repo:           r,
+		contextTimeout: timeout,
+	}
+}
+
+// Create stores the given API key, which must belong to the caller's
+// organisation, returning its token. Only a salted hash of the token secret
+// is stored, so it cannot be recovered later. Callers restricted to some
+// scopes can only grant those.
+func (a *apiKeyUsecase) Create(c context.Context, k *models.APIKey) (string, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if !tenant.Allows(ctx, k.Organisation) {
+		return "", models.ErrNotFound
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		for _, scope := range k.Scopes {
+			if !p.HasScope(scope) {
+				return "", models.ErrScopeNotGranted
+			}
+		}
+	}
+
+	prefix, err := randomHex(prefixLength)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomHex(secretLength)
+	if err != nil {
+		return "", err
+	}
+	salt, err := randomHex(saltLength)
+	if err != nil {
+		return "", err
+	}
+
+	k.Prefix = prefix
+	k.Salt = salt
+	k.Hash = hash(salt, secret)
+	k.CreatedAt = time.Now()
+	k.RevokedAt = nil
+	id, err := a.repo.Store(ctx, k)
+	if err != nil {
+		return "", err
+	}
+
+	k.ID = id
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"apikey": id,
+		"scopes": k.Scopes,
+	}).Info("api key created")
+	return prefix + tokenSeparator + secret, nil
+}
+
+// Fetch lists the API keys of the caller's organisation.
+func (a *apiKeyUsecase) Fetch(c context.Context) ([]*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	return a.repo.Fetch(ctx)
+}
+
+// Revoke revokes an API key of the caller's organisation, which cannot
+// authenticate any call afterwards.
+func (a *apiKeyUsecase) Revoke(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.repo.Revoke(ctx, id, time.Now()); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField("apikey", id).Info("api key revoked")
+	return nil
+}
+
+// Authenticate gets the not revoked API key the given token belongs to,
+// failing with ErrInvalidAPIKey when there is none.
+func (a *apiKeyUsecase) Authenticate(c context.Context, token string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	parts := strings.SplitN(token, tokenSeparator, 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	k, err := a.repo.GetByPrefix(ctx, parts[0])
+	if err == models.ErrNotFound {
+		return nil, models.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash(k.Salt, parts[1])), []byte(k.Hash)) != 1 || k.Revoked() {
+		return nil, models.ErrInvalidAPIKey
+	}
+
+	return k, nil
+}
+
+// hash hashes the given secret with the given salt.
+func hash(salt, secret string) string {
+	sum := sha256.Sum256([]byte(salt + secret))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,100 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/apikey/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/apikey/usecase"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestCreateAndAuthenticate(t *testing.T) {
+	mockAPIKeyRepo := new(mocks.Repository)
+	var stored models.APIKey
+	mockAPIKeyRepo.On("Store", mock.Anything, mock.AnythingOfType("*models.APIKey")).
+		Run(func(args mock.Arguments) { stored = *args.Get(1).(*models.APIKey) }).
+		Return(int64(7), nil).Once()
+
+	u := ucase.NewAPIKey(mockAPIKeyRepo, time.Second*2)
+	k := &models.APIKey{Organisation: "org-a", Name: "ci", Scopes: []string{models.ScopePaymentsRead}}
+
+	token, err := u.Create(tenant.NewContext(context.TODO(), "org-a"), k)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), k.ID)
+	assert.True(t, strings.HasPrefix(token, k.Prefix+"."))
+	assert.NotContains(t, stored.Hash, strings.TrimPrefix(token, k.Prefix+"."), "only a hash of the secret is stored")
+
+	stored.ID = 7
+	mockAPIKeyRepo.On("GetByPrefix", mock.Anything, k.Prefix).Return(&stored, nil)
+
+	authenticated, err := u.Authenticate(context.TODO(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), authenticated.ID)
+
+	_, err = u.Authenticate(context.TODO(), k.Prefix+".wrong")
+	assert.Equal(t, models.ErrInvalidAPIKey, err)
+
+	revokedAt := time.Now()
+	stored.RevokedAt = &revokedAt
+	_, err = u.Authenticate(context.TODO(), token)
+	assert.Equal(t, models.ErrInvalidAPIKey, err)
+
+	mockAPIKeyRepo.AssertExpectations(t)
+}
+
+func TestAuthenticateUnknown(t *testing.T) {
+	mockAPIKeyRepo := new(mocks.Repository)
+	mockAPIKeyRepo.On("GetByPrefix", mock.Anything, "0123456789abcdef").Return(nil, models.ErrNotFound)
+
+	u := ucase.NewAPIKey(mockAPIKeyRepo, time.Second*2)
+
+	for _, token := range []string{"", "0123456789abcdef", ".secret", "0123456789abcdef.secret"} {
+		_, err := u.Authenticate(context.TODO(), token)
+		assert.Equal(t, models.ErrInvalidAPIKey, err, token)
+	}
+	mockAPIKeyRepo.AssertExpectations(t)
+}
+
+func TestCreateOtherOrganisation(t *testing.T) {
+	mockAPIKeyRepo := new(mocks.Repository)
+	u := ucase.NewAPIKey(mockAPIKeyRepo, time.Second*2)
+
+	_, err := u.Create(tenant.NewContext(context.TODO(), "org-b"), &models.APIKey{Organisation: "org-a"})
+	assert.Equal(t, models.ErrNotFound, err)
+	mockAPIKeyRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+}
+
+func TestCreateScopeNotGranted(t *testing.T) {
+	mockAPIKeyRepo := new(mocks.Repository)
+	u := ucase.NewAPIKey(mockAPIKeyRepo, time.Second*2)
+	ctx := auth.NewContext(context.TODO(), &auth.Principal{
+		Organisation: "org-a",
+		Scopes:       []string{models.ScopeAPIKeysWrite, models.ScopePaymentsRead},
+	})
+
+	k := &models.APIKey{Organisation: "org-a", Scopes: []string{models.ScopePaymentsRead, models.ScopePaymentsWrite}}
+	_, err := u.Create(ctx, k)
+	assert.Equal(t, models.ErrScopeNotGranted, err)
+	mockAPIKeyRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+}
+
+func TestRevoke(t *testing.T) {
+	mockAPIKeyRepo := new(mocks.Repository)
+	mockAPIKeyRepo.On("Revoke", mock.Anything, int64(7), mock.AnythingOfType("time.Time")).Return(nil).Once()
+	mockAPIKeyRepo.On("Revoke", mock.Anything, int64(8), mock.AnythingOfType("time.Time")).Return(models.ErrNotFound).Once()
+
+	u := ucase.NewAPIKey(mockAPIKeyRepo, time.Second*2)
+
+	assert.NoError(t, u.Revoke(context.TODO(), 7))
+	assert.Equal(t, models.ErrNotFound, u.Revoke(context.TODO(), 8))
+	mockAPIKeyRepo.AssertExpectations(t)
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+type authorizedAPIKeyUsecase struct {
+	next   apikey.Usecase
+	policy *auth.Policy
+}
+
+// NewAuthorizedAPIKey decorates an API key use case checking the caller was
+// granted the permission of every call by the given policy, and every
+// permission of the scopes it grants, so that no key can do more than its
+// creator. Authenticating a key is not checked, as there is no caller yet.
+func NewAuthorizedAPIKey(next apikey.Usecase, policy *auth.Policy) apikey.Usecase {
+	return &authorizedAPIKeyUsecase{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (a *authorizedAPIKeyUsecase) Create(c context.Context, k *models.APIKey) (string, error) {
+	if err := a.policy.Authorize(c, apikey.PermissionWrite); err != nil {
+		return "", err
+	}
+	for _, scope := range k.Scopes {
+		for _, permission := range apikey.ScopePermissions(scope) {
+			if a.policy.Authorize(c, permission) != nil {
+				return "", models.ErrScopeNotGranted
+			}
+		}
+	}
+
+	return a.next.Create(c, k)
+}
+
+func (a *authorizedAPIKeyUsecase) Fetch(c context.Context) ([]*models.APIKey, error) {
+	if err := a.policy.Authorize(c, apikey.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return a.next.Fetch(c)
+}
+
+func (a *authorizedAPIKeyUsecase) Revoke(c context.Context, id int64) error {
+	if err := a.policy.Authorize(c, apikey.PermissionWrite); err != nil {
+		return err
+	}
+
+	return a.next.Revoke(c, id)
+}
+
+func (a *authorizedAPIKeyUsecase) Authenticate(c context.Context, token string) (*models.APIKey, error) {
+	return a.next.Authenticate(c, token)
+}
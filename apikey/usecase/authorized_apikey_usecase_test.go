@@ -0,0 +1,55 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/adriacidre/go-clean-arch/apikey/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/apikey/usecase"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+func TestAuthorizedAPIKey(t *testing.T) {
+	mockUCase := new(mocks.APIKey)
+	mockUCase.On("Create", mock.Anything, mock.AnythingOfType("*models.APIKey")).Return("token", nil)
+	mockUCase.On("Fetch", mock.Anything).Return([]*models.APIKey{}, nil)
+	mockUCase.On("Authenticate", mock.Anything, "token").Return(&models.APIKey{ID: 1}, nil)
+
+	u := ucase.NewAuthorizedAPIKey(mockUCase, auth.NewPolicy(map[string][]string{
+		"operator": {"payments:read", "payments:create", "apikeys:read", "apikeys:write"},
+		"auditor":  {"payments:read", "apikeys:read"},
+		"admin":    {auth.AllPermissions},
+	}))
+	as := func(role string) context.Context {
+		return auth.NewContext(context.TODO(), &auth.Principal{Roles: []string{role}})
+	}
+	key := func(scopes ...string) *models.APIKey {
+		return &models.APIKey{Organisation: "org-a", Scopes: scopes}
+	}
+
+	_, err := u.Create(as("operator"), key(models.ScopePaymentsRead, models.ScopeAPIKeysRead))
+	assert.NoError(t, err)
+	_, err = u.Create(as("operator"), key(models.ScopePaymentsWrite))
+	assert.Equal(t, models.ErrScopeNotGranted, err, "operators cannot delete payments")
+	_, err = u.Create(as("operator"), key(models.ScopeWebhooksWrite))
+	assert.Equal(t, models.ErrScopeNotGranted, err)
+	_, err = u.Create(as("admin"), key(models.ScopePaymentsWrite, models.ScopeWebhooksWrite))
+	assert.NoError(t, err)
+
+	_, err = u.Create(as("auditor"), key(models.ScopePaymentsRead))
+	assert.Equal(t, &auth.PermissionError{Permission: "apikeys:write"}, err)
+	assert.Equal(t, &auth.PermissionError{Permission: "apikeys:write"}, u.Revoke(as("auditor"), 1))
+	_, err = u.Fetch(as("auditor"))
+	assert.NoError(t, err)
+
+	_, err = u.Authenticate(context.TODO(), "token")
+	assert.NoError(t, err)
+
+	mockUCase.AssertNumberOfCalls(t, "Create", 2)
+	mockUCase.AssertNumberOfCalls(t, "Fetch", 1)
+	mockUCase.AssertNotCalled(t, "Revoke", mock.Anything, mock.Anything)
+}
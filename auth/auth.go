@@ -0,0 +1,66 @@
+// Package auth authenticates API callers, carrying the verified principal
+// through the request context.
+package auth
+
+import (
+	"context"
+)
+
+// RoleAdmin role allowed to operate on every organisation.
+const RoleAdmin = "admin"
+
+// Principal authenticated caller.
+type Principal struct {
+	// Subject identifies the caller, e.g. the sub claim of its token.
+	Subject string
+	// Organisation the caller belongs to.
+	Organisation string
+	// Roles granted to the caller.
+	Roles []string
+	// Scopes the caller is restricted to, every one of them when nil.
+	Scopes []string
+	// Claims every claim of the verified token.
+	Claims map[string]interface{}
+}
+
+// HasRole reports whether the principal was granted the given role.
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope reports whether the principal may act within the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying the given principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal carried by ctx, if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return p, ok && p != nil
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Errors returned when a token is rejected.
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+// Claims naming the organisation and roles of the token subject.
+const (
+	ClaimOrganisation = "org"
+	ClaimRoles        = "roles"
+)
+
+// Verifier verifies HS256, RS256 and ES256 signed JWTs, checking their exp,
+// nbf, iss and aud claims.
+type Verifier struct {
+	secret   []byte
+	keys     map[string]crypto.PublicKey
+	issuer   string
+	audience string
+	parser   *jwt.Parser
+}
+
+// NewVerifier builds a verifier accepting tokens issued by issuer for
+// audience, each check being skipped when empty. Keys must be added before
+// any token can be verified.
+func NewVerifier(issuer, audience string) *Verifier {
+	return &Verifier{
+		keys:     make(map[string]crypto.PublicKey),
+		issuer:   issuer,
+		audience: audience,
+		parser: &jwt.Parser{
+			ValidMethods: []string{
+				jwt.SigningMethodHS256.Alg(),
+				jwt.SigningMethodRS256.Alg(),
+				jwt.SigningMethodES256.Alg(),
+			},
+		},
+	}
+}
+
+// SetSecret sets the secret HS256 tokens are signed with.
+func (v *Verifier) SetSecret(secret []byte) {
+	v.secret = secret
+}
+
+// AddPublicKey adds an RSA or P-256 ECDSA key RS256 and ES256 tokens are
+// verified with. Tokens naming a key id in their kid header are only verified
+// with the key of that id, an empty one matching tokens without kid.
+func (v *Verifier) AddPublicKey(kid string, key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return fmt.Errorf("key %q: only P-256 ECDSA keys are supported", kid)
+		}
+	default:
+		return fmt.Errorf("key %q: unsupported key type %T", kid, key)
+	}
+	v.keys[kid] = key
+
+	return nil
+}
+
+// Verify checks the signature and claims of the given token, returning the
+// principal it authenticates.
+func (v *Verifier) Verify(token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(token, claims, v.key); err != nil {
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Inner != nil {
+			return nil, fmt.Errorf("%v: %v", ErrInvalidToken, ve.Inner)
+		}
+		return nil, fmt.Errorf("%v: %v", ErrInvalidToken, err)
+	}
+
+	now := time.Now().Unix()
+	if !claims.VerifyExpiresAt(now, true) {
+		return nil, fmt.Errorf("%v: missing or past exp", ErrInvalidToken)
+	}
+	if v.issuer != "" && !claims.VerifyIssuer(v.issuer, true) {
+		return nil, fmt.Errorf("%v: unexpected iss", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.VerifyAudience(v.audience, true) {
+		return nil, fmt.Errorf("%v: unexpected aud", ErrInvalidToken)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("%v: missing sub", ErrInvalidToken)
+	}
+
+	organisation, _ := claims[ClaimOrganisation].(string)
+
+	return &Principal{
+		Subject:      subject,
+		Organisation: organisation,
+		Roles:        stringList(claims[ClaimRoles]),
+		Claims:       claims,
+	}, nil
+}
+
+// stringList reads a claim holding either a single string or a list of them.
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	default:
+		return nil
+	}
+}
+
+// key picks the key a token is verified with, making sure it matches the
+// token algorithm so a public key can never be used as an HMAC secret.
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	if token.Method == jwt.SigningMethodHS256 {
+		if len(v.secret) == 0 {
+			return nil, ErrUnknownKey
+		}
+		return v.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := v.keys[kid]
+	if !ok && kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			key, ok = k, true
+		}
+	}
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey:
+		if token.Method == jwt.SigningMethodRS256 {
+			return key, nil
+		}
+	case *ecdsa.PublicKey:
+		if token.Method == jwt.SigningMethodES256 {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("key %q cannot verify %s tokens", kid, token.Method.Alg())
+}
+
+// ParsePublicKeyPEM parses a PEM encoded RSA or ECDSA public key.
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	return nil, errors.New("no RSA or ECDSA public key found")
+}
+
+// jwk JSON Web Key, as defined by RFC 7517, restricted to the fields of RSA
+// and EC public keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS parses the signature keys of a JSON Web Key Set by key id.
+func ParseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %v", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the P-256 curve")
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,152 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return s
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "https://issuer.example",
+		"aud": "payment",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+		"org": "org-1",
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	v := auth.NewVerifier("https://issuer.example", "payment")
+	v.SetSecret([]byte(secret))
+
+	p, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(secret), "", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", p.Subject)
+	assert.Equal(t, "org-1", p.Claims["org"])
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, []byte("another secret of thirty-two bytes"), "", validClaims()))
+	assert.Error(t, err)
+}
+
+func TestVerifyClaims(t *testing.T) {
+	v := auth.NewVerifier("https://issuer.example", "payment")
+	v.SetSecret([]byte(secret))
+
+	tests := map[string]func(jwt.MapClaims){
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"missing exp":    func(c jwt.MapClaims) { delete(c, "exp") },
+		"not yet valid":  func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Hour).Unix() },
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"missing sub":    func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			mutate(claims)
+			_, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(secret), "", claims))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), auth.ErrInvalidToken.Error())
+			}
+		})
+	}
+
+	claims := validClaims()
+	claims["aud"] = []string{"other", "payment"}
+	_, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(secret), "", claims))
+	assert.NoError(t, err, "any of the audiences may match")
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	public, err := auth.ParsePublicKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	v := auth.NewVerifier("", "")
+	require.NoError(t, v.AddPublicKey("", public))
+
+	p, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, "", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", p.Subject)
+
+	// The public key must not be usable as an HMAC secret.
+	_, err = v.Verify(sign(t, jwt.SigningMethodHS256, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), "", validClaims()))
+	assert.Error(t, err)
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS512, key, "", validClaims()))
+	assert.Error(t, err, "only RS256 is accepted")
+}
+
+func TestVerifyES256JWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	b64 := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"EC","kid":"ec-1","use":"sig","crv":"P-256","x":%q,"y":%q},
+		{"kty":"RSA","kid":"rsa-1","n":%q,"e":%q},
+		{"kty":"RSA","kid":"enc-1","use":"enc","n":%q,"e":%q}
+	]}`, b64(ecKey.X), b64(ecKey.Y), b64(rsaKey.N), b64(big.NewInt(int64(rsaKey.E))), b64(rsaKey.N), b64(big.NewInt(int64(rsaKey.E))))
+	keys, err := auth.ParseJWKS([]byte(jwks))
+	require.NoError(t, err)
+	assert.Len(t, keys, 2, "encryption keys are skipped")
+
+	v := auth.NewVerifier("", "")
+	for kid, key := range keys {
+		require.NoError(t, v.AddPublicKey(kid, key))
+	}
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims()))
+	assert.NoError(t, err)
+	_, err = v.Verify(sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims()))
+	assert.NoError(t, err)
+
+	_, err = v.Verify(sign(t, jwt.SigningMethodES256, ecKey, "rsa-1", validClaims()))
+	assert.Error(t, err, "the key of the kid must match the algorithm")
+	_, err = v.Verify(sign(t, jwt.SigningMethodES256, ecKey, "unknown", validClaims()))
+	assert.Error(t, err)
+	_, err = v.Verify(sign(t, jwt.SigningMethodES256, ecKey, "", validClaims()))
+	assert.Error(t, err, "tokens without kid are ambiguous with several keys")
+}
+
+func TestParseJWKSRejectsInvalidKeys(t *testing.T) {
+	_, err := auth.ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"p384","crv":"P-384","x":"AA","y":"AA"}]}`))
+	assert.Error(t, err)
+	_, err = auth.ParseJWKS([]byte(`{"keys":[{"kty":"EC","kid":"off","crv":"P-256","x":"AQ","y":"AQ"}]}`))
+	assert.Error(t, err)
+	_, err = auth.ParseJWKS([]byte(`not json`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// AllPermissions permission granting every other one, e.g. to admins.
+const AllPermissions = "*"
+
+// RoleAPIKey role of the callers authenticated with an API key.
+const RoleAPIKey = "apikey"
+
+// PermissionError error of callers lacking a permission.
+type PermissionError struct {
+	Permission string
+}
+
+func (e *PermissionError) Error() string {
+	return "Missing permission " + e.Permission
+}
+
+// Policy permissions granted to each role.
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+// NewPolicy builds the policy granting each role the given permissions. Role
+// names are case insensitive.
+func NewPolicy(roles map[string][]string) *Policy {
+	p := &Policy{roles: make(map[string]map[string]bool, len(roles))}
+	for role, permissions := range roles {
+		granted := make(map[string]bool, len(permissions))
+		for _, permission := range permissions {
+			granted[permission] = true
+		}
+		p.roles[strings.ToLower(role)] = granted
+	}
+
+	return p
+}
+
+// Allows reports whether one of the principal roles grants the given
+// permission.
+func (p *Policy) Allows(principal *Principal, permission string) bool {
+	for _, role := range principal.Roles {
+		granted := p.roles[strings.ToLower(role)]
+		if granted[permission] || granted[AllPermissions] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authorize checks the principal carried by ctx was granted the given
+// permission, failing with a *PermissionError otherwise. Calls without a
+// principal, only possible when no authentication is configured, are allowed,
+// as is every call when there is no policy.
+func (p *Policy) Authorize(ctx context.Context, permission string) error {
+	principal, ok := FromContext(ctx)
+	if !ok || p == nil || p.Allows(principal, permission) {
+		return nil
+	}
+
+	return &PermissionError{Permission: permission}
+}
+
+// AuthorizeAdmin checks as Authorize the principal carried by ctx was granted
+// the given permission, or has the admin role when there is no policy. Calls
+// without a principal are denied.
+func (p *Policy) AuthorizeAdmin(ctx context.Context, permission string) error {
+	principal, ok := FromContext(ctx)
+	switch {
+	case !ok:
+	case p == nil && principal.HasRole(RoleAdmin), p != nil && p.Allows(principal, permission):
+		return nil
+	}
+
+	return &PermissionError{Permission: permission}
+}
@@ -0,0 +1,50 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+)
+
+func TestPolicy(t *testing.T) {
+	p := auth.NewPolicy(map[string][]string{
+		"operator": {"payments:read", "payments:create"},
+		"approver": {"payments:read", "payments:submit"},
+		"admin":    {auth.AllPermissions},
+	})
+
+	operator := &auth.Principal{Roles: []string{"Operator"}}
+	assert.True(t, p.Allows(operator, "payments:create"))
+	assert.False(t, p.Allows(operator, "payments:submit"))
+	assert.True(t, p.Allows(&auth.Principal{Roles: []string{"operator", "approver"}}, "payments:submit"))
+	assert.True(t, p.Allows(&auth.Principal{Roles: []string{auth.RoleAdmin}}, "payments:delete"))
+	assert.False(t, p.Allows(&auth.Principal{Roles: []string{"auditor"}}, "payments:read"))
+	assert.False(t, p.Allows(&auth.Principal{}, "payments:read"))
+
+	assert.NoError(t, p.Authorize(context.TODO(), "payments:submit"), "unauthenticated calls are allowed")
+	assert.NoError(t, p.Authorize(auth.NewContext(context.TODO(), operator), "payments:create"))
+	err := p.Authorize(auth.NewContext(context.TODO(), operator), "payments:submit")
+	if assert.IsType(t, &auth.PermissionError{}, err) {
+		assert.Equal(t, "Missing permission payments:submit", err.Error())
+	}
+}
+
+func TestAuthorizeAdmin(t *testing.T) {
+	operator := auth.NewContext(context.TODO(), &auth.Principal{Roles: []string{"operator"}})
+	admin := auth.NewContext(context.TODO(), &auth.Principal{Roles: []string{auth.RoleAdmin}})
+	denied := &auth.PermissionError{Permission: "payments:restore"}
+
+	var none *auth.Policy
+	assert.NoError(t, none.Authorize(operator, "payments:restore"), "every call is allowed without a policy")
+	assert.Equal(t, denied, none.AuthorizeAdmin(context.TODO(), "payments:restore"))
+	assert.Equal(t, denied, none.AuthorizeAdmin(operator, "payments:restore"))
+	assert.NoError(t, none.AuthorizeAdmin(admin, "payments:restore"))
+
+	p := auth.NewPolicy(map[string][]string{"operator": {"payments:restore"}})
+	assert.Equal(t, denied, p.AuthorizeAdmin(context.TODO(), "payments:restore"), "unauthenticated calls are denied")
+	assert.NoError(t, p.AuthorizeAdmin(operator, "payments:restore"))
+	assert.Equal(t, denied, p.AuthorizeAdmin(admin, "payments:restore"), "the policy grants the permission")
+}
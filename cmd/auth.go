@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/config"
+	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// newJWTVerifier builds the bearer token verifier from the configured keys,
+// returning nil when none is configured.
+func newJWTVerifier(c config.JWT) (*auth.Verifier, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	v := auth.NewVerifier(c.Issuer, c.Audience)
+	if c.Secret != "" {
+		v.SetSecret([]byte(c.Secret))
+	}
+
+	if c.PublicKeyFile != "" {
+		data, err := ioutil.ReadFile(c.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := auth.ParsePublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("auth.jwt.public_key_file: %v", err)
+		}
+		if err = v.AddPublicKey("", key); err != nil {
+			return nil, fmt.Errorf("auth.jwt.public_key_file: %v", err)
+		}
+	}
+
+	if c.JWKSFile != "" {
+		data, err := ioutil.ReadFile(c.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys, err := auth.ParseJWKS(data)
+		if err != nil {
+			return nil, fmt.Errorf("auth.jwt.jwks_file: %v", err)
+		}
+		for kid, key := range keys {
+			if err = v.AddPublicKey(kid, key); err != nil {
+				return nil, fmt.Errorf("auth.jwt.jwks_file: %v", err)
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// newPolicy builds the authorisation policy from the configured roles,
+// returning nil when none is configured.
+func newPolicy(roles map[string][]string) (*auth.Policy, error) {
+	if len(roles) == 0 {
+		return nil, nil
+	}
+
+	known := map[string]bool{auth.AllPermissions: true}
+	for _, permissions := range [][]string{payment.Permissions(), apikey.Permissions(), webhook.Permissions()} {
+		for _, permission := range permissions {
+			known[permission] = true
+		}
+	}
+
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	for _, role := range names {
+		for _, permission := range roles[role] {
+			if !known[permission] {
+				return nil, fmt.Errorf("auth.roles.%s: unknown permission %q", role, permission)
+			}
+		}
+	}
+
+	return auth.NewPolicy(roles), nil
+}
@@ -0,0 +1,139 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/cmd"
+)
+
+// run executes the command line with the given arguments and input,
+// returning its output.
+func run(t *testing.T, in string, args ...string) (string, error) {
+	root := cmd.NewRootCommand()
+	out := new(bytes.Buffer)
+	root.SetOut(out)
+	root.SetIn(strings.NewReader(in))
+	root.SetArgs(args)
+
+	err := root.Execute()
+
+	return out.String(), err
+}
+
+// writeConfig writes a configuration file with the given database settings.
+func writeConfig(t *testing.T, dir string, database map[string]string) string {
+	config := map[string]interface{}{
+		"server":   map[string]string{"address": ":9090"},
+		"context":  map[string]int{"timeout": 2},
+		"database": database,
+	}
+	content, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(path, content, 0600))
+
+	return path
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cmd")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return dir
+}
+
+func TestVersion(t *testing.T) {
+	out, err := run(t, "", "version", "--config", "missing.json")
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Version+"\n", out)
+}
+
+func TestMissingConfig(t *testing.T) {
+	_, err := run(t, "", "config", "validate", "--config", filepath.Join(tempDir(t), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestConfigValidate(t *testing.T) {
+	dir := tempDir(t)
+
+	config := writeConfig(t, dir, map[string]string{"driver": "memory"})
+	out, err := run(t, "", "config", "validate", "--config", config)
+	require.NoError(t, err)
+	assert.Contains(t, out, "configuration is valid")
+
+	config = writeConfig(t, dir, map[string]string{"driver": "postgres", "host": "localhost"})
+	_, err = run(t, "", "config", "validate", "--config", config)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "database.port is required")
+		assert.Contains(t, err.Error(), "database.name is required")
+	}
+
+	config = writeConfig(t, dir, map[string]string{"driver": "oracle"})
+	_, err = run(t, "", "config", "validate", "--config", config)
+	assert.Error(t, err)
+}
+
+func TestMigrateSeedExportImport(t *testing.T) {
+	dir := tempDir(t)
+	config := writeConfig(t, dir, map[string]string{
+		"driver": "sqlite3",
+		"name":   filepath.Join(dir, "payment.db"),
+	})
+
+	_, err := run(t, "", "migrate", "up", "--config", config)
+	require.NoError(t, err)
+
+	out, err := run(t, "", "migrate", "status", "--config", config)
+	require.NoError(t, err)
+	assert.NotContains(t, out, "pending")
+
+	out, err = run(t, "", "seed", "--config", config)
+	require.NoError(t, err)
+	assert.Contains(t, out, "seeded 6 payments")
+
+	out, err = run(t, "", "seed", "--config", config)
+	require.NoError(t, err)
+	assert.Contains(t, out, "seeded 0 payments")
+
+	exported, err := run(t, "", "export", "--config", config)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(exported), "\n"), 6)
+
+	other := writeConfig(t, tempDir(t), map[string]string{
+		"driver": "sqlite3",
+		"name":   filepath.Join(dir, "other.db"),
+	})
+	_, err = run(t, "", "migrate", "up", "--config", other)
+	require.NoError(t, err)
+
+	out, err = run(t, exported, "import", "--config", other)
+	require.NoError(t, err)
+	assert.Contains(t, out, "imported 6 payments, skipped 0 existing")
+
+	out, err = run(t, exported, "import", "--config", other)
+	require.NoError(t, err)
+	assert.Contains(t, out, "imported 0 payments, skipped 6 existing")
+
+	_, err = run(t, `{"payment_id":"invalid"}`, "import", "--config", other)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "line 1")
+	}
+}
+
+func TestMigrateMemoryDriver(t *testing.T) {
+	config := writeConfig(t, tempDir(t), map[string]string{"driver": "memory"})
+
+	_, err := run(t, "", "migrate", "up", "--config", config)
+	assert.Error(t, err)
+}
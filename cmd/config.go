@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(opts *options) *cobra.Command {
+	config := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the service configuration",
+	}
+
+	config.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration is complete and consistent",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Loading the configuration already validated it.
+			fmt.Fprintf(cmd.OutOrStdout(), "configuration is valid, using the %s database driver\n", opts.config.Database.Driver)
+			return nil
+		},
+	})
+
+	return config
+}
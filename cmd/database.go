@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	// Database drivers selectable with database.driver.
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	apikeyRepo "github.com/adriacidre/go-clean-arch/apikey/repository"
+	"github.com/adriacidre/go-clean-arch/config"
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	"github.com/adriacidre/go-clean-arch/payment"
+	repo "github.com/adriacidre/go-clean-arch/payment/repository"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+	"github.com/adriacidre/go-clean-arch/transaction"
+	"github.com/adriacidre/go-clean-arch/webhook"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+// openDatabase connects to the configured database, returning nil for the
+// memory driver which needs none.
+func openDatabase(c config.Database) (*sql.DB, error) {
+	if c.Driver == "memory" {
+		return nil, nil
+	}
+
+	var dsn string
+	switch c.Driver {
+	case "postgres":
+		val := url.Values{}
+		val.Add("sslmode", c.SSLMode)
+		connection := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Pass),
+			Host:     fmt.Sprintf("%s:%d", c.Host, c.Port),
+			Path:     c.Name,
+			RawQuery: val.Encode(),
+		}
+		dsn = connection.String()
+	case "mysql":
+		connection := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.User, c.Pass, c.Host, c.Port, c.Name)
+
+		val := url.Values{}
+		val.Add("parseTime", "1")
+		val.Add("loc", "Europe/Paris")
+		val.Add("allowNativePasswords", "true")
+		dsn = fmt.Sprintf("%s?%s", connection, val.Encode())
+	case "sqlite3":
+		dsn = c.Name
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", c.Driver)
+	}
+
+	dbConn, err := sql.Open(c.Driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if c.Driver == "sqlite3" {
+		dbConn.SetMaxOpenConns(1)
+	}
+	if err = dbConn.Ping(); err != nil {
+		dbConn.Close()
+		return nil, err
+	}
+
+	return dbConn, nil
+}
+
+// repositories the repositories of the service.
+type repositories struct {
+	payment     payment.Repository
+	idempotency idempotency.Repository
+	apiKey      apikey.Repository
+	outbox      outbox.Repository
+	webhook     webhook.Repository
+	tx          transaction.Manager
+}
+
+// newRepositories builds the repositories backed by the given database driver.
+func newRepositories(driver string, dbConn *sql.DB) repositories {
+	switch driver {
+	case "memory":
+		events := outboxRepo.NewMemoryOutbox()
+		return repositories{
+			payment:     repo.NewMemoryPayment(events),
+			idempotency: idempotencyRepo.NewMemoryIdempotency(),
+			apiKey:      apikeyRepo.NewMemoryAPIKey(),
+			outbox:      events,
+			webhook:     webhookRepo.NewMemoryWebhook(),
+			tx:          transaction.NewMemoryManager(),
+		}
+	case "postgres":
+		return repositories{
+			payment:     repo.NewPgPayment(dbConn),
+			idempotency: idempotencyRepo.NewPgIdempotency(dbConn),
+			apiKey:      apikeyRepo.NewPgAPIKey(dbConn),
+			outbox:      outboxRepo.NewPgOutbox(dbConn),
+			webhook:     webhookRepo.NewPgWebhook(dbConn),
+			tx:          transaction.NewSQLManager(dbConn),
+		}
+	case "sqlite3":
+		return repositories{
+			payment:     repo.NewSqlitePayment(dbConn),
+			idempotency: idempotencyRepo.NewSqliteIdempotency(dbConn),
+			apiKey:      apikeyRepo.NewSqliteAPIKey(dbConn),
+			outbox:      outboxRepo.NewSqliteOutbox(dbConn),
+			webhook:     webhookRepo.NewSqliteWebhook(dbConn),
+			tx:          transaction.NewSQLManager(dbConn),
+		}
+	default:
+		return repositories{
+			payment:     repo.NewMysqlPayment(dbConn),
+			idempotency: idempotencyRepo.NewMysqlIdempotency(dbConn),
+			apiKey:      apikeyRepo.NewMysqlAPIKey(dbConn),
+			outbox:      outboxRepo.NewMysqlOutbox(dbConn),
+			webhook:     webhookRepo.NewMysqlWebhook(dbConn),
+			tx:          transaction.NewSQLManager(dbConn),
+		}
+	}
+}
+
+// withPaymentUsecase opens the configured database and runs fn with a payment
+// use case on top of it, closing the database afterwards.
+func withPaymentUsecase(c *config.Config, fn func(payment.Usecase) error) error {
+	dbConn, err := openDatabase(c.Database)
+	if err != nil {
+		return err
+	}
+	if dbConn != nil {
+		defer dbConn.Close()
+	}
+
+	repos := newRepositories(c.Database.Driver, dbConn)
+
+	return fn(ucase.NewPayment(repos.payment, repos.tx, c.Context.Timeout))
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adriacidre/go-clean-arch/config"
+	"github.com/adriacidre/go-clean-arch/migration"
+)
+
+func newMigrateCommand(opts *options) *cobra.Command {
+	migrate := &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the database schema migrations",
+	}
+
+	migrate.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply every pending migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withMigrator(opts.config, func(m *migration.Migrator) error {
+					run, err := m.Up(context.Background())
+					printMigrations(cmd, run)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down",
+			Short: "Revert the latest applied migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withMigrator(opts.config, func(m *migration.Migrator) error {
+					run, err := m.Down(context.Background())
+					printMigrations(cmd, run)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "to <version>",
+			Short: "Apply or revert migrations until the given version, 0 reverting all",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+
+				return withMigrator(opts.config, func(m *migration.Migrator) error {
+					run, err := m.To(context.Background(), version)
+					printMigrations(cmd, run)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "baseline <version>",
+			Short: "Record the migrations until the given version as applied without running them",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.ParseInt(args[0], 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid version %q", args[0])
+				}
+
+				return withMigrator(opts.config, func(m *migration.Migrator) error {
+					run, err := m.Baseline(context.Background(), version)
+					printMigrations(cmd, run)
+					return err
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "List the migrations and whether they are applied",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withMigrator(opts.config, func(m *migration.Migrator) error {
+					status, err := m.Status(context.Background())
+					if err != nil {
+						return err
+					}
+					for _, s := range status {
+						applied := "pending"
+						if s.Applied {
+							applied = "applied " + s.AppliedAt.Format(time.RFC3339)
+						}
+						fmt.Fprintf(cmd.OutOrStdout(), "%04d %-40s %s\n", s.Version, s.Name, applied)
+					}
+					return nil
+				})
+			},
+		},
+	)
+
+	return migrate
+}
+
+// withMigrator opens the configured database and runs fn with its migrator.
+func withMigrator(c *config.Config, fn func(*migration.Migrator) error) error {
+	if c.Database.Driver == "memory" {
+		return fmt.Errorf("the memory driver has no schema to migrate")
+	}
+
+	dbConn, err := openDatabase(c.Database)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+
+	m, err := migration.NewMigrator(dbConn, c.Database.Driver)
+	if err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+func printMigrations(cmd *cobra.Command, run []migration.Migration) {
+	for _, m := range run {
+		fmt.Fprintf(cmd.OutOrStdout(), "%04d %s\n", m.Version, m.Name)
+	}
+	if len(run) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "no migrations to run")
+	}
+}
@@ -0,0 +1,91 @@
+// Package cmd implements the command line of the payment service binary.
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adriacidre/go-clean-arch/config"
+)
+
+// Version service version, set at build time with
+// -ldflags "-X github.com/adriacidre/go-clean-arch/cmd.Version=<version>".
+var Version = "dev"
+
+// options state shared by the commands, the configuration being loaded
+// before any of them runs.
+type options struct {
+	configFile string
+	config     *config.Config
+}
+
+// NewRootCommand builds the payment service command tree. Running it without
+// a subcommand starts the HTTP server.
+func NewRootCommand() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:           "payment",
+		Short:         "Payment resources REST API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return opts.load(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(opts.config)
+		},
+	}
+	root.PersistentFlags().StringVar(&opts.configFile, "config", "config.json", "configuration file")
+	config.BindFlags(root.PersistentFlags())
+
+	root.AddCommand(
+		newServeCommand(opts),
+		newMigrateCommand(opts),
+		newSeedCommand(opts),
+		newImportCommand(opts),
+		newExportCommand(opts),
+		newConfigCommand(opts),
+		newVersionCommand(),
+	)
+
+	return root
+}
+
+// Execute runs the command line, exiting with a non-zero status on failure.
+func Execute() {
+	if err := NewRootCommand().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// load reads the configuration from the file, environment and flags.
+func (o *options) load(cmd *cobra.Command) error {
+	c, err := config.Load(o.configFile, cmd.Flags())
+	if err != nil {
+		return err
+	}
+	o.config = c
+
+	if c.Debug {
+		fmt.Fprintln(cmd.ErrOrStderr(), "Service RUN on DEBUG mode")
+	}
+
+	return nil
+}
+
+func newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Print the service version",
+		Args:  cobra.NoArgs,
+		// The version is known without any configuration.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintln(cmd.OutOrStdout(), Version)
+		},
+	}
+}
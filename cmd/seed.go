@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+)
+
+func newSeedCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "seed",
+		Short: "Store sample payments for local development",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withPaymentUsecase(opts.config, func(u payment.Usecase) error {
+				stored, err := seed(context.Background(), u)
+				fmt.Fprintf(cmd.OutOrStdout(), "seeded %d payments\n", stored)
+				return err
+			})
+		},
+	}
+}
+
+// seed stores the sample payments, skipping the ones already stored so it can
+// be run repeatedly.
+func seed(ctx context.Context, u payment.Usecase) (int, error) {
+	stored := 0
+	for i := 1; i <= 6; i++ {
+		p := &models.Payment{
+			PaymentID:    fmt.Sprintf("12345678901234567%d", i),
+			Organisation: "43d5b63-8e6f-432e-a8fa-c5d8d2ee5fcb",
+			Amount:       fmt.Sprintf("%d00.00", i),
+			Currency:     "GBP",
+			Debtor: models.Party{
+				Name:          "EJ Brown Black",
+				AccountNumber: "GB29XABC10161234567801",
+				AccountScheme: "IBAN",
+				BankID:        "203301",
+			},
+			Beneficiary: models.Party{
+				Name:          "Wilfred Jeremiah Owens",
+				AccountNumber: "31926819",
+				AccountScheme: "BBAN",
+				BankID:        "403000",
+			},
+			Scheme:     "FPS",
+			Reference:  "Payment for Em's piano lessons",
+			EndToEndID: "Wil piano Jan",
+		}
+
+		_, err := u.Store(ctx, p)
+		if err == models.ErrConflict {
+			continue
+		}
+		if err != nil {
+			return stored, err
+		}
+		stored++
+	}
+
+	return stored, nil
+}
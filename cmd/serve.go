@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	apikeyDeliver "github.com/adriacidre/go-clean-arch/apikey/delivery/http"
+	apikeyUcase "github.com/adriacidre/go-clean-arch/apikey/usecase"
+	"github.com/adriacidre/go-clean-arch/config"
+	"github.com/adriacidre/go-clean-arch/health"
+	healthDeliver "github.com/adriacidre/go-clean-arch/health/delivery/http"
+	"github.com/adriacidre/go-clean-arch/metrics"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	"github.com/adriacidre/go-clean-arch/outbox/publisher"
+	"github.com/adriacidre/go-clean-arch/outbox/relay"
+	"github.com/adriacidre/go-clean-arch/payment"
+	httpDeliver "github.com/adriacidre/go-clean-arch/payment/delivery/http"
+	"github.com/adriacidre/go-clean-arch/payment/purger"
+	repo "github.com/adriacidre/go-clean-arch/payment/repository"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/webhook"
+	webhookDeliver "github.com/adriacidre/go-clean-arch/webhook/delivery/http"
+	"github.com/adriacidre/go-clean-arch/webhook/dispatcher"
+	webhookUcase "github.com/adriacidre/go-clean-arch/webhook/usecase"
+)
+
+// shutdownFunc releases a resource once the server stopped serving requests.
+type shutdownFunc func(ctx context.Context) error
+
+func newServeCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the HTTP server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return serve(opts.config)
+		},
+	}
+}
+
+// serve starts the HTTP server, blocking until SIGINT or SIGTERM is received
+// and the server has shut down gracefully.
+func serve(c *config.Config) error {
+	tp, err := tracing.NewProvider(context.Background(), c.Tracing.Exporter, c.Tracing.Endpoint, "payment", Version, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := newJWTVerifier(c.Auth.JWT)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return err
+	}
+	if verifier == nil && !c.Auth.Disabled {
+		_ = tp.Shutdown(context.Background())
+		return errors.New("no auth.jwt key configured: set one, or auth.disabled to serve the API unauthenticated")
+	}
+
+	policy, err := newPolicy(c.Auth.Roles)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return err
+	}
+
+	guard, err := webhook.NewGuard(c.Webhook.AllowedHosts)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return err
+	}
+
+	proxies, err := middleware.ParseTrustedProxies(c.RateLimit.TrustedProxies)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return err
+	}
+
+	dbConn, err := openDatabase(c.Database)
+	if err != nil {
+		_ = tp.Shutdown(context.Background())
+		return err
+	}
+
+	reg := metrics.NewRegistry()
+	if dbConn != nil {
+		metrics.RegisterDBStats(reg, dbConn, c.Database.Name)
+	}
+
+	repos := newRepositories(c.Database.Driver, dbConn)
+	ar := repo.NewTracedPayment(repos.payment, tp.Tracer("github.com/adriacidre/go-clean-arch/payment/repository"), c.Database.Driver)
+	ar = repo.NewInstrumentedPayment(ar, metrics.NewCalls(reg, "repository"))
+	au := ucase.NewPayment(ar, repos.tx, c.Context.Timeout)
+	au = ucase.NewAuthorizedPayment(au, policy)
+	au = ucase.NewTracedPayment(au, tp.Tracer("github.com/adriacidre/go-clean-arch/payment/usecase"))
+	au = ucase.NewInstrumentedPayment(au, metrics.NewCalls(reg, "usecase"))
+	ku := apikeyUcase.NewAPIKey(repos.apiKey, c.Context.Timeout)
+	ku = apikeyUcase.NewAuthorizedAPIKey(ku, policy)
+	wu := webhookUcase.NewWebhook(repos.webhook, guard, c.Context.Timeout)
+	wu = webhookUcase.NewAuthorizedWebhook(wu, policy)
+
+	logger := logrus.StandardLogger()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	if c.Debug {
+		logger.SetLevel(logrus.DebugLevel)
+	}
+
+	e := echo.New()
+	e.Debug = true
+	middL := middleware.InitMiddleware()
+	middL.Logger = logger
+	middL.JWTVerifier = verifier
+	middL.AuthDisabled = c.Auth.Disabled
+	if c.Auth.Disabled {
+		logger.Warn("auth.disabled is set, the API is not authenticated")
+	}
+	middL.APIKeys = ku
+	middL.IdempotencyStore = repos.idempotency
+	middL.IdempotencyTTL = c.Idempotency.TTL
+	middL.HTTPMetrics = metrics.NewHTTP(reg)
+	middL.TracerProvider = tp
+	middL.RateLimiter = ratelimit.NewMemoryStore()
+	middL.RateLimits = c.RateLimit.Routes
+	middL.DefaultRateLimit = c.RateLimit.Default
+	middL.IPRateLimit = c.RateLimit.IP
+	middL.TrustedProxies = proxies
+	middL.CORSPolicy = middleware.CORSPolicy{
+		AllowedOrigins:   c.CORS.AllowedOrigins,
+		AllowedMethods:   c.CORS.AllowedMethods,
+		AllowedHeaders:   c.CORS.AllowedHeaders,
+		ExposedHeaders:   c.CORS.ExposedHeaders,
+		AllowCredentials: c.CORS.AllowCredentials,
+		MaxAge:           c.CORS.MaxAge,
+	}
+	e.Use(middL.RequestLogger)
+	e.Use(middL.Tracing)
+	e.Use(middL.Metrics)
+	e.Use(middL.CORS)
+
+	hc := health.New()
+	if dbConn != nil {
+		hc.AddCheck("database", dbConn.PingContext)
+	}
+	healthDeliver.NewHealthHTTPHandler(e, hc)
+	// Client IPs are limited before authentication, so failed attempts count,
+	// and responses are only replayed to authenticated callers.
+	httpDeliver.NewPaymentHTTPHandler(e, au, middL.RateLimitIP, middL.APIKey, middL.JWT, middL.Tenant, middL.RateLimit,
+		middL.RequireScope(models.ScopePaymentsRead, models.ScopePaymentsWrite), middL.Idempotency)
+	apikeyDeliver.NewAPIKeyHTTPHandler(e, ku, middL.RateLimitIP, middL.APIKey, middL.JWT, middL.Tenant, middL.RateLimit,
+		middL.RequireScope(models.ScopeAPIKeysRead, models.ScopeAPIKeysWrite))
+	webhookDeliver.NewWebhookHTTPHandler(e, wu, middL.RateLimitIP, middL.APIKey, middL.JWT, middL.Tenant, middL.RateLimit,
+		middL.RequireScope(models.ScopeWebhooksRead, models.ScopeWebhooksWrite))
+	e.GET("/metrics", echo.WrapHandler(metrics.Handler(reg)))
+
+	// The outbox relay, the webhook dispatcher and the purger are stopped
+	// first, then the database pool is closed once none of them can use it
+	// anymore and pending spans are flushed last.
+	workers := make([]shutdownFunc, 0)
+	publishers := make([]outbox.Publisher, 0)
+	if c.Outbox.Publishes("log") {
+		publishers = append(publishers, publisher.NewLogPublisher(logger))
+	}
+	if c.Outbox.Publishes("webhook") {
+		publishers = append(publishers, dispatcher.NewPublisher(repos.webhook))
+		d := dispatcher.NewDispatcher(repos.webhook, guard.Client(c.Webhook.Timeout),
+			c.Webhook.Interval, int64(c.Webhook.BatchSize), c.Webhook.MaxAttempts)
+		d.Start()
+		workers = append(workers, d.Shutdown)
+	}
+	if len(publishers) > 0 {
+		r := relay.NewRelay(repos.outbox, publisher.NewMultiPublisher(publishers...), c.Outbox.Interval, int64(c.Outbox.BatchSize))
+		r.Start()
+		workers = append([]shutdownFunc{r.Shutdown}, workers...)
+	}
+	// Deleted payments are only purged once a retention is set, while expired
+	// idempotent responses always are.
+	var purged payment.Repository
+	if c.Purge.Retention > 0 {
+		purged = ar
+	}
+	p := purger.NewPurger(purged, repos.idempotency, c.Purge.Retention, c.Purge.Interval, int64(c.Purge.BatchSize))
+	p.Start()
+	workers = append(workers, p.Shutdown)
+	closeDatabase := func() error { return nil }
+	if dbConn != nil {
+		closeDatabase = dbConn.Close
+	}
+	shutdown := []shutdownFunc{stopWorkers(workers, closeDatabase), tp.Shutdown}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return runServer(ctx, e, c.Server.Address, c.Server.ShutdownTimeout, hc.ShuttingDown, shutdown...)
+}
+
+// runServer serves requests on e until ctx is done. It then calls stopping,
+// stops accepting connections, waits up to timeout for the in-flight requests
+// to finish and runs the shutdown functions in order, giving them up to
+// timeout again, returning the first error found.
+func runServer(ctx context.Context, e *echo.Echo, address string, timeout time.Duration, stopping func(), shutdown ...shutdownFunc) error {
+	started := make(chan error, 1)
+	go func() {
+		started <- e.Start(address)
+	}()
+
+	var err error
+	select {
+	case err = <-started:
+		// The server could not start, so there is nothing to drain.
+		runShutdown(context.Background(), shutdown)
+		return err
+	case <-ctx.Done():
+	}
+
+	logrus.Info("Shutting down, waiting up to ", timeout, " for in-flight requests")
+	if stopping != nil {
+		stopping()
+	}
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), timeout)
+	defer cancelDrain()
+
+	err = e.Shutdown(drainCtx)
+	if err != nil {
+		logrus.Error(err)
+	}
+
+	// Draining may have used up its whole budget, which would leave none to
+	// the shutdown functions.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if serr := runShutdown(shutdownCtx, shutdown); err == nil {
+		err = serr
+	}
+	if serr := <-started; serr != nil && serr != http.ErrServerClosed && err == nil {
+		err = serr
+	}
+
+	return err
+}
+
+// runShutdown runs every shutdown function even when some fail, returning
+// the first error.
+func runShutdown(ctx context.Context, shutdown []shutdownFunc) error {
+	var first error
+	for _, fn := range shutdown {
+		if err := fn(ctx); err != nil {
+			logrus.Error(err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+
+	return first
+}
+
+// stopWorkers returns a shutdown function stopping the given workers, then
+// calling release only once every one of them stopped, as the others may
+// still be using what it releases, e.g. the database pool.
+func stopWorkers(workers []shutdownFunc, release func() error) shutdownFunc {
+	return func(ctx context.Context) error {
+		if err := runShutdown(ctx, workers); err != nil {
+			logrus.Warn("Workers still running, leaving the database open")
+			return err
+		}
+
+		return release()
+	}
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowServer builds a server whose /slow requests block until release is
+// closed, signalling on inFlight once they are being handled.
+func slowServer(t *testing.T, inFlight chan<- struct{}, release <-chan struct{}) (*echo.Echo, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	e := echo.New()
+	e.HideBanner = true
+	e.Listener = listener
+	e.GET("/slow", func(c echo.Context) error {
+		inFlight <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "done")
+	})
+
+	return e, "http://" + listener.Addr().String()
+}
+
+func TestRunServerDrainsInFlightRequests(t *testing.T) {
+	inFlight := make(chan struct{}, 1)
+	release := make(chan struct{})
+	e, url := slowServer(t, inFlight, release)
+
+	var mu sync.Mutex
+	steps := make([]string, 0)
+	step := func(name string) shutdownFunc {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			steps = append(steps, name)
+			return nil
+		}
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runServer(ctx, e, "", 5*time.Second, func() { step("stopping")(ctx) }, step("flush"), step("close database"))
+	}()
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	responses := make(chan result, 1)
+	go func() {
+		res, err := http.Get(url + "/slow")
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		responses <- result{status: res.StatusCode, body: string(body), err: err}
+	}()
+
+	<-inFlight
+	stop()
+
+	// New connections are refused while the in-flight request is drained.
+	// Connections still accepted meanwhile are closed, as the server would
+	// otherwise wait for them on shutdown.
+	assert.Eventually(t, func() bool {
+		conn, err := net.DialTimeout("tcp", url[len("http://"):], 100*time.Millisecond)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"stopping"}, steps, "shutdown functions must wait for in-flight requests")
+	mu.Unlock()
+
+	close(release)
+	res := <-responses
+	require.NoError(t, res.err)
+	assert.Equal(t, http.StatusOK, res.status)
+	assert.Equal(t, "done", res.body)
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []string{"stopping", "flush", "close database"}, steps)
+}
+
+func TestRunServerDeadline(t *testing.T) {
+	inFlight := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+	e, url := slowServer(t, inFlight, release)
+
+	closed := false
+	var closeErr error
+	closeDatabase := func(ctx context.Context) error {
+		closed = true
+		closeErr = ctx.Err()
+		return nil
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runServer(ctx, e, "", 50*time.Millisecond, nil, closeDatabase)
+	}()
+
+	go func() {
+		res, err := http.Get(url + "/slow")
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+
+	<-inFlight
+	stop()
+
+	err := <-done
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, closed, "resources must be released even when the deadline is exceeded")
+	assert.NoError(t, closeErr, "shutdown functions get their own timeout")
+}
+
+func TestStopWorkers(t *testing.T) {
+	stuck := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	stopped := func(ctx context.Context) error { return nil }
+
+	released := false
+	release := func() error {
+		released = true
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, stopWorkers([]shutdownFunc{stopped, stuck}, release)(ctx))
+	assert.False(t, released, "the database must outlive the workers using it")
+
+	assert.NoError(t, stopWorkers([]shutdownFunc{stopped}, release)(context.Background()))
+	assert.True(t, released)
+}
+
+func TestRunServerStartFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	e := echo.New()
+	e.HideBanner = true
+
+	closed := false
+	err = runServer(context.Background(), e, listener.Addr().String(), time.Second, nil, func(ctx context.Context) error {
+		closed = true
+		return errors.New("ignored")
+	})
+	assert.Error(t, err)
+	assert.True(t, closed)
+}
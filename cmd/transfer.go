@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+)
+
+// exportPageSize number of payments fetched per page when exporting.
+const exportPageSize = 100
+
+func newImportCommand(opts *options) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Store the payments of a JSON lines file, one payment per line",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := cmd.InOrStdin()
+			if file != "" && file != "-" {
+				f, err := os.Open(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			return withPaymentUsecase(opts.config, func(u payment.Usecase) error {
+				stored, skipped, err := importPayments(context.Background(), u, in)
+				fmt.Fprintf(cmd.OutOrStdout(), "imported %d payments, skipped %d existing\n", stored, skipped)
+				return err
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "file to read, - for standard input")
+
+	return cmd
+}
+
+func newExportCommand(opts *options) *cobra.Command {
+	var file string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write every payment as a JSON lines file, one payment per line",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			out := cmd.OutOrStdout()
+			if file != "" && file != "-" {
+				f, err := os.Create(file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				out = f
+			}
+
+			return withPaymentUsecase(opts.config, func(u payment.Usecase) error {
+				return exportPayments(context.Background(), u, out)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "-", "file to write, - for standard output")
+
+	return cmd
+}
+
+// importPayments validates and stores every payment read from in. Payments
+// whose payment ID is already stored are skipped, and imported ones start
+// their lifecycle again as created.
+func importPayments(ctx context.Context, u payment.Usecase, in io.Reader) (stored, skipped int, err error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var p models.Payment
+		if err = json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			return stored, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+		if err = p.Validate(); err != nil {
+			return stored, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		p.ID = 0
+		_, err = u.Store(ctx, &p)
+		if err == models.ErrConflict {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return stored, skipped, fmt.Errorf("line %d: %v", line, err)
+		}
+		stored++
+	}
+
+	return stored, skipped, scanner.Err()
+}
+
+// exportPayments writes every stored payment to out, paging through them.
+func exportPayments(ctx context.Context, u payment.Usecase, out io.Writer) error {
+	encoder := json.NewEncoder(out)
+	cursor := ""
+	for {
+		list, next, err := u.Fetch(ctx, cursor, exportPageSize)
+		if err != nil {
+			return err
+		}
+		for _, p := range list {
+			if err = encoder.Encode(p); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
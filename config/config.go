@@ -0,0 +1,661 @@
+// Package config loads the typed service configuration from a file,
+// environment variables and command line flags.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+)
+
+// EnvPrefix prefix of the environment variables overriding configuration keys,
+// database.host being read from PAYMENT_DATABASE_HOST.
+const EnvPrefix = "PAYMENT"
+
+// secretFileSuffix suffix of the keys naming a file to read a secret from.
+const secretFileSuffix = "_file"
+
+// Config service configuration.
+type Config struct {
+	Debug       bool
+	Server      Server
+	Context     Context
+	Idempotency Idempotency
+	Database    Database
+	Tracing     Tracing
+	Auth        Auth
+	CORS        CORS
+	RateLimit   RateLimit
+	Outbox      Outbox
+	Webhook     Webhook
+	Purge       Purge
+}
+
+// Server HTTP server configuration.
+type Server struct {
+	Address         string
+	ShutdownTimeout time.Duration
+}
+
+// Context request handling configuration.
+type Context struct {
+	Timeout time.Duration
+}
+
+// Idempotency Idempotency-Key handling configuration.
+type Idempotency struct {
+	TTL time.Duration
+}
+
+// Database database connection configuration.
+type Database struct {
+	Driver  string
+	Host    string
+	Port    int
+	User    string
+	Pass    string
+	Name    string
+	SSLMode string
+}
+
+// Tracing span export configuration.
+type Tracing struct {
+	Exporter string
+	Endpoint string
+}
+
+// Auth API authentication and authorisation configuration.
+type Auth struct {
+	// Disabled serves the API without authentication, which is otherwise
+	// required to start the server.
+	Disabled bool
+	JWT      JWT
+	// Roles permissions granted to each role, authenticated callers being
+	// allowed everything when empty.
+	Roles map[string][]string
+}
+
+// JWT bearer token verification configuration, tokens being required once
+// any key is set.
+type JWT struct {
+	Secret        string
+	PublicKeyFile string
+	JWKSFile      string
+	Issuer        string
+	Audience      string
+}
+
+// Enabled reports whether a key to verify tokens with is configured.
+func (j JWT) Enabled() bool {
+	return j.Secret != "" || j.PublicKeyFile != "" || j.JWKSFile != ""
+}
+
+// CORS cross-origin resource sharing configuration, denying cross-origin
+// access when no origin is allowed.
+type CORS struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// RateLimit per client rate limiting configuration.
+type RateLimit struct {
+	// Default limit of the routes missing from Routes, unlimited when zero.
+	Default ratelimit.Limit
+	// Routes limits of the routes named by their method and path, e.g.
+	// "POST /payment".
+	Routes map[string]ratelimit.Limit
+	// IP limit of the requests of each client IP, taken before
+	// authentication, unlimited when zero.
+	IP ratelimit.Limit
+	// TrustedProxies IP addresses or CIDR networks of the proxies whose
+	// forwarded client IP headers are trusted.
+	TrustedProxies []string
+}
+
+// Outbox domain events relay configuration.
+type Outbox struct {
+	// Publishers publishers relaying the events: log and webhook, or none to
+	// leave them on the outbox.
+	Publishers []string
+	Interval   time.Duration
+	BatchSize  int
+}
+
+// Publishes reports whether the events are relayed to the named publisher.
+func (o Outbox) Publishes(name string) bool {
+	for _, publisher := range o.Publishers {
+		if publisher == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Purge deleted payments and expired idempotency keys purge configuration.
+type Purge struct {
+	// Retention time deleted payments are kept for, and can be restored,
+	// before being purged. They are never purged when zero.
+	Retention time.Duration
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Webhook webhook deliveries configuration.
+type Webhook struct {
+	Interval  time.Duration
+	BatchSize int
+	// MaxAttempts attempts of a delivery before leaving it dead.
+	MaxAttempts int
+	Timeout     time.Duration
+	// AllowedHosts hosts, named or given as IP addresses or CIDR networks,
+	// webhooks may reach over http and on private addresses.
+	AllowedHosts []string
+}
+
+// key a configuration key with its default value and description.
+type key struct {
+	name         string
+	defaultValue interface{}
+	usage        string
+	secret       bool
+}
+
+// keys every known configuration key.
+var keys = []key{
+	{name: "debug", defaultValue: false, usage: "run in debug mode"},
+	{name: "server.address", defaultValue: ":9090", usage: "address the HTTP server listens on"},
+	{name: "server.shutdown_timeout", defaultValue: 10, usage: "seconds in-flight requests are waited for on shutdown"},
+	{name: "context.timeout", defaultValue: 2, usage: "request timeout in seconds"},
+	{name: "idempotency.ttl", defaultValue: 86400, usage: "seconds an idempotency key is kept for"},
+	{name: "database.driver", defaultValue: "mysql", usage: "database driver: mysql, postgres, sqlite3 or memory"},
+	{name: "database.host", defaultValue: "", usage: "database host"},
+	{name: "database.port", defaultValue: "", usage: "database port"},
+	{name: "database.user", defaultValue: "", usage: "database user"},
+	{name: "database.pass", defaultValue: "", usage: "database password", secret: true},
+	{name: "database.name", defaultValue: "", usage: "database name, or file path for sqlite3"},
+	{name: "database.sslmode", defaultValue: "disable", usage: "postgres sslmode"},
+	{name: "tracing.exporter", defaultValue: "none", usage: "span exporter: none, stdout or otlp"},
+	{name: "tracing.endpoint", defaultValue: "", usage: "OTLP/HTTP collector URL, e.g. http://localhost:4318"},
+	{name: "auth.disabled", defaultValue: false, usage: "serve the API without authentication, for local development only"},
+	{name: "auth.jwt.secret", defaultValue: "", usage: "HS256 token secret", secret: true},
+	{name: "auth.jwt.public_key_file", defaultValue: "", usage: "PEM RSA or ECDSA public key verifying RS256 and ES256 tokens"},
+	{name: "auth.jwt.jwks_file", defaultValue: "", usage: "JSON Web Key Set verifying RS256 and ES256 tokens"},
+	{name: "auth.jwt.issuer", defaultValue: "", usage: "required token iss claim"},
+	{name: "auth.jwt.audience", defaultValue: "", usage: "required token aud claim"},
+	{name: "cors.allowed_origins", defaultValue: "", usage: "comma separated origins allowed to call the API, * for any"},
+	{name: "cors.allowed_methods", defaultValue: "GET,HEAD,POST,PATCH,DELETE", usage: "comma separated methods allowed on cross-origin requests"},
+	{name: "cors.allowed_headers", defaultValue: "Accept,Content-Type,Authorization,Access-Token,Idempotency-Key,If-Match,If-None-Match,X-Organisation,X-Request-ID", usage: "comma separated headers allowed on cross-origin requests"},
+	{name: "cors.exposed_headers", defaultValue: "ETag,X-Cursor,X-Request-ID", usage: "comma separated response headers exposed to cross-origin scripts"},
+	{name: "cors.allow_credentials", defaultValue: false, usage: "allow cross-origin requests with credentials"},
+	{name: "cors.max_age", defaultValue: 600, usage: "seconds browsers may cache preflight responses for"},
+	{name: "ratelimit.default", defaultValue: "", usage: "requests/period each client can send to every route, e.g. 600/1m"},
+	{name: "ratelimit.ip", defaultValue: "", usage: "requests/period each IP address can send, counted before authentication, e.g. 1200/1m"},
+	{name: "ratelimit.trusted_proxies", defaultValue: "", usage: "comma separated IP addresses or CIDR networks of the proxies whose X-Forwarded-For and X-Real-IP headers are trusted"},
+	{name: "outbox.publisher", defaultValue: "log,webhook", usage: "comma separated publishers relaying the domain events: log and webhook, or none"},
+	{name: "outbox.interval", defaultValue: 1, usage: "seconds between outbox polls"},
+	{name: "outbox.batch_size", defaultValue: 100, usage: "events relayed per outbox poll"},
+	{name: "webhook.interval", defaultValue: 1, usage: "seconds between polls for due webhook deliveries"},
+	{name: "webhook.batch_size", defaultValue: 100, usage: "webhook deliveries made per poll"},
+	{name: "webhook.max_attempts", defaultValue: 10, usage: "attempts of a webhook delivery before giving up on it"},
+	{name: "webhook.timeout", defaultValue: 10, usage: "seconds a webhook is given to answer"},
+	{name: "webhook.allowed_hosts", defaultValue: "", usage: "comma separated hosts, IP addresses or CIDR networks webhooks may reach over http and on private addresses"},
+	{name: "purge.retention", defaultValue: 0, usage: "days deleted payments are kept for before being purged, 0 keeping them forever"},
+	{name: "purge.interval", defaultValue: 3600, usage: "seconds between purges of the deleted payments and expired idempotency keys"},
+	{name: "purge.batch_size", defaultValue: 100, usage: "deleted payments and expired idempotency keys purged per purge"},
+}
+
+// mappings keys holding a map, only settable on the file, whose entries are
+// known keys too.
+var mappings = []string{"auth.roles", "ratelimit.routes"}
+
+// BindFlags defines a flag for every configuration key on the given flag set,
+// named like the key, e.g. --database.host.
+func BindFlags(flags *pflag.FlagSet) {
+	for _, k := range keys {
+		flags.String(k.name, "", k.usage)
+		if k.secret {
+			flags.String(k.name+secretFileSuffix, "", "file to read the "+k.usage+" from")
+		}
+	}
+}
+
+// Load reads the configuration with increasing precedence from the defaults,
+// the file at path, PAYMENT_* environment variables and the flags set on the
+// given flag set, which may be nil. Secrets such as database.pass can also be
+// read from the file named by their _file key, e.g. PAYMENT_DATABASE_PASS_FILE.
+// The resulting configuration is validated, the error naming every missing or
+// malformed key.
+func Load(path string, flags *pflag.FlagSet) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	for _, k := range keys {
+		v.SetDefault(k.name, k.defaultValue)
+		if flags != nil {
+			bindFlag(v, flags, k.name)
+			if k.secret {
+				bindFlag(v, flags, k.name+secretFileSuffix)
+			}
+		}
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+
+	l := &loader{v: v}
+	l.unknownKeys()
+
+	c := &Config{
+		Debug: l.bool("debug"),
+		Server: Server{
+			Address:         l.string("server.address"),
+			ShutdownTimeout: time.Duration(l.int("server.shutdown_timeout")) * time.Second,
+		},
+		Context: Context{
+			Timeout: time.Duration(l.int("context.timeout")) * time.Second,
+		},
+		Idempotency: Idempotency{
+			TTL: time.Duration(l.int("idempotency.ttl")) * time.Second,
+		},
+		Database: Database{
+			Driver:  l.string("database.driver"),
+			Host:    l.string("database.host"),
+			Port:    l.int("database.port"),
+			User:    l.string("database.user"),
+			Pass:    l.secret("database.pass"),
+			Name:    l.string("database.name"),
+			SSLMode: l.string("database.sslmode"),
+		},
+		Tracing: Tracing{
+			Exporter: l.string("tracing.exporter"),
+			Endpoint: l.string("tracing.endpoint"),
+		},
+		Auth: Auth{
+			Disabled: l.bool("auth.disabled"),
+			JWT: JWT{
+				Secret:        l.secret("auth.jwt.secret"),
+				PublicKeyFile: l.string("auth.jwt.public_key_file"),
+				JWKSFile:      l.string("auth.jwt.jwks_file"),
+				Issuer:        l.string("auth.jwt.issuer"),
+				Audience:      l.string("auth.jwt.audience"),
+			},
+			Roles: l.v.GetStringMapStringSlice("auth.roles"),
+		},
+		CORS: CORS{
+			AllowedOrigins:   l.list("cors.allowed_origins"),
+			AllowedMethods:   l.list("cors.allowed_methods"),
+			AllowedHeaders:   l.list("cors.allowed_headers"),
+			ExposedHeaders:   l.list("cors.exposed_headers"),
+			AllowCredentials: l.bool("cors.allow_credentials"),
+			MaxAge:           time.Duration(l.int("cors.max_age")) * time.Second,
+		},
+		RateLimit: RateLimit{
+			Default:        l.limit("ratelimit.default"),
+			Routes:         l.routeLimits("ratelimit.routes"),
+			IP:             l.limit("ratelimit.ip"),
+			TrustedProxies: l.list("ratelimit.trusted_proxies"),
+		},
+		Outbox: Outbox{
+			Publishers: l.list("outbox.publisher"),
+			Interval:   time.Duration(l.int("outbox.interval")) * time.Second,
+			BatchSize:  l.int("outbox.batch_size"),
+		},
+		Webhook: Webhook{
+			Interval:     time.Duration(l.int("webhook.interval")) * time.Second,
+			BatchSize:    l.int("webhook.batch_size"),
+			MaxAttempts:  l.int("webhook.max_attempts"),
+			Timeout:      time.Duration(l.int("webhook.timeout")) * time.Second,
+			AllowedHosts: l.list("webhook.allowed_hosts"),
+		},
+		Purge: Purge{
+			Retention: time.Duration(l.int("purge.retention")) * 24 * time.Hour,
+			Interval:  time.Duration(l.int("purge.interval")) * time.Second,
+			BatchSize: l.int("purge.batch_size"),
+		},
+	}
+
+	l.problems = append(l.problems, c.problems()...)
+	if len(l.problems) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %s", strings.Join(l.problems, "; "))
+	}
+
+	return c, nil
+}
+
+// Validate checks the configuration has every setting the service needs.
+func (c *Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (c *Config) problems() []string {
+	problems := make([]string, 0)
+	if c.Server.Address == "" {
+		problems = append(problems, "server.address is required")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "server.shutdown_timeout must be a positive number of seconds")
+	}
+	if c.Context.Timeout <= 0 {
+		problems = append(problems, "context.timeout must be a positive number of seconds")
+	}
+	if c.Idempotency.TTL < 0 {
+		problems = append(problems, "idempotency.ttl must not be negative")
+	}
+
+	switch c.Database.Driver {
+	case "memory":
+	case "sqlite3":
+		if c.Database.Name == "" {
+			problems = append(problems, "database.name is required")
+		}
+	case "mysql", "postgres":
+		if c.Database.Host == "" {
+			problems = append(problems, "database.host is required")
+		}
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			problems = append(problems, "database.port is required and must be between 1 and 65535")
+		}
+		if c.Database.User == "" {
+			problems = append(problems, "database.user is required")
+		}
+		if c.Database.Name == "" {
+			problems = append(problems, "database.name is required")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver %q is not one of mysql, postgres, sqlite3 or memory", c.Database.Driver))
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			problems = append(problems, "tracing.endpoint is required by the otlp exporter")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter %q is not one of none, stdout or otlp", c.Tracing.Exporter))
+	}
+
+	if c.Auth.Disabled && c.Auth.JWT.Enabled() {
+		problems = append(problems, "auth.disabled cannot be combined with an auth.jwt key")
+	}
+	// HMAC secrets shorter than the SHA-256 output are easy to brute force.
+	if c.Auth.JWT.Secret != "" && len(c.Auth.JWT.Secret) < 32 {
+		problems = append(problems, "auth.jwt.secret must be at least 32 bytes long")
+	}
+
+	// Browsers refuse credentials along with the * origin, and echoing any
+	// origin instead would let every site act on behalf of the user.
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				problems = append(problems, "cors.allow_credentials cannot be used with the * origin")
+				break
+			}
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		problems = append(problems, "cors.max_age must not be negative")
+	}
+
+	relayed := false
+	for _, publisher := range c.Outbox.Publishers {
+		switch publisher {
+		case "none":
+			if len(c.Outbox.Publishers) > 1 {
+				problems = append(problems, "outbox.publisher none cannot be combined with other publishers")
+			}
+		case "log", "webhook":
+			relayed = true
+		default:
+			problems = append(problems, fmt.Sprintf("outbox.publisher %q is not one of log, webhook or none", publisher))
+		}
+	}
+	if relayed {
+		if c.Outbox.Interval <= 0 {
+			problems = append(problems, "outbox.interval must be a positive number of seconds")
+		}
+		if c.Outbox.BatchSize <= 0 {
+			problems = append(problems, "outbox.batch_size must be positive")
+		}
+	}
+	if c.Outbox.Publishes("webhook") {
+		if c.Webhook.Interval <= 0 {
+			problems = append(problems, "webhook.interval must be a positive number of seconds")
+		}
+		if c.Webhook.BatchSize <= 0 {
+			problems = append(problems, "webhook.batch_size must be positive")
+		}
+		if c.Webhook.MaxAttempts <= 0 {
+			problems = append(problems, "webhook.max_attempts must be positive")
+		}
+		if c.Webhook.Timeout <= 0 {
+			problems = append(problems, "webhook.timeout must be a positive number of seconds")
+		}
+	}
+	for _, host := range c.Webhook.AllowedHosts {
+		if strings.Contains(host, "/") {
+			if _, _, err := net.ParseCIDR(host); err != nil {
+				problems = append(problems, fmt.Sprintf("webhook.allowed_hosts %q is not a valid CIDR network", host))
+			}
+		}
+	}
+
+	for _, proxy := range c.RateLimit.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problems = append(problems, fmt.Sprintf("ratelimit.trusted_proxies %q is not an IP address or CIDR network", proxy))
+		}
+	}
+
+	if c.Purge.Retention < 0 {
+		problems = append(problems, "purge.retention must not be negative")
+	}
+	if c.Purge.Interval <= 0 {
+		problems = append(problems, "purge.interval must be a positive number of seconds")
+	}
+	if c.Purge.BatchSize <= 0 {
+		problems = append(problems, "purge.batch_size must be positive")
+	}
+
+	roles := make([]string, 0, len(c.Auth.Roles))
+	for role := range c.Auth.Roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	for _, role := range roles {
+		if len(c.Auth.Roles[role]) == 0 {
+			problems = append(problems, fmt.Sprintf("auth.roles.%s must grant some permission", role))
+		}
+	}
+
+	return problems
+}
+
+func bindFlag(v *viper.Viper, flags *pflag.FlagSet, name string) {
+	if flag := flags.Lookup(name); flag != nil {
+		_ = v.BindPFlag(name, flag)
+	}
+}
+
+// loader reads typed values out of viper, collecting the malformed ones.
+type loader struct {
+	v        *viper.Viper
+	problems []string
+}
+
+func (l *loader) string(name string) string {
+	return l.v.GetString(name)
+}
+
+func (l *loader) int(name string) int {
+	raw := strings.TrimSpace(l.v.GetString(name))
+	if raw == "" {
+		return 0
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s must be an integer, got %q", name, raw))
+	}
+
+	return value
+}
+
+func (l *loader) bool(name string) bool {
+	raw := strings.TrimSpace(l.v.GetString(name))
+	if raw == "" {
+		return false
+	}
+
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s must be true or false, got %q", name, raw))
+	}
+
+	return value
+}
+
+// list reads a list, given either as an array or as comma separated values.
+func (l *loader) list(name string) []string {
+	var values []string
+	switch raw := l.v.Get(name).(type) {
+	case []interface{}:
+		for _, v := range raw {
+			values = append(values, fmt.Sprint(v))
+		}
+	case []string:
+		values = raw
+	default:
+		values = strings.Split(l.v.GetString(name), ",")
+	}
+
+	list := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
+
+// limit reads a requests/period rate limit, zero when unset.
+func (l *loader) limit(name string) ratelimit.Limit {
+	raw := strings.TrimSpace(l.v.GetString(name))
+	if raw == "" {
+		return ratelimit.Limit{}
+	}
+
+	limit, err := ratelimit.ParseLimit(raw)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	return limit
+}
+
+// routeLimits reads the rate limits of the routes named by their method and
+// path, e.g. "POST /payment".
+func (l *loader) routeLimits(name string) map[string]ratelimit.Limit {
+	raw := l.v.GetStringMapString(name)
+	routes := make([]string, 0, len(raw))
+	for route := range raw {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+
+	limits := make(map[string]ratelimit.Limit, len(raw))
+	for _, route := range routes {
+		parts := strings.Fields(route)
+		if len(parts) != 2 || !strings.HasPrefix(parts[1], "/") {
+			l.problems = append(l.problems, fmt.Sprintf("%s.%s is not named as METHOD /path", name, route))
+			continue
+		}
+		limit, err := ratelimit.ParseLimit(raw[route])
+		if err != nil {
+			l.problems = append(l.problems, fmt.Sprintf("%s.%s: %v", name, route, err))
+			continue
+		}
+		limits[strings.ToUpper(parts[0])+" "+parts[1]] = limit
+	}
+
+	return limits
+}
+
+// secret reads a secret either from its key or from the file named by its
+// _file key, trimming the trailing newline files usually end with.
+func (l *loader) secret(name string) string {
+	value := l.v.GetString(name)
+	file := l.v.GetString(name + secretFileSuffix)
+	if file == "" {
+		return value
+	}
+	if value != "" {
+		l.problems = append(l.problems, fmt.Sprintf("only one of %s and %s%s can be set", name, name, secretFileSuffix))
+		return value
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		l.problems = append(l.problems, fmt.Sprintf("%s%s: %v", name, secretFileSuffix, err))
+		return ""
+	}
+
+	return strings.TrimRight(string(content), "\r\n")
+}
+
+// unknownKeys reports the keys set on the file that are not known, catching
+// typos that would otherwise silently leave settings empty.
+func (l *loader) unknownKeys() {
+	known := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		known[k.name] = true
+		if k.secret {
+			known[k.name+secretFileSuffix] = true
+		}
+	}
+
+	unknown := make([]string, 0)
+	for _, name := range l.v.AllKeys() {
+		if !known[name] && !inMapping(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		l.problems = append(l.problems, fmt.Sprintf("unknown key %s", name))
+	}
+}
+
+// inMapping reports whether the given key is an entry of one of the mappings.
+func inMapping(name string) bool {
+	for _, m := range mappings {
+		if strings.HasPrefix(name, m+".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,356 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/config"
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+)
+
+const validConfig = `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {
+    "driver": "mysql",
+    "host": "localhost",
+    "port": "3306",
+    "user": "root",
+    "name": "payment"
+  }
+}`
+
+func writeFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "config")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return path
+}
+
+func setenv(t *testing.T, key, value string) {
+	require.NoError(t, os.Setenv(key, value))
+	t.Cleanup(func() { os.Unsetenv(key) })
+}
+
+func TestLoadFile(t *testing.T) {
+	c, err := config.Load(writeFile(t, "config.json", validConfig), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, ":9090", c.Server.Address)
+	assert.Equal(t, 10*time.Second, c.Server.ShutdownTimeout)
+	assert.Equal(t, 2*time.Second, c.Context.Timeout)
+	assert.Equal(t, 24*time.Hour, c.Idempotency.TTL)
+	assert.Equal(t, "mysql", c.Database.Driver)
+	assert.Equal(t, "localhost", c.Database.Host)
+	assert.Equal(t, 3306, c.Database.Port)
+	assert.Equal(t, "root", c.Database.User)
+	assert.Equal(t, "", c.Database.Pass)
+	assert.Equal(t, "payment", c.Database.Name)
+	assert.Equal(t, "disable", c.Database.SSLMode)
+	assert.Equal(t, "none", c.Tracing.Exporter)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.json", validConfig)
+	setenv(t, "PAYMENT_DATABASE_HOST", "env-host")
+	setenv(t, "PAYMENT_DATABASE_USER", "env-user")
+
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	config.BindFlags(flags)
+	require.NoError(t, flags.Parse([]string{"--database.user", "flag-user"}))
+
+	c, err := config.Load(path, flags)
+	require.NoError(t, err)
+
+	assert.Equal(t, "env-host", c.Database.Host, "environment overrides the file")
+	assert.Equal(t, "flag-user", c.Database.User, "flags override the environment")
+	assert.Equal(t, "payment", c.Database.Name)
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	path := writeFile(t, "config.json", validConfig)
+	setenv(t, "PAYMENT_DATABASE_PASS_FILE", writeFile(t, "pass", "s3cr3t\n"))
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", c.Database.Pass)
+
+	setenv(t, "PAYMENT_DATABASE_PASS", "other")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "only one of database.pass and database.pass_file")
+	}
+}
+
+func TestLoadNamesEveryProblem(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "context": {"timeout": "soon"},
+  "database": {
+    "driver": "postgres",
+    "ºdatabase": {"host": "localhost"},
+    "port": "99999"
+  }
+}`)
+
+	_, err := config.Load(path, nil)
+	require.Error(t, err)
+	for _, problem := range []string{
+		`context.timeout must be an integer, got "soon"`,
+		"unknown key database.ºdatabase.host",
+		"database.host is required",
+		"database.port is required and must be between 1 and 65535",
+		"database.user is required",
+		"database.name is required",
+	} {
+		assert.Contains(t, err.Error(), problem)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := config.Load(filepath.Join(os.TempDir(), "missing-config.json"), nil)
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	c := &config.Config{
+		Server:   config.Server{Address: ":9090", ShutdownTimeout: time.Second},
+		Context:  config.Context{Timeout: time.Second},
+		Database: config.Database{Driver: "memory"},
+		Purge:    config.Purge{Interval: time.Hour, BatchSize: 100},
+	}
+	assert.NoError(t, c.Validate())
+
+	c.Database.Driver = "oracle"
+	assert.Error(t, c.Validate())
+}
+
+func TestValidateTracing(t *testing.T) {
+	c := &config.Config{
+		Server:   config.Server{Address: ":9090", ShutdownTimeout: time.Second},
+		Context:  config.Context{Timeout: time.Second},
+		Database: config.Database{Driver: "memory"},
+		Purge:    config.Purge{Interval: time.Hour, BatchSize: 100},
+		Tracing:  config.Tracing{Exporter: "otlp"},
+	}
+	if assert.Error(t, c.Validate()) {
+		assert.Contains(t, c.Validate().Error(), "tracing.endpoint is required by the otlp exporter")
+	}
+
+	c.Tracing.Endpoint = "http://localhost:4318"
+	assert.NoError(t, c.Validate())
+
+	c.Tracing.Exporter = "zipkin"
+	assert.Error(t, c.Validate())
+}
+
+func TestLoadJWT(t *testing.T) {
+	path := writeFile(t, "config.json", validConfig)
+	setenv(t, "PAYMENT_AUTH_JWT_SECRET_FILE", writeFile(t, "secret", "0123456789abcdef0123456789abcdef\n"))
+	setenv(t, "PAYMENT_AUTH_JWT_ISSUER", "https://issuer.example")
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.True(t, c.Auth.JWT.Enabled())
+	assert.Equal(t, "0123456789abcdef0123456789abcdef", c.Auth.JWT.Secret)
+	assert.Equal(t, "https://issuer.example", c.Auth.JWT.Issuer)
+
+	setenv(t, "PAYMENT_AUTH_JWT_SECRET_FILE", writeFile(t, "secret", "short"))
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "auth.jwt.secret must be at least 32 bytes long")
+	}
+
+	setenv(t, "PAYMENT_AUTH_JWT_SECRET_FILE", writeFile(t, "secret", "0123456789abcdef0123456789abcdef"))
+	setenv(t, "PAYMENT_AUTH_DISABLED", "true")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "auth.disabled cannot be combined with an auth.jwt key")
+	}
+}
+
+func TestLoadRoles(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {"driver": "memory"},
+  "auth": {
+    "roles": {
+      "Operator": ["payments:read", "payments:create"],
+      "auditor": ["payments:read"]
+    }
+  }
+}`)
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"operator": {"payments:read", "payments:create"},
+		"auditor":  {"payments:read"},
+	}, c.Auth.Roles)
+
+	c.Auth.Roles["approver"] = nil
+	if err = c.Validate(); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "auth.roles.approver must grant some permission")
+	}
+}
+
+func TestLoadCORS(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {"driver": "memory"},
+  "cors": {"allowed_origins": ["https://app.example.com"], "max_age": 60}
+}`)
+	setenv(t, "PAYMENT_CORS_EXPOSED_HEADERS", "X-Cursor, X-Request-ID")
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://app.example.com"}, c.CORS.AllowedOrigins)
+	assert.Equal(t, []string{"GET", "HEAD", "POST", "PATCH", "DELETE"}, c.CORS.AllowedMethods)
+	assert.Equal(t, []string{"X-Cursor", "X-Request-ID"}, c.CORS.ExposedHeaders)
+	assert.False(t, c.CORS.AllowCredentials)
+	assert.Equal(t, time.Minute, c.CORS.MaxAge)
+
+	setenv(t, "PAYMENT_CORS_ALLOWED_ORIGINS", "*")
+	setenv(t, "PAYMENT_CORS_ALLOW_CREDENTIALS", "true")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "cors.allow_credentials cannot be used with the * origin")
+	}
+}
+
+func TestLoadRateLimit(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {"driver": "memory"},
+  "ratelimit": {
+    "default": "600/1m",
+    "routes": {"POST /payment": "10/1m", "post /payment/:id/actions/:action": "5/s"}
+  }
+}`)
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 600, Period: time.Minute}, c.RateLimit.Default)
+	assert.Equal(t, map[string]ratelimit.Limit{
+		"POST /payment":                     {Requests: 10, Period: time.Minute},
+		"POST /payment/:id/actions/:action": {Requests: 5, Period: time.Second},
+	}, c.RateLimit.Routes)
+
+	assert.True(t, c.RateLimit.IP.IsZero())
+	assert.Empty(t, c.RateLimit.TrustedProxies)
+
+	setenv(t, "PAYMENT_RATELIMIT_IP", "1200/1m")
+	setenv(t, "PAYMENT_RATELIMIT_TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.1")
+	c, err = config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ratelimit.Limit{Requests: 1200, Period: time.Minute}, c.RateLimit.IP)
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, c.RateLimit.TrustedProxies)
+
+	setenv(t, "PAYMENT_RATELIMIT_TRUSTED_PROXIES", "proxy.local")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ratelimit.trusted_proxies")
+	}
+	setenv(t, "PAYMENT_RATELIMIT_TRUSTED_PROXIES", "")
+
+	setenv(t, "PAYMENT_RATELIMIT_DEFAULT", "lots")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "ratelimit.default")
+	}
+}
+
+func TestLoadOutbox(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {"driver": "memory"}
+}`)
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Outbox{Publishers: []string{"log", "webhook"}, Interval: time.Second, BatchSize: 100}, c.Outbox)
+	assert.Equal(t, config.Webhook{Interval: time.Second, BatchSize: 100, MaxAttempts: 10, Timeout: 10 * time.Second, AllowedHosts: []string{}}, c.Webhook)
+
+	setenv(t, "PAYMENT_WEBHOOK_ALLOWED_HOSTS", "hooks.internal, 10.0.0.0/8")
+	c, err = config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hooks.internal", "10.0.0.0/8"}, c.Webhook.AllowedHosts)
+
+	setenv(t, "PAYMENT_WEBHOOK_ALLOWED_HOSTS", "10.0.0.0/33")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "webhook.allowed_hosts")
+	}
+	setenv(t, "PAYMENT_WEBHOOK_ALLOWED_HOSTS", "")
+
+	setenv(t, "PAYMENT_OUTBOX_PUBLISHER", "none,log")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "outbox.publisher none")
+	}
+
+	setenv(t, "PAYMENT_OUTBOX_PUBLISHER", "webhook")
+	setenv(t, "PAYMENT_WEBHOOK_MAX_ATTEMPTS", "0")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "webhook.max_attempts")
+	}
+
+	setenv(t, "PAYMENT_OUTBOX_PUBLISHER", "kafka")
+	setenv(t, "PAYMENT_OUTBOX_INTERVAL", "0")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "outbox.publisher")
+	}
+
+	setenv(t, "PAYMENT_OUTBOX_PUBLISHER", "log")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "outbox.interval")
+	}
+}
+
+func TestLoadPurge(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+  "server": {"address": ":9090"},
+  "context": {"timeout": 2},
+  "database": {"driver": "memory"}
+}`)
+
+	c, err := config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Purge{Interval: time.Hour, BatchSize: 100}, c.Purge)
+
+	setenv(t, "PAYMENT_PURGE_BATCH_SIZE", "0")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err, "expired idempotency keys are purged even without retention") {
+		assert.Contains(t, err.Error(), "purge.batch_size")
+	}
+
+	setenv(t, "PAYMENT_PURGE_RETENTION", "30")
+
+	setenv(t, "PAYMENT_PURGE_BATCH_SIZE", "10")
+	c, err = config.Load(path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, config.Purge{Retention: 30 * 24 * time.Hour, Interval: time.Hour, BatchSize: 10}, c.Purge)
+
+	setenv(t, "PAYMENT_PURGE_RETENTION", "-1")
+	_, err = config.Load(path, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "purge.retention")
+	}
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo"
+
+	"github.com/adriacidre/go-clean-arch/health"
+)
+
+// HealthHandler http handler for the liveness and readiness probes.
+type HealthHandler struct {
+	Health *health.Health
+}
+
+// NewHealthHTTPHandler health http handler constructor.
+func NewHealthHTTPHandler(e *echo.Echo, h *health.Health) {
+	handler := &HealthHandler{
+		Health: h,
+	}
+	e.GET("/healthz", handler.Liveness)
+	e.GET("/readyz", handler.Readiness)
+}
+
+// Liveness handles the liveness probe, answering as long as the process can
+// serve requests at all.
+func (h *HealthHandler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// Readiness handles the readiness probe, responding 503 Service Unavailable
+// when a dependency is down or the service is shutting down.
+func (h *HealthHandler) Readiness(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report := h.Health.Readiness(ctx)
+	if !report.Ready() {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,73 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/health"
+	healthHttp "github.com/adriacidre/go-clean-arch/health/delivery/http"
+)
+
+func request(h *health.Health, path string) *httptest.ResponseRecorder {
+	e := echo.New()
+	healthHttp.NewHealthHTTPHandler(e, h)
+
+	req := httptest.NewRequest(echo.GET, path, nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestLiveness(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error { return errors.New("down") })
+
+	rec := request(h, "/healthz")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"alive"}`, rec.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error { return nil })
+
+	rec := request(h, "/readyz")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusReady, report.Status)
+	assert.Equal(t, health.StatusUp, report.Dependencies["database"].Status)
+}
+
+func TestReadinessDependencyDown(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	rec := request(h, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var report health.Report
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, "connection refused", report.Dependencies["database"].Error)
+}
+
+func TestReadinessShuttingDown(t *testing.T) {
+	h := health.New()
+	h.ShuttingDown()
+
+	rec := request(h, "/readyz")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), health.StatusShuttingDown)
+}
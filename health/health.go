@@ -0,0 +1,134 @@
+// Package health reports whether the service is alive and ready to serve
+// requests, checking the dependencies it needs.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dependency statuses.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Readiness statuses.
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not ready"
+	StatusShuttingDown = "shutting down"
+)
+
+// checkTimeout time a dependency check is given before it is reported down.
+const checkTimeout = 2 * time.Second
+
+// CheckFunc checks a dependency is available, e.g. by pinging it.
+type CheckFunc func(ctx context.Context) error
+
+// DependencyStatus result of checking a dependency.
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report readiness of the service along with its dependencies status.
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+// Ready whether the report allows the service to receive traffic.
+func (r *Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+// Health checks the readiness of the service.
+type Health struct {
+	mu           sync.RWMutex
+	checks       map[string]CheckFunc
+	shuttingDown int32
+}
+
+// New health constructor with no dependencies to check.
+func New() *Health {
+	return &Health{checks: make(map[string]CheckFunc)}
+}
+
+// AddCheck registers a dependency checked on readiness.
+func (h *Health) AddCheck(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+// ShuttingDown flips the service to not ready for good, so traffic stops
+// being routed to it while it drains in-flight requests.
+func (h *Health) ShuttingDown() {
+	atomic.StoreInt32(&h.shuttingDown, 1)
+}
+
+// Readiness checks every dependency concurrently, the service being ready
+// only when all of them are up and it is not shutting down.
+func (h *Health) Readiness(c context.Context) *Report {
+	report := &Report{Status: StatusReady, Dependencies: make(map[string]DependencyStatus)}
+	if atomic.LoadInt32(&h.shuttingDown) == 1 {
+		report.Status = StatusShuttingDown
+		return report
+	}
+
+	h.mu.RLock()
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]CheckFunc, len(names))
+	for i, name := range names {
+		checks[i] = h.checks[name]
+	}
+	h.mu.RUnlock()
+
+	results := make([]DependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = check(c, checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		report.Dependencies[name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+
+	return report
+}
+
+func check(c context.Context, fn CheckFunc) DependencyStatus {
+	ctx, cancel := context.WithTimeout(c, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := fn(ctx)
+	status := DependencyStatus{
+		Status:    StatusUp,
+		LatencyMs: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
@@ -0,0 +1,60 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/health"
+)
+
+func TestReadinessWithoutDependencies(t *testing.T) {
+	report := health.New().Readiness(context.TODO())
+
+	assert.True(t, report.Ready())
+	assert.Empty(t, report.Dependencies)
+}
+
+func TestReadinessReportsEveryDependency(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error { return nil })
+	h.AddCheck("broker", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	report := h.Readiness(context.TODO())
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, health.StatusUp, report.Dependencies["database"].Status)
+	assert.Empty(t, report.Dependencies["database"].Error)
+	assert.Equal(t, health.StatusDown, report.Dependencies["broker"].Status)
+	assert.Equal(t, "connection refused", report.Dependencies["broker"].Error)
+	assert.True(t, report.Dependencies["broker"].LatencyMs >= 0)
+}
+
+func TestReadinessTimesOutChecks(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := h.Readiness(ctx)
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusDown, report.Dependencies["database"].Status)
+}
+
+func TestShuttingDown(t *testing.T) {
+	h := health.New()
+	h.AddCheck("database", func(ctx context.Context) error { return nil })
+	h.ShuttingDown()
+
+	report := h.Readiness(context.TODO())
+
+	assert.False(t, report.Ready())
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+}
@@ -0,0 +1,21 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Repository repository interface to interact with stored idempotent responses.
+// Store reserves a key, failing with ErrConflict while a not yet expired
+// response holds it, Complete fills in the pending response reserved and
+// Release gives up the reservation. Purge deletes up to num responses expired
+// before the given time, returning how many were deleted.
+type Repository interface {
+	Get(ctx context.Context, key string) (*models.IdempotentResponse, error)
+	Store(ctx context.Context, r *models.IdempotentResponse) error
+	Complete(ctx context.Context, r *models.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+	Purge(ctx context.Context, before time.Time, num int64) (int64, error)
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+type mysqlIdempotency struct {
+	Conn *sql.DB
+}
+
+// NewMysqlIdempotency mysql idempotent responses constructor.
+func NewMysqlIdempotency(Conn *sql.DB) idempotency.Repository {
+	return &mysqlIdempotency{Conn}
+}
+
+// Get gets the not yet expired response stored for the given key.
+func (m *mysqlIdempotency) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	query := `SELECT idempotency_key,request_hash,status_code,headers,body,created_at,expires_at
+  						FROM idempotent_response WHERE idempotency_key = ? AND expires_at > ?`
+
+	r := new(models.IdempotentResponse)
+	var headers sql.NullString
+	err := m.Conn.QueryRowContext(ctx, query, key, time.Now()).Scan(
+		&r.Key,
+		&r.RequestHash,
+		&r.StatusCode,
+		&headers,
+		&r.Body,
+		&r.CreatedAt,
+		&r.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	if r.Headers, err = decodeHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Store stores the given response unless a not yet expired one is stored with
+// the same key, failing with ErrConflict then.
+func (m *mysqlIdempotency) Store(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key = ? AND expires_at <= ?`
+	if _, err := m.Conn.ExecContext(ctx, query, r.Key, r.CreatedAt); err != nil {
+		return err
+	}
+
+	query = `INSERT idempotent_response SET idempotency_key=? , request_hash=? , status_code=? , body=? , created_at=? , expires_at=?`
+	_, err := m.Conn.ExecContext(ctx, query, r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt, r.ExpiresAt)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return models.ErrConflict
+	}
+
+	return err
+}
+
+// Complete fills in the pending response stored with the key and request hash
+// of the given one, failing with ErrNotFound when there is none.
+func (m *mysqlIdempotency) Complete(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `UPDATE idempotent_response SET status_code=? , headers=? , body=? , expires_at=?
+		WHERE idempotency_key = ? AND request_hash = ? AND status_code = 0`
+
+	headers, err := encodeHeaders(r.Headers)
+	if err != nil {
+		return err
+	}
+	res, err := m.Conn.ExecContext(ctx, query, r.StatusCode, headers, r.Body, r.ExpiresAt, r.Key, r.RequestHash)
+	return completed(res, err)
+}
+
+// Release deletes the pending response stored with the given key.
+func (m *mysqlIdempotency) Release(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key = ? AND status_code = 0`
+
+	_, err := m.Conn.ExecContext(ctx, query, key)
+	return err
+}
+
+// Purge deletes up to num responses expired before the given time, returning
+// how many were deleted.
+func (m *mysqlIdempotency) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	query := `DELETE FROM idempotent_response WHERE expires_at <= ? LIMIT ?`
+
+	res, err := m.Conn.ExecContext(ctx, query, before, num)
+	return purged(res, err)
+}
+
+// mysqlDuplicateEntry MySQL error number of unique index violations.
+const mysqlDuplicateEntry = 1062
+
+// completed maps the result of completing a pending response to ErrNotFound
+// when it affected none.
+func completed(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affect == 0 {
+		return models.ErrNotFound
+	}
+
+	return nil
+}
+
+// purged maps the result of purging expired responses to how many it deleted.
+func purged(res sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// encodeHeaders encodes the given response headers to be stored, as NULL when
+// there are none.
+func encodeHeaders(headers map[string]string) (interface{}, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
+// decodeHeaders decodes the stored response headers, if any.
+func decodeHeaders(stored sql.NullString) (map[string]string, error) {
+	if !stored.Valid || stored.String == "" {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(stored.String), &headers); err != nil {
+		return nil, err
+	}
+
+	return headers, nil
+}
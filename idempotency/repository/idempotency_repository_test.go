@@ -0,0 +1,146 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+func TestGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"idempotency_key", "request_hash", "status_code", "headers", "body", "created_at", "expires_at"}).
+		AddRow("key-1", "hash", 201, `{"Location":"/payment/1"}`, []byte(`{"id":1}`), now, now.Add(time.Hour))
+
+	query := "SELECT (.+) FROM idempotent_response WHERE idempotency_key = \\? AND expires_at > \\?"
+
+	mock.ExpectQuery(query).WithArgs("key-1", sqlmock.AnyArg()).WillReturnRows(rows)
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	r, err := a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 201, r.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(r.Body))
+	assert.Equal(t, map[string]string{"Location": "/payment/1"}, r.Headers)
+}
+
+func TestGetNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"idempotency_key", "request_hash", "status_code", "headers", "body", "created_at", "expires_at"})
+
+	mock.ExpectQuery("SELECT (.+) FROM idempotent_response").WillReturnRows(rows)
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	r, err := a.Get(context.TODO(), "key-1")
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, r)
+}
+
+func TestStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	r := &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		Body:        []byte{},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+
+	mock.ExpectExec("DELETE FROM idempotent_response WHERE idempotency_key = \\? AND expires_at <= \\?").
+		WithArgs(r.Key, r.CreatedAt).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT idempotent_response SET (.+)").
+		WithArgs(r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt, r.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	err = a.Store(context.TODO(), r)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+
+	mock.ExpectExec("DELETE FROM idempotent_response").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT idempotent_response SET (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'key-1' for key 'PRIMARY'"})
+
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	err = a.Store(context.TODO(), &models.IdempotentResponse{Key: "key-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	assert.Equal(t, models.ErrConflict, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestComplete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	r := &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		StatusCode:  201,
+		Headers:     map[string]string{"ETag": `"1"`},
+		Body:        []byte(`{"id":1}`),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	}
+
+	query := "UPDATE idempotent_response SET status_code=\\? , headers=\\? , body=\\? , expires_at=\\? WHERE idempotency_key = \\? AND request_hash = \\? AND status_code = 0"
+	mock.ExpectExec(query).WithArgs(r.StatusCode, `{"ETag":"\"1\""}`, r.Body, r.ExpiresAt, r.Key, r.RequestHash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	assert.NoError(t, a.Complete(context.TODO(), r))
+	assert.Equal(t, models.ErrNotFound, a.Complete(context.TODO(), r))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	before := time.Now()
+
+	mock.ExpectExec("DELETE FROM idempotent_response WHERE expires_at <= \\? LIMIT \\?").WithArgs(before, int64(10)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	a := idempotencyRepo.NewMysqlIdempotency(db)
+
+	purged, err := a.Purge(context.TODO(), before, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+type memoryIdempotency struct {
+	mu        sync.Mutex
+	responses map[string]models.IdempotentResponse
+}
+
+// NewMemoryIdempotency in-memory idempotent responses constructor.
+func NewMemoryIdempotency() idempotency.Repository {
+	return &memoryIdempotency{
+		responses: make(map[string]models.IdempotentResponse),
+	}
+}
+
+// Get gets the not yet expired response stored for the given key.
+func (m *memoryIdempotency) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.responses[key]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+
+	if !r.ExpiresAt.After(time.Now()) {
+		delete(m.responses, key)
+		return nil, models.ErrNotFound
+	}
+
+	return &r, nil
+}
+
+// Store stores the given response unless a not yet expired one is stored with
+// the same key, failing with ErrConflict then.
+func (m *memoryIdempotency) Store(ctx context.Context, r *models.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.responses[r.Key]; ok && stored.ExpiresAt.After(r.CreatedAt) {
+		return models.ErrConflict
+	}
+	m.responses[r.Key] = *r
+
+	return nil
+}
+
+// Complete fills in the pending response stored with the key and request hash
+// of the given one, failing with ErrNotFound when there is none.
+func (m *memoryIdempotency) Complete(ctx context.Context, r *models.IdempotentResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.responses[r.Key]
+	if !ok || !stored.Pending() || stored.RequestHash != r.RequestHash {
+		return models.ErrNotFound
+	}
+	stored.StatusCode = r.StatusCode
+	stored.Headers = r.Headers
+	stored.Body = r.Body
+	stored.ExpiresAt = r.ExpiresAt
+	m.responses[r.Key] = stored
+
+	return nil
+}
+
+// Release deletes the pending response stored with the given key.
+func (m *memoryIdempotency) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if stored, ok := m.responses[key]; ok && stored.Pending() {
+		delete(m.responses, key)
+	}
+
+	return nil
+}
+
+// Purge deletes up to num responses expired before the given time, returning
+// how many were deleted.
+func (m *memoryIdempotency) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var purged int64
+	for key, stored := range m.responses {
+		if purged >= num {
+			break
+		}
+		if stored.ExpiresAt.After(before) {
+			continue
+		}
+		delete(m.responses, key)
+		purged++
+	}
+
+	return purged, nil
+}
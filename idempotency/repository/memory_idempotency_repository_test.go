@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+func TestMemoryStoreAndGet(t *testing.T) {
+	a := idempotencyRepo.NewMemoryIdempotency()
+	now := time.Now()
+
+	_, err := a.Get(context.TODO(), "key-1")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	pending := &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	assert.NoError(t, a.Store(context.TODO(), pending))
+	assert.Equal(t, models.ErrConflict, a.Store(context.TODO(), pending), "keys not yet expired are not replaced")
+
+	err = a.Complete(context.TODO(), &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		StatusCode:  201,
+		Body:        []byte(`{"id":1}`),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, a.Release(context.TODO(), "key-1"), "completed responses are kept")
+
+	r, err := a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 201, r.StatusCode)
+}
+
+func TestMemoryGetExpired(t *testing.T) {
+	a := idempotencyRepo.NewMemoryIdempotency()
+	now := time.Now()
+
+	err := a.Store(context.TODO(), &models.IdempotentResponse{
+		Key:       "key-1",
+		CreatedAt: now.Add(-2 * time.Hour),
+		ExpiresAt: now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+
+	_, err = a.Get(context.TODO(), "key-1")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	err = a.Store(context.TODO(), &models.IdempotentResponse{Key: "key-1", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+	assert.NoError(t, err, "expired keys are replaced")
+}
+
+func TestMemoryPurge(t *testing.T) {
+	a := idempotencyRepo.NewMemoryIdempotency()
+	now := time.Now()
+
+	for _, key := range []string{"key-1", "key-2"} {
+		err := a.Store(context.TODO(), &models.IdempotentResponse{
+			Key:       key,
+			CreatedAt: now.Add(-2 * time.Hour),
+			ExpiresAt: now.Add(-time.Hour),
+		})
+		assert.NoError(t, err)
+	}
+	err := a.Store(context.TODO(), &models.IdempotentResponse{Key: "key-3", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	purged, err := a.Purge(context.TODO(), now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	purged, err = a.Purge(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = a.Get(context.TODO(), "key-3")
+	assert.NoError(t, err, "responses not yet expired are kept")
+}
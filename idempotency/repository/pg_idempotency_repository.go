@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+type pgIdempotency struct {
+	Conn *sql.DB
+}
+
+// NewPgIdempotency postgres idempotent responses constructor.
+func NewPgIdempotency(Conn *sql.DB) idempotency.Repository {
+	return &pgIdempotency{Conn}
+}
+
+// Get gets the not yet expired response stored for the given key.
+func (m *pgIdempotency) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	query := `SELECT idempotency_key,request_hash,status_code,headers,body,created_at,expires_at
+  						FROM idempotent_response WHERE idempotency_key = $1 AND expires_at > $2`
+
+	r := new(models.IdempotentResponse)
+	var headers sql.NullString
+	err := m.Conn.QueryRowContext(ctx, query, key, time.Now()).Scan(
+		&r.Key,
+		&r.RequestHash,
+		&r.StatusCode,
+		&headers,
+		&r.Body,
+		&r.CreatedAt,
+		&r.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	if r.Headers, err = decodeHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Store stores the given response unless a not yet expired one is stored with
+// the same key, failing with ErrConflict then.
+func (m *pgIdempotency) Store(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `INSERT INTO idempotent_response (idempotency_key, request_hash, status_code, body, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (idempotency_key) DO UPDATE SET request_hash=EXCLUDED.request_hash, status_code=EXCLUDED.status_code,
+		headers=NULL, body=EXCLUDED.body, created_at=EXCLUDED.created_at, expires_at=EXCLUDED.expires_at
+		WHERE idempotent_response.expires_at <= EXCLUDED.created_at`
+
+	res, err := m.Conn.ExecContext(ctx, query, r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt, r.ExpiresAt)
+	return stored(res, err)
+}
+
+// Complete fills in the pending response stored with the key and request hash
+// of the given one, failing with ErrNotFound when there is none.
+func (m *pgIdempotency) Complete(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `UPDATE idempotent_response SET status_code=$1, headers=$2, body=$3, expires_at=$4
+		WHERE idempotency_key = $5 AND request_hash = $6 AND status_code = 0`
+
+	headers, err := encodeHeaders(r.Headers)
+	if err != nil {
+		return err
+	}
+	res, err := m.Conn.ExecContext(ctx, query, r.StatusCode, headers, r.Body, r.ExpiresAt, r.Key, r.RequestHash)
+	return completed(res, err)
+}
+
+// Release deletes the pending response stored with the given key.
+func (m *pgIdempotency) Release(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key = $1 AND status_code = 0`
+
+	_, err := m.Conn.ExecContext(ctx, query, key)
+	return err
+}
+
+// Purge deletes up to num responses expired before the given time, returning
+// how many were deleted.
+func (m *pgIdempotency) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key IN
+		(SELECT idempotency_key FROM idempotent_response WHERE expires_at <= $1 LIMIT $2)`
+
+	res, err := m.Conn.ExecContext(ctx, query, before, num)
+	return purged(res, err)
+}
+
+// stored maps the result of an upsert replacing only expired responses to
+// ErrConflict when it affected none.
+func stored(res sql.Result, err error) error {
+	if err = completed(res, err); err == models.ErrNotFound {
+		return models.ErrConflict
+	}
+
+	return err
+}
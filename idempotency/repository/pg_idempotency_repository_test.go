@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+func TestPgGet(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"idempotency_key", "request_hash", "status_code", "headers", "body", "created_at", "expires_at"}).
+		AddRow("key-1", "hash", 201, `{"Location":"/payment/1"}`, []byte(`{"id":1}`), now, now.Add(time.Hour))
+
+	query := "SELECT (.+) FROM idempotent_response WHERE idempotency_key = \\$1 AND expires_at > \\$2"
+
+	mock.ExpectQuery(query).WithArgs("key-1", sqlmock.AnyArg()).WillReturnRows(rows)
+	a := idempotencyRepo.NewPgIdempotency(db)
+
+	r, err := a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", r.RequestHash)
+	assert.Equal(t, map[string]string{"Location": "/payment/1"}, r.Headers)
+}
+
+func TestPgStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	r := &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		Body:        []byte{},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+
+	query := "INSERT INTO idempotent_response (.+) ON CONFLICT \\(idempotency_key\\) DO UPDATE SET (.+) WHERE idempotent_response.expires_at <= EXCLUDED.created_at"
+	mock.ExpectExec(query).WithArgs(r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt, r.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt, r.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a := idempotencyRepo.NewPgIdempotency(db)
+
+	assert.NoError(t, a.Store(context.TODO(), r))
+	assert.Equal(t, models.ErrConflict, a.Store(context.TODO(), r), "keys not yet expired are not replaced")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+type sqliteIdempotency struct {
+	Conn *sql.DB
+}
+
+// NewSqliteIdempotency sqlite idempotent responses constructor. Times are kept
+// in UTC as SQLite compares them as text.
+func NewSqliteIdempotency(Conn *sql.DB) idempotency.Repository {
+	return &sqliteIdempotency{Conn}
+}
+
+// Get gets the not yet expired response stored for the given key.
+func (m *sqliteIdempotency) Get(ctx context.Context, key string) (*models.IdempotentResponse, error) {
+	query := `SELECT idempotency_key,request_hash,status_code,headers,body,created_at,expires_at
+  						FROM idempotent_response WHERE idempotency_key = ? AND expires_at > ?`
+
+	r := new(models.IdempotentResponse)
+	var headers sql.NullString
+	err := m.Conn.QueryRowContext(ctx, query, key, time.Now().UTC()).Scan(
+		&r.Key,
+		&r.RequestHash,
+		&r.StatusCode,
+		&headers,
+		&r.Body,
+		&r.CreatedAt,
+		&r.ExpiresAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrNotFound
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	if r.Headers, err = decodeHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Store stores the given response unless a not yet expired one is stored with
+// the same key, failing with ErrConflict then.
+func (m *sqliteIdempotency) Store(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `INSERT INTO idempotent_response (idempotency_key, request_hash, status_code, body, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (idempotency_key) DO UPDATE SET request_hash=EXCLUDED.request_hash, status_code=EXCLUDED.status_code,
+		headers=NULL, body=EXCLUDED.body, created_at=EXCLUDED.created_at, expires_at=EXCLUDED.expires_at
+		WHERE idempotent_response.expires_at <= EXCLUDED.created_at`
+
+	res, err := m.Conn.ExecContext(ctx, query, r.Key, r.RequestHash, r.StatusCode, r.Body, r.CreatedAt.UTC(), r.ExpiresAt.UTC())
+	return stored(res, err)
+}
+
+// Complete fills in the pending response stored with the key and request hash
+// of the given one, failing with ErrNotFound when there is none.
+func (m *sqliteIdempotency) Complete(ctx context.Context, r *models.IdempotentResponse) error {
+	query := `UPDATE idempotent_response SET status_code=?, headers=?, body=?, expires_at=?
+		WHERE idempotency_key = ? AND request_hash = ? AND status_code = 0`
+
+	headers, err := encodeHeaders(r.Headers)
+	if err != nil {
+		return err
+	}
+	res, err := m.Conn.ExecContext(ctx, query, r.StatusCode, headers, r.Body, r.ExpiresAt.UTC(), r.Key, r.RequestHash)
+	return completed(res, err)
+}
+
+// Release deletes the pending response stored with the given key.
+func (m *sqliteIdempotency) Release(ctx context.Context, key string) error {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key = ? AND status_code = 0`
+
+	_, err := m.Conn.ExecContext(ctx, query, key)
+	return err
+}
+
+// Purge deletes up to num responses expired before the given time, returning
+// how many were deleted.
+func (m *sqliteIdempotency) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	query := `DELETE FROM idempotent_response WHERE idempotency_key IN
+		(SELECT idempotency_key FROM idempotent_response WHERE expires_at <= ? LIMIT ?)`
+
+	res, err := m.Conn.ExecContext(ctx, query, before.UTC(), num)
+	return purged(res, err)
+}
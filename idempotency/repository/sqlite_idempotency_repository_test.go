@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/testutil"
+)
+
+func TestSqliteStoreAndGet(t *testing.T) {
+	db := testutil.OpenSqlite(t, ":memory:")
+
+	a := idempotencyRepo.NewSqliteIdempotency(db)
+	now := time.Now()
+
+	err := a.Store(context.TODO(), &models.IdempotentResponse{
+		Key:         "expired",
+		RequestHash: "hash",
+		Body:        []byte(`{}`),
+		CreatedAt:   now.Add(-2 * time.Hour),
+		ExpiresAt:   now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+
+	_, err = a.Get(context.TODO(), "expired")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	pending := &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		Body:        []byte{},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Minute),
+	}
+	assert.NoError(t, a.Store(context.TODO(), pending))
+	assert.Equal(t, models.ErrConflict, a.Store(context.TODO(), pending), "keys not yet expired are not replaced")
+
+	r, err := a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+	assert.True(t, r.Pending())
+
+	err = a.Complete(context.TODO(), &models.IdempotentResponse{
+		Key:         "key-1",
+		RequestHash: "hash",
+		StatusCode:  201,
+		Headers:     map[string]string{"Location": "/payment/1"},
+		Body:        []byte(`{"id":1}`),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, a.Release(context.TODO(), "key-1"), "completed responses are kept")
+
+	r, err = a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 201, r.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(r.Body))
+	assert.Equal(t, map[string]string{"Location": "/payment/1"}, r.Headers)
+
+	pending.Key = "key-2"
+	assert.NoError(t, a.Store(context.TODO(), pending))
+	assert.NoError(t, a.Release(context.TODO(), "key-2"))
+	_, err = a.Get(context.TODO(), "key-2")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	err = a.Store(context.TODO(), &models.IdempotentResponse{
+		Key:         "expired-2",
+		RequestHash: "hash",
+		Body:        []byte(`{}`),
+		CreatedAt:   now.Add(-2 * time.Hour),
+		ExpiresAt:   now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+
+	purged, err := a.Purge(context.TODO(), now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	purged, err = a.Purge(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged, "responses not yet expired are kept")
+	_, err = a.Get(context.TODO(), "key-1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,55 @@
+// Package logging carries a request scoped structured logger through the
+// context, so every line logged while handling a request can be correlated.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader header carrying the request ID, both on requests and
+// responses.
+const RequestIDHeader = "X-Request-ID"
+
+// Fields set on the request scoped logger.
+const (
+	FieldRequestID = "request_id"
+	FieldTenant    = "tenant"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying the given logger.
+func NewContext(ctx context.Context, logger logrus.FieldLogger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or the standard logger when
+// there is none.
+func FromContext(ctx context.Context) logrus.FieldLogger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(logrus.FieldLogger); ok {
+			return logger
+		}
+	}
+
+	return logrus.StandardLogger()
+}
+
+// WithFields returns a copy of ctx whose logger also sets the given fields.
+func WithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx).WithFields(fields))
+}
+
+// NewRequestID generates a random request ID.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		logrus.Error(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,34 @@
+package logging_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, logrus.StandardLogger(), logging.FromContext(context.Background()))
+
+	logger, hook := test.NewNullLogger()
+	ctx := logging.NewContext(context.Background(), logger.WithField(logging.FieldRequestID, "req-1"))
+	ctx = logging.WithFields(ctx, logrus.Fields{logging.FieldTenant: "org-1"})
+	logging.FromContext(ctx).Info("hello")
+
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "hello", entry.Message)
+	assert.Equal(t, "req-1", entry.Data[logging.FieldRequestID])
+	assert.Equal(t, "org-1", entry.Data[logging.FieldTenant])
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := logging.NewRequestID()
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, id, logging.NewRequestID())
+}
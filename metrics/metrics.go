@@ -0,0 +1,110 @@
+// Package metrics defines the Prometheus metrics exposed by the service.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefix of every metric exposed by the service.
+const Namespace = "payment"
+
+// NewRegistry registry with the Go runtime and process collectors.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return reg
+}
+
+// Handler exposes the metrics gathered by the registry in the Prometheus
+// text format.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
+
+// HTTP request metrics labelled by method, route and status code.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// NewHTTP registers the HTTP request metrics on reg.
+func NewHTTP(reg prometheus.Registerer) *HTTP {
+	labels := []string{"method", "route", "status"}
+	h := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests handled.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+	}
+	reg.MustRegister(h.requests, h.duration)
+
+	return h
+}
+
+// Observe records a request to the given route template, e.g. /payment/:id.
+func (h *HTTP) Observe(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	h.requests.WithLabelValues(method, route, code).Inc()
+	h.duration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// Calls method call metrics of a layer, such as the payment use case.
+type Calls struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewCalls registers the call metrics of the given layer on reg.
+func NewCalls(reg prometheus.Registerer, layer string) *Calls {
+	c := &Calls{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: layer,
+			Name:      "call_duration_seconds",
+			Help:      "Method call latency.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: layer,
+			Name:      "call_errors_total",
+			Help:      "Method calls returning an error.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(c.duration, c.errors)
+
+	return c
+}
+
+// Observe records a call to method started at start, returning err.
+func (c *Calls) Observe(method string, start time.Time, err error) {
+	c.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.errors.WithLabelValues(method).Inc()
+	}
+}
+
+// RegisterDBStats exposes the sql.DBStats of the database pool as gauges.
+func RegisterDBStats(reg prometheus.Registerer, db *sql.DB, name string) {
+	reg.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
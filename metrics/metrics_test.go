@@ -0,0 +1,41 @@
+package metrics_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+)
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	reg := metrics.NewRegistry()
+	metrics.RegisterDBStats(reg, db, "payment")
+	metrics.NewHTTP(reg).Observe("GET", "/payment", 200, 10*time.Millisecond)
+	metrics.NewCalls(reg, "usecase").Observe("Fetch", time.Now(), nil)
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(reg).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+
+	for _, name := range []string{
+		`payment_http_requests_total{method="GET",route="/payment",status="200"} 1`,
+		`payment_http_request_duration_seconds_bucket`,
+		`payment_usecase_call_duration_seconds_count{method="Fetch"} 1`,
+		`go_sql_open_connections{db_name="payment"}`,
+		`go_goroutines`,
+	} {
+		assert.Contains(t, string(body), name)
+	}
+}
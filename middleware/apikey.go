@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// APIKey authenticates requests with the API key of their Access-Token
+// header, storing a principal with the apikey role restricted to the
+// organisation and scopes of the key on the request context. Requests with an
+// unknown or revoked key are rejected with 401, those without one are left to
+// the other authentication middleware.
+func (m *GoMiddleware) APIKey(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		token := req.Header.Get(AccessTokenKey)
+		if m.APIKeys == nil || token == "" {
+			return next(c)
+		}
+
+		key, err := m.APIKeys.Authenticate(req.Context(), token)
+		if err == models.ErrInvalidAPIKey {
+			logging.FromContext(req.Context()).Info("api key rejected")
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid API key")
+		}
+		if err != nil {
+			return err
+		}
+
+		principal := &auth.Principal{
+			Subject:      "apikey:" + strconv.FormatInt(key.ID, 10),
+			Organisation: key.Organisation,
+			Roles:        []string{auth.RoleAPIKey},
+			Scopes:       key.Scopes,
+		}
+		ctx := auth.NewContext(req.Context(), principal)
+		ctx = logging.WithFields(ctx, logrus.Fields{"subject": principal.Subject})
+		c.SetRequest(req.WithContext(ctx))
+
+		return next(c)
+	}
+}
+
+// RequireScope rejects with 403 the requests of principals lacking the read
+// scope, for GET and HEAD requests, or the write scope, for any other one.
+// Unauthenticated requests, only possible when no authentication is
+// configured, are let through.
+func (m *GoMiddleware) RequireScope(read, write string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			principal, ok := auth.FromContext(c.Request().Context())
+			if !ok {
+				return next(c)
+			}
+
+			scope := write
+			if method := c.Request().Method; method == echo.GET || method == echo.HEAD {
+				scope = read
+			}
+			if !principal.HasScope(scope) {
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Missing scope %s", scope))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/apikey/mocks"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+func TestAPIKey(t *testing.T) {
+	mockAPIKeys := new(mocks.APIKey)
+	key := &models.APIKey{ID: 7, Organisation: "org-a", Scopes: []string{models.ScopePaymentsRead}}
+	mockAPIKeys.On("Authenticate", mock.Anything, "valid").Return(key, nil)
+	mockAPIKeys.On("Authenticate", mock.Anything, "revoked").Return(nil, models.ErrInvalidAPIKey)
+	mockAPIKeys.On("Authenticate", mock.Anything, "failing").Return(nil, errors.New("Unexpected Error"))
+
+	m := middleware.InitMiddleware()
+	m.APIKeys = mockAPIKeys
+
+	tests := map[string]struct {
+		token     string
+		status    int
+		principal *auth.Principal
+	}{
+		"without key": {status: http.StatusOK},
+		"valid key": {token: "valid", status: http.StatusOK, principal: &auth.Principal{
+			Subject:      "apikey:7",
+			Organisation: "org-a",
+			Roles:        []string{auth.RoleAPIKey},
+			Scopes:       []string{models.ScopePaymentsRead},
+		}},
+		"revoked key": {token: "revoked", status: http.StatusUnauthorized},
+		"failing":     {token: "failing", status: http.StatusInternalServerError},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var principal *auth.Principal
+			e := echo.New()
+			e.GET("/payment", func(c echo.Context) error {
+				principal, _ = auth.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, m.APIKey)
+
+			req := test.NewRequest(echo.GET, "/payment", nil)
+			if tc.token != "" {
+				req.Header.Set(middleware.AccessTokenKey, tc.token)
+			}
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.principal, principal)
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	m := middleware.InitMiddleware()
+	reader := &auth.Principal{Scopes: []string{models.ScopePaymentsRead}}
+
+	tests := map[string]struct {
+		principal *auth.Principal
+		method    string
+		status    int
+	}{
+		"unauthenticated":  {method: echo.POST, status: http.StatusOK},
+		"unrestricted":     {principal: &auth.Principal{}, method: echo.POST, status: http.StatusOK},
+		"reader reading":   {principal: reader, method: echo.GET, status: http.StatusOK},
+		"reader writing":   {principal: reader, method: echo.POST, status: http.StatusForbidden},
+		"reader deleting":  {principal: reader, method: echo.DELETE, status: http.StatusForbidden},
+		"without any read": {principal: &auth.Principal{Scopes: []string{}}, method: echo.GET, status: http.StatusForbidden},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			e := echo.New()
+			e.Any("/payment", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tc.principal != nil {
+						c.SetRequest(c.Request().WithContext(auth.NewContext(c.Request().Context(), tc.principal)))
+					}
+					return next(c)
+				}
+			}, m.RequireScope(models.ScopePaymentsRead, models.ScopePaymentsWrite))
+
+			req := test.NewRequest(tc.method, "/payment", nil)
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.status == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), "Missing scope payments:")
+			}
+		})
+	}
+}
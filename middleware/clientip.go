@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ParseTrustedProxies parses the given IP addresses and CIDR networks of the
+// proxies whose forwarded client IP headers are trusted.
+func ParseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			_, n, err := net.ParseCIDR(proxy)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %v", proxy, err)
+			}
+			networks = append(networks, n)
+			continue
+		}
+
+		ip := net.ParseIP(proxy)
+		if ip == nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an IP address", proxy)
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+
+	return networks, nil
+}
+
+// clientIP address of the client of the request: its remote address, unless
+// sent by a trusted proxy. X-Forwarded-For, or else X-Real-IP, is then read,
+// the client being the rightmost hop not added by a trusted proxy, so that
+// clients cannot choose the address they are told apart by.
+func (m *GoMiddleware) clientIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	if !m.trustedProxy(ip) {
+		return ip
+	}
+
+	if header := req.Header.Get(echo.HeaderXForwardedFor); header != "" {
+		hops := strings.Split(header, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+			ip = hop
+			if !m.trustedProxy(hop) {
+				break
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(req.Header.Get(echo.HeaderXRealIP)); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+
+	return ip
+}
+
+// trustedProxy tells whether the given address is one of a trusted proxy.
+func (m *GoMiddleware) trustedProxy(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, n := range m.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
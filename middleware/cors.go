@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// anyOrigin AllowedOrigins entry allowing every origin.
+const anyOrigin = "*"
+
+// CORSPolicy cross-origin resource sharing policy. Browsers are denied
+// cross-origin access when no origin is allowed.
+type CORSPolicy struct {
+	// AllowedOrigins origins allowed to call the API, e.g.
+	// https://app.example.com, or every one of them with "*".
+	AllowedOrigins []string
+	// AllowedMethods methods allowed on cross-origin requests.
+	AllowedMethods []string
+	// AllowedHeaders request headers allowed on cross-origin requests.
+	AllowedHeaders []string
+	// ExposedHeaders response headers scripts are allowed to read, e.g.
+	// X-Cursor.
+	ExposedHeaders []string
+	// AllowCredentials allows cookies and authorization headers to be sent.
+	AllowCredentials bool
+	// MaxAge time browsers may cache preflight responses for.
+	MaxAge time.Duration
+}
+
+// allowsOrigin reports whether the given origin is allowed.
+func (p *CORSPolicy) allowsOrigin(origin string) bool {
+	for _, o := range p.AllowedOrigins {
+		if o == anyOrigin || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowOriginHeader Access-Control-Allow-Origin value answering the given
+// allowed origin, echoing it unless every origin is allowed without
+// credentials.
+func (p *CORSPolicy) allowOriginHeader(origin string) string {
+	if !p.AllowCredentials {
+		for _, o := range p.AllowedOrigins {
+			if o == anyOrigin {
+				return anyOrigin
+			}
+		}
+	}
+
+	return origin
+}
+
+// CORS applies the CORS policy: preflight requests are answered with 204 and
+// the allowed methods and headers when allowed and 403 otherwise, while
+// requests from allowed origins get the headers letting browsers read their
+// response.
+func (m *GoMiddleware) CORS(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p := &m.CORSPolicy
+		if len(p.AllowedOrigins) == 0 {
+			return next(c)
+		}
+
+		req := c.Request()
+		header := c.Response().Header()
+		header.Add(echo.HeaderVary, echo.HeaderOrigin)
+
+		origin := req.Header.Get(echo.HeaderOrigin)
+		preflight := req.Method == echo.OPTIONS && req.Header.Get(echo.HeaderAccessControlRequestMethod) != ""
+		if preflight {
+			header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
+			header.Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+		}
+		if origin == "" {
+			return next(c)
+		}
+		if !p.allowsOrigin(origin) {
+			if preflight {
+				return c.NoContent(http.StatusForbidden)
+			}
+			return next(c)
+		}
+
+		header.Set(echo.HeaderAccessControlAllowOrigin, p.allowOriginHeader(origin))
+		if p.AllowCredentials {
+			header.Set(echo.HeaderAccessControlAllowCredentials, "true")
+		}
+
+		if !preflight {
+			if len(p.ExposedHeaders) > 0 {
+				header.Set(echo.HeaderAccessControlExposeHeaders, strings.Join(p.ExposedHeaders, ", "))
+			}
+			return next(c)
+		}
+
+		if !contains(p.AllowedMethods, req.Header.Get(echo.HeaderAccessControlRequestMethod)) ||
+			!containsAll(p.AllowedHeaders, req.Header.Get(echo.HeaderAccessControlRequestHeaders)) {
+			header.Del(echo.HeaderAccessControlAllowOrigin)
+			header.Del(echo.HeaderAccessControlAllowCredentials)
+			return c.NoContent(http.StatusForbidden)
+		}
+
+		header.Set(echo.HeaderAccessControlAllowMethods, strings.Join(p.AllowedMethods, ", "))
+		if len(p.AllowedHeaders) > 0 {
+			header.Set(echo.HeaderAccessControlAllowHeaders, strings.Join(p.AllowedHeaders, ", "))
+		}
+		if p.MaxAge > 0 {
+			header.Set(echo.HeaderAccessControlMaxAge, strconv.Itoa(int(p.MaxAge/time.Second)))
+		}
+
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// contains reports whether list holds value, ignoring case.
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsAll reports whether list holds every entry of the given comma
+// separated values, ignoring case.
+func containsAll(list []string, values string) bool {
+	for _, value := range strings.Split(values, ",") {
+		if value = strings.TrimSpace(value); value != "" && !contains(list, value) {
+			return false
+		}
+	}
+
+	return true
+}
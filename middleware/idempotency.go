@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+const (
+	// IdempotencyKeyHeader header carrying the client chosen idempotency key.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// idempotencyKeyMaxLength longest idempotency key accepted.
+	idempotencyKeyMaxLength = 255
+
+	// idempotencyReservationTTL time a key is reserved for while its first
+	// request is handled, so it is freed should the process die meanwhile.
+	idempotencyReservationTTL = time.Minute
+
+	// idempotencyMaxBodySize largest request body read to be hashed.
+	idempotencyMaxBodySize = 1 << 20
+)
+
+// idempotencyHeaders response headers stored and replayed along with the
+// response body.
+var idempotencyHeaders = []string{echo.HeaderContentType, echo.HeaderLocation, "ETag"}
+
+// idempotencyWriter response writer keeping a copy of the response body.
+type idempotencyWriter struct {
+	io.Writer
+	http.ResponseWriter
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	return w.Writer.Write(b)
+}
+
+// Idempotency replays the stored response of POST requests retried with the
+// same Idempotency-Key header, rejecting keys reused with a different body.
+// The key is reserved while its first request is handled, so retries sent
+// meanwhile are rejected rather than handled twice.
+func (m *GoMiddleware) Idempotency(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		key := req.Header.Get(IdempotencyKeyHeader)
+		if req.Method != echo.POST || key == "" {
+			return next(c)
+		}
+
+		if len(key) > idempotencyKeyMaxLength {
+			return echo.NewHTTPError(http.StatusBadRequest, "Idempotency key is too long")
+		}
+
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Response(), req.Body, idempotencyMaxBodySize))
+		if _, ok := err.(*http.MaxBytesError); ok {
+			return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "Request body is too large")
+		}
+		if err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := requestHash(req, body)
+
+		ctx := req.Context()
+		key = scopedKey(ctx, key)
+		stored, err := m.IdempotencyStore.Get(ctx, key)
+		switch {
+		case err == nil && stored.RequestHash != hash:
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, models.ErrIdempotencyKeyReused.Error())
+		case err == nil && stored.Pending():
+			return echo.NewHTTPError(http.StatusConflict, models.ErrIdempotentRequestInProgress.Error())
+		case err == nil:
+			return replay(c, stored)
+		case err != models.ErrNotFound:
+			return err
+		}
+
+		now := time.Now()
+		err = m.IdempotencyStore.Store(ctx, &models.IdempotentResponse{
+			Key:         key,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyReservationTTL),
+		})
+		if err == models.ErrConflict {
+			return echo.NewHTTPError(http.StatusConflict, models.ErrIdempotentRequestInProgress.Error())
+		}
+		if err != nil {
+			return err
+		}
+
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := m.IdempotencyStore.Release(ctx, key); err != nil {
+				logging.FromContext(ctx).Error(err)
+			}
+		}()
+
+		res := c.Response()
+		buf := new(bytes.Buffer)
+		res.Writer = &idempotencyWriter{Writer: io.MultiWriter(res.Writer, buf), ResponseWriter: res.Writer}
+
+		if err = next(c); err != nil {
+			return err
+		}
+
+		// Server errors are not stored so the client can retry them.
+		if res.Status >= http.StatusInternalServerError {
+			return nil
+		}
+
+		err = m.IdempotencyStore.Complete(ctx, &models.IdempotentResponse{
+			Key:         key,
+			RequestHash: hash,
+			StatusCode:  res.Status,
+			Headers:     responseHeaders(res.Header()),
+			Body:        buf.Bytes(),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.IdempotencyTTL),
+		})
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil
+		}
+		completed = true
+
+		return nil
+	}
+}
+
+// replay sends the given stored response again, with its stored headers.
+func replay(c echo.Context, stored *models.IdempotentResponse) error {
+	contentType := echo.MIMEApplicationJSONCharsetUTF8
+	for name, value := range stored.Headers {
+		if name == echo.HeaderContentType {
+			contentType = value
+			continue
+		}
+		c.Response().Header().Set(name, value)
+	}
+
+	return c.Blob(stored.StatusCode, contentType, stored.Body)
+}
+
+// responseHeaders picks the headers of the given response to be stored.
+func responseHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string)
+	for _, name := range idempotencyHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	return headers
+}
+
+// requestHash identifies a request by its method, path and body.
+func requestHash(req *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, req.Method+" "+req.URL.Path+"\n")
+	_, _ = h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scopedKey stores the given idempotency key apart for each organisation and
+// caller, so keys chosen by others are neither replayed nor reported as used.
+func scopedKey(ctx context.Context, key string) string {
+	subject := ""
+	if p, ok := auth.FromContext(ctx); ok {
+		subject = p.Subject
+	}
+
+	h := sha256.New()
+	_, _ = io.WriteString(h, tenant.FromContext(ctx)+"\n")
+	_, _ = io.WriteString(h, subject+"\n")
+	_, _ = io.WriteString(h, key)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,231 @@
+package middleware_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/idempotency/mocks"
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestIdempotencyFirstRequest(t *testing.T) {
+	store := new(mocks.Repository)
+	store.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, models.ErrNotFound)
+	store.On("Store", mock.Anything, mock.MatchedBy(func(r *models.IdempotentResponse) bool {
+		return r.Pending() && r.ExpiresAt.Sub(r.CreatedAt) == time.Minute
+	})).Return(nil)
+	store.On("Complete", mock.Anything, mock.MatchedBy(func(r *models.IdempotentResponse) bool {
+		return r.StatusCode == http.StatusCreated &&
+			string(r.Body) == `{"id":1}` && r.ExpiresAt.Sub(r.CreatedAt) == time.Hour
+	})).Return(nil)
+
+	e := echo.New()
+	req := test.NewRequest(echo.POST, "/payment", strings.NewReader(`{"payment_id":"p1"}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	res := test.NewRecorder()
+	c := e.NewContext(req, res)
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	calls := 0
+	h := m.Idempotency(func(c echo.Context) error {
+		calls++
+		return c.JSONBlob(http.StatusCreated, []byte(`{"id":1}`))
+	})
+	assert.Nil(t, h(c))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, `{"id":1}`, res.Body.String())
+	store.AssertExpectations(t)
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	store := new(mocks.Repository)
+	e := echo.New()
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	var stored *models.IdempotentResponse
+	store.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, models.ErrNotFound).Once()
+	store.On("Store", mock.Anything, mock.Anything).Return(nil).Once()
+	store.On("Complete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.IdempotentResponse)
+	}).Return(nil).Once()
+
+	calls := 0
+	h := m.Idempotency(func(c echo.Context) error {
+		calls++
+		c.Response().Header().Set(echo.HeaderLocation, "/payment/1")
+		c.Response().Header().Set("ETag", `"1"`)
+		return c.JSONBlob(http.StatusCreated, []byte(`{"id":1}`))
+	})
+
+	req := test.NewRequest(echo.POST, "/payment", strings.NewReader(`{"payment_id":"p1"}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	assert.Nil(t, h(e.NewContext(req, test.NewRecorder())))
+
+	store.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(stored, nil)
+
+	req = test.NewRequest(echo.POST, "/payment", strings.NewReader(`{"payment_id":"p1"}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	res := test.NewRecorder()
+	assert.Nil(t, h(e.NewContext(req, res)))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, `{"id":1}`, res.Body.String())
+	assert.Equal(t, "/payment/1", res.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, `"1"`, res.Header().Get("ETag"))
+	assert.Equal(t, echo.MIMEApplicationJSONCharsetUTF8, res.Header().Get(echo.HeaderContentType))
+
+	req = test.NewRequest(echo.POST, "/payment", strings.NewReader(`{"payment_id":"p2"}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	err := h(e.NewContext(req, test.NewRecorder()))
+
+	assert.Equal(t, 1, calls)
+	if assert.IsType(t, &echo.HTTPError{}, err) {
+		assert.Equal(t, http.StatusUnprocessableEntity, err.(*echo.HTTPError).Code)
+	}
+	store.AssertExpectations(t)
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	store := new(mocks.Repository)
+
+	e := echo.New()
+	req := test.NewRequest(echo.POST, "/payment", strings.NewReader(strings.Repeat("a", 1<<20+1)))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	c := e.NewContext(req, test.NewRecorder())
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	h := m.Idempotency(func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+	err := h(c)
+
+	if assert.IsType(t, &echo.HTTPError{}, err) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*echo.HTTPError).Code)
+	}
+	store.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyWithoutKey(t *testing.T) {
+	store := new(mocks.Repository)
+
+	e := echo.New()
+	req := test.NewRequest(echo.POST, "/payment", strings.NewReader(`{}`))
+	res := test.NewRecorder()
+	c := e.NewContext(req, res)
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	h := m.Idempotency(func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	})
+	assert.Nil(t, h(c))
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	store.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func TestIdempotencyServerErrorNotStored(t *testing.T) {
+	store := new(mocks.Repository)
+	store.On("Get", mock.Anything, mock.AnythingOfType("string")).Return(nil, models.ErrNotFound)
+	store.On("Store", mock.Anything, mock.Anything).Return(nil)
+	store.On("Release", mock.Anything, mock.AnythingOfType("string")).Return(nil)
+
+	e := echo.New()
+	req := test.NewRequest(echo.POST, "/payment", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+	res := test.NewRecorder()
+	c := e.NewContext(req, res)
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	h := m.Idempotency(func(c echo.Context) error {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	assert.Nil(t, h(c))
+
+	store.AssertNotCalled(t, "Complete", mock.Anything, mock.Anything)
+	store.AssertExpectations(t)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	store := idempotencyRepo.NewMemoryIdempotency()
+	e := echo.New()
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	calls := 0
+	started, finish := make(chan struct{}), make(chan struct{})
+	h := m.Idempotency(func(c echo.Context) error {
+		calls++
+		close(started)
+		<-finish
+		return c.JSONBlob(http.StatusCreated, []byte(`{"id":1}`))
+	})
+	send := func() (*test.ResponseRecorder, error) {
+		req := test.NewRequest(echo.POST, "/payment", strings.NewReader(`{"payment_id":"p1"}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		res := test.NewRecorder()
+		return res, h(e.NewContext(req, res))
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := send()
+		done <- err
+	}()
+	<-started
+
+	_, err := send()
+	if assert.IsType(t, &echo.HTTPError{}, err) {
+		assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+	}
+
+	close(finish)
+	assert.NoError(t, <-done)
+
+	res, err := send()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, `{"id":1}`, res.Body.String())
+}
+
+func TestIdempotencyKeyPerOrganisation(t *testing.T) {
+	store := idempotencyRepo.NewMemoryIdempotency()
+	e := echo.New()
+	m := &middleware.GoMiddleware{IdempotencyStore: store, IdempotencyTTL: time.Hour}
+
+	h := m.Idempotency(func(c echo.Context) error {
+		return c.JSONBlob(http.StatusCreated, []byte(`{"organisation":"`+tenant.FromContext(c.Request().Context())+`"}`))
+	})
+	send := func(organisation, body string) (*test.ResponseRecorder, error) {
+		req := test.NewRequest(echo.POST, "/payment", strings.NewReader(body))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		req = req.WithContext(tenant.NewContext(req.Context(), organisation))
+		res := test.NewRecorder()
+		return res, h(e.NewContext(req, res))
+	}
+
+	res, err := send("org-a", `{"payment_id":"p1"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"organisation":"org-a"}`, res.Body.String())
+
+	res, err = send("org-b", `{"payment_id":"p2"}`)
+	assert.NoError(t, err, "keys chosen by other organisations are not reported as reused")
+	assert.Equal(t, `{"organisation":"org-b"}`, res.Body.String())
+
+	res, err = send("org-a", `{"payment_id":"p1"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"organisation":"org-a"}`, res.Body.String())
+}
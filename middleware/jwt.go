@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+)
+
+// bearerPrefix authorization scheme prefix of bearer tokens.
+const bearerPrefix = "Bearer "
+
+// JWT authenticates requests with the bearer token of their Authorization
+// header, storing the verified principal on the request context. Requests
+// without a valid token are rejected with 401, unless already authenticated,
+// e.g. with an API key, or authentication is disabled.
+func (m *GoMiddleware) JWT(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		if _, ok := auth.FromContext(req.Context()); ok || m.JWTVerifier == nil && m.AuthDisabled {
+			return next(c)
+		}
+		if m.JWTVerifier == nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Missing API key")
+		}
+
+		header := req.Header.Get(echo.HeaderAuthorization)
+		if len(header) <= len(bearerPrefix) || !strings.EqualFold(header[:len(bearerPrefix)], bearerPrefix) {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			return echo.NewHTTPError(http.StatusUnauthorized, "Missing bearer token")
+		}
+
+		principal, err := m.JWTVerifier.Verify(header[len(bearerPrefix):])
+		if err != nil {
+			logging.FromContext(req.Context()).WithError(err).Info("token rejected")
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+			return echo.NewHTTPError(http.StatusUnauthorized, "Invalid bearer token")
+		}
+
+		ctx := auth.NewContext(req.Context(), principal)
+		ctx = logging.WithFields(ctx, logrus.Fields{"subject": principal.Subject})
+		c.SetRequest(req.WithContext(ctx))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/middleware"
+)
+
+const jwtSecret = "0123456789abcdef0123456789abcdef"
+
+func TestJWT(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = auth.NewVerifier("", "payment")
+	m.JWTVerifier.SetSecret([]byte(jwtSecret))
+
+	e := echo.New()
+	e.GET("/payment", func(c echo.Context) error {
+		p, ok := auth.FromContext(c.Request().Context())
+		require.True(t, ok)
+		return c.String(http.StatusOK, p.Subject)
+	}, m.JWT)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "user-1",
+		"aud": "payment",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(jwtSecret))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		header       string
+		status       int
+		authenticate string
+		body         string
+	}{
+		"valid":   {header: "Bearer " + token, status: http.StatusOK, body: "user-1"},
+		"missing": {status: http.StatusUnauthorized, authenticate: "Bearer"},
+		"basic":   {header: "Basic dXNlcjpwYXNz", status: http.StatusUnauthorized, authenticate: "Bearer"},
+		"invalid": {header: "Bearer " + token + "x", status: http.StatusUnauthorized, authenticate: `Bearer error="invalid_token"`},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := test.NewRequest(echo.GET, "/payment", nil)
+			if tc.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tc.header)
+			}
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.authenticate, rec.Header().Get(echo.HeaderWWWAuthenticate))
+			if tc.body != "" {
+				assert.Equal(t, tc.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWTWithoutVerifier(t *testing.T) {
+	m := middleware.InitMiddleware()
+
+	e := echo.New()
+	e.GET("/payment", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, m.JWT)
+
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, test.NewRequest(echo.GET, "/payment", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "requests are only let through when authentication is disabled")
+
+	m.AuthDisabled = true
+	rec = test.NewRecorder()
+	e.ServeHTTP(rec, test.NewRequest(echo.GET, "/payment", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+)
+
+// maxRequestIDLength longest X-Request-ID accepted from callers, longer ones
+// being replaced so they cannot bloat every log line.
+const maxRequestIDLength = 128
+
+// RequestLogger propagates the caller's X-Request-ID, generating one when
+// missing, stores a logger tagged with it on the request context and writes an
+// access log line once the request has been handled.
+func (m *GoMiddleware) RequestLogger(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		requestID := req.Header.Get(logging.RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = logging.NewRequestID()
+		}
+		c.Response().Header().Set(logging.RequestIDHeader, requestID)
+
+		var logger logrus.FieldLogger = logrus.StandardLogger()
+		if m.Logger != nil {
+			logger = m.Logger
+		}
+		ctx := logging.NewContext(req.Context(), logger.WithField(logging.FieldRequestID, requestID))
+		c.SetRequest(req.WithContext(ctx))
+
+		start := time.Now()
+		err := next(c)
+		status, route := outcome(c, err)
+
+		// Later middlewares may have tagged the logger, e.g. with the tenant.
+		entry := logging.FromContext(c.Request().Context()).WithFields(logrus.Fields{
+			"method":     req.Method,
+			"route":      route,
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+		switch {
+		case status >= 500:
+			entry.Error("request handled")
+		case status >= 400:
+			entry.Warn("request handled")
+		default:
+			entry.Info("request handled")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/middleware"
+)
+
+// jsonLines decodes the JSON log lines written to out.
+func jsonLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	lines := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		fields := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal([]byte(line), &fields))
+		lines = append(lines, fields)
+	}
+
+	return lines
+}
+
+func TestRequestLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	m := middleware.InitMiddleware()
+	m.Logger = logger
+
+	e := echo.New()
+	e.Use(m.RequestLogger)
+	e.GET("/payment/:id", func(c echo.Context) error {
+		ctx := logging.WithFields(c.Request().Context(), logrus.Fields{logging.FieldTenant: "org-1"})
+		c.SetRequest(c.Request().WithContext(ctx))
+		logging.FromContext(ctx).Info("handling")
+		return c.String(http.StatusOK, "ok")
+	})
+
+	req := test.NewRequest(echo.GET, "/payment/1", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-1")
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-1", rec.Header().Get(logging.RequestIDHeader))
+
+	lines := jsonLines(t, &out)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "handling", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+
+	access := lines[1]
+	assert.Equal(t, "request handled", access["msg"])
+	assert.Equal(t, "info", access["level"])
+	assert.Equal(t, "req-1", access["request_id"])
+	assert.Equal(t, "org-1", access["tenant"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/payment/:id", access["route"])
+	assert.Equal(t, float64(http.StatusOK), access["status"])
+	assert.Contains(t, access, "latency_ms")
+}
+
+func TestRequestLoggerGeneratesRequestID(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	m := middleware.InitMiddleware()
+	m.Logger = logger
+
+	e := echo.New()
+	e.Use(m.RequestLogger)
+
+	req := test.NewRequest(echo.GET, "/unknown", nil)
+	req.Header.Set(logging.RequestIDHeader, strings.Repeat("x", 200))
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	requestID := rec.Header().Get(logging.RequestIDHeader)
+	assert.Len(t, requestID, 32, "overlong request IDs are replaced")
+
+	lines := jsonLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, requestID, lines[0]["request_id"])
+	assert.Equal(t, "warning", lines[0]["level"])
+	assert.Equal(t, "unmatched", lines[0]["route"])
+	assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// unmatchedRoute route label of the requests not matching any route, keeping
+// unknown paths from creating a label value each.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of the requests per route and
+// status code.
+func (m *GoMiddleware) Metrics(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if m.HTTPMetrics == nil {
+			return next(c)
+		}
+
+		start := time.Now()
+		err := next(c)
+
+		status, route := outcome(c, err)
+		m.HTTPMetrics.Observe(c.Request().Method, route, status, time.Since(start))
+
+		return err
+	}
+}
+
+// outcome status code and route template of a request handled by next.
+func outcome(c echo.Context, err error) (int, string) {
+	// Errors are only turned into responses once the middleware chain
+	// returns, so their status is taken from the error itself.
+	status := c.Response().Status
+	if err != nil {
+		status = http.StatusInternalServerError
+		if he, ok := err.(*echo.HTTPError); ok {
+			status = he.Code
+		}
+	}
+
+	// Echo reports the request path as the route when none matches.
+	route := c.Path()
+	if route == "" || err == echo.ErrNotFound || err == echo.ErrMethodNotAllowed {
+		route = unmatchedRoute
+	}
+
+	return status, route
+}
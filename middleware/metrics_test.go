@@ -0,0 +1,60 @@
+package middleware_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+	"github.com/adriacidre/go-clean-arch/middleware"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := middleware.InitMiddleware()
+	m.HTTPMetrics = metrics.NewHTTP(reg)
+
+	e := echo.New()
+	e.Use(m.Metrics)
+	e.GET("/payment/:id", func(c echo.Context) error {
+		if c.Param("id") == "0" {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid id")
+		}
+		return c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/payment/1", "/payment/2", "/payment/0", "/unknown/1"} {
+		e.ServeHTTP(test.NewRecorder(), test.NewRequest(echo.GET, path, nil))
+	}
+
+	expected := `
+# HELP payment_http_requests_total HTTP requests handled.
+# TYPE payment_http_requests_total counter
+payment_http_requests_total{method="GET",route="/payment/:id",status="200"} 2
+payment_http_requests_total{method="GET",route="/payment/:id",status="400"} 1
+payment_http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "payment_http_requests_total"))
+	count, err := testutil.GatherAndCount(reg, "payment_http_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	m := middleware.InitMiddleware()
+
+	e := echo.New()
+	e.Use(m.Metrics)
+	e.GET("/payment", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, test.NewRequest(echo.GET, "/payment", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
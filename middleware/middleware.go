@@ -1,6 +1,18 @@
 package middleware
 
-import "github.com/labstack/echo"
+import (
+	"net"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/adriacidre/go-clean-arch/apikey"
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/metrics"
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+)
 
 const (
 	// AccessTokenKey valid access token key.
@@ -8,14 +20,45 @@ const (
 )
 
 type GoMiddleware struct {
-	// another stuff , may be needed by middleware
-}
-
-func (m *GoMiddleware) CORS(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		c.Response().Header().Set("Access-Control-Allow-Origin", "*")
-		return next(c)
-	}
+	// IdempotencyStore stores the responses replayed to retried requests.
+	IdempotencyStore idempotency.Repository
+	// IdempotencyTTL time an idempotency key is kept for.
+	IdempotencyTTL time.Duration
+	// HTTPMetrics records the requests served, none when nil.
+	HTTPMetrics *metrics.HTTP
+	// TracerProvider traces the requests served, none when nil.
+	TracerProvider trace.TracerProvider
+	// Logger base of the request scoped loggers, the standard logger when nil.
+	Logger *logrus.Logger
+	// JWTVerifier verifies the bearer tokens of protected routes, which only
+	// accept API keys when nil.
+	JWTVerifier *auth.Verifier
+	// AuthDisabled lets the unauthenticated requests of protected routes
+	// through, unrestricted, instead of rejecting them with 401. Bearer tokens
+	// are still required when JWTVerifier is set.
+	AuthDisabled bool
+	// APIKeys authenticates the API keys of protected routes, which are only
+	// authenticated with bearer tokens when nil.
+	APIKeys apikey.Usecase
+	// CORSPolicy cross-origin resource sharing policy, denying cross-origin
+	// access when zero.
+	CORSPolicy CORSPolicy
+	// RateLimiter keeps the rate limited clients token buckets, requests
+	// being unlimited when nil.
+	RateLimiter ratelimit.Store
+	// RateLimits limits of the routes named by their method and path, e.g.
+	// "POST /payment".
+	RateLimits map[string]ratelimit.Limit
+	// DefaultRateLimit limit of the routes missing from RateLimits, unlimited
+	// when zero.
+	DefaultRateLimit ratelimit.Limit
+	// IPRateLimit limit of the requests of each client IP, taken before
+	// authentication, unlimited when zero.
+	IPRateLimit ratelimit.Limit
+	// TrustedProxies networks of the proxies whose X-Forwarded-For and
+	// X-Real-IP headers tell the client IP, which is the remote address of
+	// requests sent by any other host.
+	TrustedProxies []*net.IPNet
 }
 
 // InitMiddleware initializes middleware.
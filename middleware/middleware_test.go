@@ -3,6 +3,7 @@ package middleware_test
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/adriacidre/go-clean-arch/middleware"
 	"github.com/labstack/echo"
@@ -12,12 +13,30 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+// corsServer serves GET /payment behind the CORS middleware with the given
+// policy.
+func corsServer(policy middleware.CORSPolicy) *echo.Echo {
+	m := middleware.InitMiddleware()
+	m.CORSPolicy = policy
+
+	e := echo.New()
+	e.Use(m.CORS)
+	e.GET("/payment", func(c echo.Context) error {
+		c.Response().Header().Set("X-Cursor", "10")
+		return c.NoContent(http.StatusOK)
+	})
+
+	return e
+}
+
 func TestCORS(t *testing.T) {
 	e := echo.New()
 	req := test.NewRequest(echo.GET, "/", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
 	res := test.NewRecorder()
 	c := e.NewContext(req, res)
 	m := middleware.InitMiddleware()
+	m.CORSPolicy.AllowedOrigins = []string{"*"}
 
 	h := m.CORS(echo.HandlerFunc(func(c echo.Context) error {
 		return c.NoContent(http.StatusOK)
@@ -26,3 +45,101 @@ func TestCORS(t *testing.T) {
 
 	assert.Equal(t, "*", res.Header().Get("Access-Control-Allow-Origin"))
 }
+
+func TestCORSDisabled(t *testing.T) {
+	e := corsServer(middleware.CORSPolicy{})
+
+	req := test.NewRequest(echo.GET, "/payment", nil)
+	req.Header.Set(echo.HeaderOrigin, "https://app.example.com")
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+}
+
+func TestCORSRequest(t *testing.T) {
+	e := corsServer(middleware.CORSPolicy{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		ExposedHeaders:   []string{"X-Cursor"},
+		AllowCredentials: true,
+	})
+
+	tests := map[string]struct {
+		origin      string
+		allowOrigin string
+	}{
+		"same origin":      {},
+		"allowed origin":   {origin: "https://app.example.com", allowOrigin: "https://app.example.com"},
+		"unknown origin":   {origin: "https://evil.example.com"},
+		"origin lookalike": {origin: "https://app.example.com.evil.com"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := test.NewRequest(echo.GET, "/payment", nil)
+			if tc.origin != "" {
+				req.Header.Set(echo.HeaderOrigin, tc.origin)
+			}
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "10", rec.Header().Get("X-Cursor"))
+			assert.Equal(t, []string{echo.HeaderOrigin}, rec.Header()[echo.HeaderVary])
+			assert.Equal(t, tc.allowOrigin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			if tc.allowOrigin != "" {
+				assert.Equal(t, "true", rec.Header().Get(echo.HeaderAccessControlAllowCredentials))
+				assert.Equal(t, "X-Cursor", rec.Header().Get(echo.HeaderAccessControlExposeHeaders))
+			} else {
+				assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowCredentials))
+				assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlExposeHeaders))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	e := corsServer(middleware.CORSPolicy{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	tests := map[string]struct {
+		origin  string
+		method  string
+		headers string
+		status  int
+	}{
+		"allowed":         {origin: "https://app.example.com", method: "POST", headers: "content-type, authorization", status: http.StatusNoContent},
+		"without headers": {origin: "https://app.example.com", method: "GET", status: http.StatusNoContent},
+		"unknown origin":  {origin: "https://evil.example.com", method: "POST", status: http.StatusForbidden},
+		"method denied":   {origin: "https://app.example.com", method: "DELETE", status: http.StatusForbidden},
+		"header denied":   {origin: "https://app.example.com", method: "POST", headers: "X-Secret", status: http.StatusForbidden},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := test.NewRequest(echo.OPTIONS, "/payment", nil)
+			req.Header.Set(echo.HeaderOrigin, tc.origin)
+			req.Header.Set(echo.HeaderAccessControlRequestMethod, tc.method)
+			if tc.headers != "" {
+				req.Header.Set(echo.HeaderAccessControlRequestHeaders, tc.headers)
+			}
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, []string{echo.HeaderOrigin, echo.HeaderAccessControlRequestMethod, echo.HeaderAccessControlRequestHeaders},
+				rec.Header()[echo.HeaderVary])
+			if tc.status != http.StatusNoContent {
+				assert.Empty(t, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+				return
+			}
+			assert.Equal(t, tc.origin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+			assert.Equal(t, "GET, POST", rec.Header().Get(echo.HeaderAccessControlAllowMethods))
+			assert.Equal(t, "Content-Type, Authorization", rec.Header().Get(echo.HeaderAccessControlAllowHeaders))
+			assert.Equal(t, "600", rec.Header().Get(echo.HeaderAccessControlMaxAge))
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+// Rate limit response headers.
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimitIP limits the requests of every client IP to IPRateLimit, across
+// routes, answering 429 once it is exceeded. It runs before authentication,
+// so that requests failing it are limited too.
+func (m *GoMiddleware) RateLimitIP(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if m.RateLimiter == nil || m.IPRateLimit.IsZero() {
+			return next(c)
+		}
+
+		if err := m.take(c, "ip:"+m.clientIP(c.Request()), m.IPRateLimit); err != nil {
+			return err
+		}
+
+		return next(c)
+	}
+}
+
+// RateLimit limits the requests of every client to each route, answering
+// 429 once its limit is exceeded. Clients are told apart by their API key,
+// their organisation or, when unauthenticated, their IP. Routes get the limit
+// named by their method and path in RateLimits, e.g. "POST /payment", or
+// DefaultRateLimit, being unlimited when it is zero.
+func (m *GoMiddleware) RateLimit(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if m.RateLimiter == nil {
+			return next(c)
+		}
+
+		route := c.Request().Method + " " + c.Path()
+		limit, ok := m.RateLimits[route]
+		if !ok {
+			limit = m.DefaultRateLimit
+		}
+		if limit.IsZero() {
+			return next(c)
+		}
+
+		if err := m.take(c, m.rateLimitClient(c)+" "+route, limit); err != nil {
+			return err
+		}
+
+		return next(c)
+	}
+}
+
+// take takes a request from the bucket of the given key, setting the rate
+// limit headers and failing with 429 once its limit is exceeded.
+func (m *GoMiddleware) take(c echo.Context, key string, limit ratelimit.Limit) error {
+	ctx := c.Request().Context()
+	res, err := m.RateLimiter.Take(ctx, key, limit)
+	if err != nil {
+		// Clients are not punished for an unavailable store.
+		logging.FromContext(ctx).WithError(err).Error("rate limiter unavailable")
+		return nil
+	}
+
+	header := c.Response().Header()
+	header.Set(RateLimitLimitHeader, strconv.Itoa(limit.Requests))
+	header.Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+	header.Set(RateLimitResetHeader, strconv.Itoa(seconds(res.Reset)))
+	if !res.Allowed {
+		header.Set(RetryAfterHeader, strconv.Itoa(seconds(res.RetryAfter)))
+		return echo.NewHTTPError(http.StatusTooManyRequests, "Rate limit exceeded")
+	}
+
+	return nil
+}
+
+// rateLimitClient identifies the client of the request.
+func (m *GoMiddleware) rateLimitClient(c echo.Context) string {
+	ctx := c.Request().Context()
+	principal, ok := auth.FromContext(ctx)
+	switch {
+	case ok && principal.HasRole(auth.RoleAPIKey):
+		return principal.Subject
+	case tenant.FromContext(ctx) != "":
+		return "tenant:" + tenant.FromContext(ctx)
+	case ok:
+		return "subject:" + principal.Subject
+	default:
+		return "ip:" + m.clientIP(c.Request())
+	}
+}
+
+// seconds rounds the given duration up to whole seconds, as rate limit
+// headers carry them.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
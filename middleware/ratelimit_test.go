@@ -0,0 +1,194 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+// failingStore rate limiter store that is never available.
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store down")
+}
+
+// rateLimitedServer serves GET and POST /payment behind the rate limit
+// middleware, authenticating requests as the principal of their
+// Authorization header, if any, and scoping them to its organisation.
+func rateLimitedServer(m *middleware.GoMiddleware) *echo.Echo {
+	principals := map[string]*auth.Principal{
+		"key-1":  {Subject: "apikey:1", Organisation: "org-a", Roles: []string{auth.RoleAPIKey}},
+		"key-2":  {Subject: "apikey:2", Organisation: "org-a", Roles: []string{auth.RoleAPIKey}},
+		"user-a": {Subject: "user-a", Organisation: "org-a"},
+		"user-b": {Subject: "user-b", Organisation: "org-a"},
+	}
+	authenticate := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if p, ok := principals[c.Request().Header.Get(echo.HeaderAuthorization)]; ok {
+				ctx := auth.NewContext(c.Request().Context(), p)
+				if !p.HasRole(auth.RoleAPIKey) {
+					ctx = tenant.NewContext(ctx, p.Organisation)
+				}
+				c.SetRequest(c.Request().WithContext(ctx))
+			}
+			return next(c)
+		}
+	}
+
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/payment", ok, authenticate, m.RateLimit)
+	e.POST("/payment", ok, authenticate, m.RateLimit)
+
+	return e
+}
+
+func serveAs(e *echo.Echo, method, authorization, ip string) *test.ResponseRecorder {
+	req := test.NewRequest(method, "/payment", nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	req.RemoteAddr = ip + ":1234"
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestRateLimit(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.RateLimiter = ratelimit.NewMemoryStore()
+	m.RateLimits = map[string]ratelimit.Limit{"POST /payment": {Requests: 2, Period: time.Hour}}
+
+	e := rateLimitedServer(m)
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		rec := serveAs(e, echo.POST, "key-1", "10.0.0.1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get(middleware.RateLimitLimitHeader))
+		assert.Equal(t, strconv.Itoa(remaining), rec.Header().Get(middleware.RateLimitRemainingHeader))
+	}
+
+	rec := serveAs(e, echo.POST, "key-1", "10.0.0.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1800", rec.Header().Get(middleware.RetryAfterHeader))
+	assert.Equal(t, "3600", rec.Header().Get(middleware.RateLimitResetHeader))
+	assert.Equal(t, "0", rec.Header().Get(middleware.RateLimitRemainingHeader))
+
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "key-2", "10.0.0.1").Code, "API keys are limited apart")
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.GET, "key-1", "10.0.0.1").Code, "routes without limit are unlimited")
+	assert.Empty(t, serveAs(e, echo.GET, "key-1", "10.0.0.1").Header().Get(middleware.RateLimitLimitHeader))
+
+	// Users are limited by organisation, anonymous clients by IP.
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "user-a", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "user-a", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveAs(e, echo.POST, "user-b", "10.0.0.2").Code)
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveAs(e, echo.POST, "", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "", "10.0.0.2").Code)
+}
+
+func TestRateLimitDefault(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.RateLimiter = ratelimit.NewMemoryStore()
+	m.DefaultRateLimit = ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	e := rateLimitedServer(m)
+
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.GET, "user-a", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveAs(e, echo.GET, "user-a", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, serveAs(e, echo.POST, "user-a", "10.0.0.1").Code, "each route has its own bucket")
+}
+
+func TestRateLimitStoreUnavailable(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.RateLimiter = failingStore{}
+	m.DefaultRateLimit = ratelimit.Limit{Requests: 1, Period: time.Minute}
+
+	e := rateLimitedServer(m)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, serveAs(e, echo.GET, "user-a", "10.0.0.1").Code)
+	}
+}
+
+func TestRateLimitForwardedIP(t *testing.T) {
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	assert.NoError(t, err)
+	m := middleware.InitMiddleware()
+	m.RateLimiter = ratelimit.NewMemoryStore()
+	m.DefaultRateLimit = ratelimit.Limit{Requests: 1, Period: time.Hour}
+	m.TrustedProxies = proxies
+
+	e := rateLimitedServer(m)
+	serve := func(remote string, headers map[string]string) int {
+		req := test.NewRequest(echo.GET, "/payment", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		req.RemoteAddr = remote + ":1234"
+		rec := test.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Clients not behind a trusted proxy cannot choose their address.
+	assert.Equal(t, http.StatusOK, serve("203.0.113.1", map[string]string{echo.HeaderXForwardedFor: "198.51.100.1"}))
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.1", map[string]string{echo.HeaderXForwardedFor: "198.51.100.2"}))
+	assert.Equal(t, http.StatusTooManyRequests, serve("203.0.113.1", map[string]string{echo.HeaderXRealIP: "198.51.100.3"}))
+
+	// Trusted proxies tell the client apart, hops they did not add ignored.
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1", map[string]string{echo.HeaderXForwardedFor: "198.51.100.4, 192.168.1.1"}))
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.2", map[string]string{echo.HeaderXForwardedFor: "1.2.3.4, 198.51.100.4"}))
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1", map[string]string{echo.HeaderXRealIP: "198.51.100.5"}))
+	assert.Equal(t, http.StatusOK, serve("10.0.0.1", nil))
+	assert.Equal(t, http.StatusTooManyRequests, serve("10.0.0.1", nil))
+}
+
+func TestRateLimitIP(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.RateLimiter = ratelimit.NewMemoryStore()
+	m.IPRateLimit = ratelimit.Limit{Requests: 2, Period: time.Hour}
+
+	calls := 0
+	e := echo.New()
+	reject := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			calls++
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+	}
+	e.GET("/payment", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, m.RateLimitIP, reject)
+
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, echo.GET, "wrong", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, echo.GET, "wrong", "10.0.0.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serveAs(e, echo.GET, "wrong", "10.0.0.1").Code, "failed authentications are limited")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusUnauthorized, serveAs(e, echo.GET, "wrong", "10.0.0.2").Code)
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := middleware.ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1", "::1"})
+	if assert.NoError(t, err) && assert.Len(t, proxies, 3) {
+		assert.Equal(t, "192.168.1.1/32", proxies[1].String())
+		assert.Equal(t, "::1/128", proxies[2].String())
+	}
+
+	_, err = middleware.ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+}
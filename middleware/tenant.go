@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+const (
+	// OrganisationHeader header admins name the organisation they operate on
+	// with.
+	OrganisationHeader = "X-Organisation"
+
+	// allOrganisations OrganisationHeader value of admins operating across
+	// every organisation.
+	allOrganisations = "*"
+)
+
+// Tenant restricts the request to the organisation of the authenticated
+// principal. Admins may name another organisation, or every one of them with
+// "*", through the X-Organisation header. Unauthenticated requests are
+// rejected with 401, unless authentication is disabled, which leaves them
+// unrestricted.
+func (m *GoMiddleware) Tenant(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		principal, ok := auth.FromContext(req.Context())
+		if !ok && m.AuthDisabled {
+			return next(c)
+		}
+		if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Not authenticated")
+		}
+
+		organisation := principal.Organisation
+		requested := req.Header.Get(OrganisationHeader)
+		switch {
+		case requested != "" && !principal.HasRole(auth.RoleAdmin):
+			if requested != organisation {
+				return echo.NewHTTPError(http.StatusForbidden, "Only admins can operate on other organisations")
+			}
+		case requested != "":
+			organisation = requested
+		}
+		if organisation == "" {
+			return echo.NewHTTPError(http.StatusForbidden, "No organisation to operate on")
+		}
+
+		scope := organisation
+		if organisation == allOrganisations {
+			scope = ""
+		}
+		ctx := tenant.NewContext(req.Context(), scope)
+		ctx = logging.WithFields(ctx, logrus.Fields{logging.FieldTenant: organisation})
+		c.SetRequest(req.WithContext(ctx))
+
+		return next(c)
+	}
+}
@@ -0,0 +1,78 @@
+package middleware_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestTenant(t *testing.T) {
+	m := middleware.InitMiddleware()
+
+	tests := map[string]struct {
+		principal *auth.Principal
+		header    string
+		status    int
+		scope     string
+	}{
+		"unauthenticated":     {status: http.StatusUnauthorized},
+		"member":              {principal: &auth.Principal{Organisation: "org-a"}, status: http.StatusOK, scope: "org-a"},
+		"member naming own":   {principal: &auth.Principal{Organisation: "org-a"}, header: "org-a", status: http.StatusOK, scope: "org-a"},
+		"member naming other": {principal: &auth.Principal{Organisation: "org-a"}, header: "org-b", status: http.StatusForbidden},
+		"member without org":  {principal: &auth.Principal{}, status: http.StatusForbidden},
+		"admin":               {principal: &auth.Principal{Organisation: "ops", Roles: []string{auth.RoleAdmin}}, status: http.StatusOK, scope: "ops"},
+		"admin naming other":  {principal: &auth.Principal{Organisation: "ops", Roles: []string{auth.RoleAdmin}}, header: "org-b", status: http.StatusOK, scope: "org-b"},
+		"admin naming every":  {principal: &auth.Principal{Roles: []string{auth.RoleAdmin}}, header: "*", status: http.StatusOK},
+		"admin without org":   {principal: &auth.Principal{Roles: []string{auth.RoleAdmin}}, status: http.StatusForbidden},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var scope string
+			e := echo.New()
+			e.GET("/payment", func(c echo.Context) error {
+				scope = tenant.FromContext(c.Request().Context())
+				return c.NoContent(http.StatusOK)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					if tc.principal != nil {
+						c.SetRequest(c.Request().WithContext(auth.NewContext(c.Request().Context(), tc.principal)))
+					}
+					return next(c)
+				}
+			}, m.Tenant)
+
+			req := test.NewRequest(echo.GET, "/payment", nil)
+			if tc.header != "" {
+				req.Header.Set(middleware.OrganisationHeader, tc.header)
+			}
+			rec := test.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.scope, scope)
+		})
+	}
+}
+
+func TestTenantAuthDisabled(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.AuthDisabled = true
+
+	e := echo.New()
+	e.GET("/payment", func(c echo.Context) error {
+		assert.Empty(t, tenant.FromContext(c.Request().Context()))
+		return c.NoContent(http.StatusOK)
+	}, m.Tenant)
+
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, test.NewRequest(echo.GET, "/payment", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "unauthenticated requests are left unrestricted")
+}
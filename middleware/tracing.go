@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+// tracerName instrumentation name of the spans started by the middleware.
+const tracerName = "github.com/adriacidre/go-clean-arch/middleware"
+
+// Tracing starts a server span for every request, continuing the trace of the
+// W3C traceparent header when present and returning the traceparent of the
+// span on the response.
+func (m *GoMiddleware) Tracing(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if m.TracerProvider == nil {
+			return next(c)
+		}
+
+		req := c.Request()
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+		// The route is only known once echo matched the request, so the span
+		// is renamed after the handler runs.
+		ctx, span := m.TracerProvider.Tracer(tracerName).Start(ctx, req.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(tracing.AttrHTTPMethod.String(req.Method)),
+		)
+		defer span.End()
+
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Response().Header()))
+		c.SetRequest(req.WithContext(ctx))
+
+		err := next(c)
+
+		status, route := outcome(c, err)
+		span.SetName(req.Method + " " + route)
+		span.SetAttributes(
+			tracing.AttrHTTPRoute.String(route),
+			tracing.AttrHTTPStatusCode.Int(status),
+		)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+
+		return err
+	}
+}
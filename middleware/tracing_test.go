@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	test "net/http/httptest"
+
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+func TestTracing(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	sr := tracetest.NewSpanRecorder()
+	m := middleware.InitMiddleware()
+	m.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	var handlerSpan trace.SpanContext
+	e := echo.New()
+	e.Use(m.Tracing)
+	e.GET("/payment/:id", func(c echo.Context) error {
+		handlerSpan = trace.SpanContextFromContext(c.Request().Context())
+		return c.String(http.StatusOK, "ok")
+	})
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := test.NewRequest(echo.GET, "/payment/1", nil)
+	req.Header.Set("traceparent", parent)
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /payment/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Contains(t, span.Attributes(), tracing.AttrHTTPRoute.String("/payment/:id"))
+	assert.Contains(t, span.Attributes(), tracing.AttrHTTPStatusCode.Int(http.StatusOK))
+
+	assert.Equal(t, span.SpanContext().SpanID(), handlerSpan.SpanID(), "the handler must run within the span")
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+span.SpanContext().SpanID().String()+"-01", rec.Header().Get("traceparent"))
+}
+
+func TestTracingUnmatchedRoute(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	m := middleware.InitMiddleware()
+	m.TracerProvider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	e := echo.New()
+	e.Use(m.Tracing)
+	e.ServeHTTP(test.NewRecorder(), test.NewRequest(echo.GET, "/unknown/1", nil))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET unmatched", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), tracing.AttrHTTPStatusCode.Int(http.StatusNotFound))
+	assert.False(t, spans[0].Parent().IsValid(), "requests without traceparent start a new trace")
+}
+
+func TestTracingDisabled(t *testing.T) {
+	m := middleware.InitMiddleware()
+
+	e := echo.New()
+	e.Use(m.Tracing)
+	e.GET("/payment", func(c echo.Context) error {
+		assert.False(t, trace.SpanFromContext(c.Request().Context()).IsRecording())
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := test.NewRecorder()
+	e.ServeHTTP(rec, test.NewRequest(echo.GET, "/payment", nil).WithContext(context.Background()))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("traceparent"))
+}
@@ -0,0 +1,401 @@
+// Package migration applies the versioned database schema migrations embedded
+// in the binary, keeping track of the applied ones in schema_migrations.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql
+var files embed.FS
+
+var (
+	// ErrUnknownDriver no migrations exist for the given database driver.
+	ErrUnknownDriver = errors.New("No migrations for the given database driver")
+	// ErrUnknownVersion the requested version is not one of the migrations.
+	ErrUnknownVersion = errors.New("Unknown migration version")
+	// ErrDirty the applied migrations are not a prefix of the known ones.
+	ErrDirty = errors.New("Applied migrations do not match the embedded ones")
+	// ErrAlreadyApplied migrations were applied to the database, which has
+	// no schema to adopt then.
+	ErrAlreadyApplied = errors.New("Migrations were already applied to the database")
+)
+
+// Migration a single schema change with the statements to apply and revert it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status whether a migration has been applied and when.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of a database driver to a database.
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator migrator constructor for the given database driver, one of
+// mysql, postgres or sqlite3.
+func NewMigrator(db *sql.DB, driver string) (*Migrator, error) {
+	migrations, err := Load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Load reads the embedded migrations of the given driver ordered by version.
+// Files are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Load(driver string) ([]Migration, error) {
+	dir := path.Join("sql", driver)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, ErrUnknownDriver
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("Malformed migration file name %s", name)
+		}
+
+		content, err := fs.ReadFile(files, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("Migration %d must have both up and down files", m.Version)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		result = append(result, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+
+	return result, nil
+}
+
+// Version returns the latest applied migration version, 0 when none is.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.current(applied)
+}
+
+// Up applies every pending migration, returning the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+
+	return m.To(ctx, m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the latest applied migration, returning it.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version == 0 {
+		return nil, nil
+	}
+
+	target := int64(0)
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+
+	return m.To(ctx, target)
+}
+
+// To applies or reverts migrations until the given version is the latest
+// applied one, returning the migrations run in order. Version 0 reverts all.
+func (m *Migrator) To(ctx context.Context, version int64) ([]Migration, error) {
+	if version != 0 && m.index(version) < 0 {
+		return nil, ErrUnknownVersion
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.current(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	run := make([]Migration, 0)
+	if version >= current {
+		for _, migration := range m.migrations {
+			if migration.Version <= current || migration.Version > version {
+				continue
+			}
+			if err = m.apply(ctx, migration, true); err != nil {
+				return run, err
+			}
+			run = append(run, migration)
+		}
+
+		return run, nil
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= version {
+			continue
+		}
+		if err = m.apply(ctx, migration, false); err != nil {
+			return run, err
+		}
+		run = append(run, migration)
+	}
+
+	return run, nil
+}
+
+// Baseline records every migration up to the given version as applied
+// without running them, adopting a database whose schema was created
+// otherwise, such as by the db.sql scripts the migrations replaced, which
+// match version 3. Only databases without applied migrations are adopted.
+func (m *Migrator) Baseline(ctx context.Context, version int64) ([]Migration, error) {
+	if m.index(version) < 0 {
+		return nil, ErrUnknownVersion
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > 0 {
+		return nil, ErrAlreadyApplied
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	run := make([]Migration, 0)
+	for _, migration := range m.migrations[:m.index(version)+1] {
+		if err = m.record(ctx, tx, migration); err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+		run = append(run, migration)
+	}
+
+	return run, tx.Commit()
+}
+
+// apply runs a migration in the given direction along with its tracking row.
+// MySQL commits DDL statements implicitly, so there a failing migration may
+// be left half applied and need fixing by hand.
+func (m *Migrator) apply(ctx context.Context, migration Migration, up bool) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+	for _, statement := range statements(script) {
+		if _, err = tx.ExecContext(ctx, statement); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("Migration %d %s failed: %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	if up {
+		err = m.record(ctx, tx, migration)
+	} else {
+		query := `DELETE FROM schema_migrations WHERE version = ` + m.placeholder(1)
+		_, err = tx.ExecContext(ctx, query, migration.Version)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// record inserts the tracking row of an applied migration.
+func (m *Migrator) record(ctx context.Context, tx *sql.Tx, migration Migration) error {
+	query := `INSERT INTO schema_migrations (version, name, applied_at) VALUES (` +
+		m.placeholder(1) + `, ` + m.placeholder(2) + `, ` + m.placeholder(3) + `)`
+	_, err := tx.ExecContext(ctx, query, migration.Version, migration.Name, time.Now().UTC())
+
+	return err
+}
+
+// applied creates the tracking table when missing and reads the applied
+// migration versions.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	timestamp := "datetime"
+	if m.driver == "postgres" {
+		timestamp = "timestamptz"
+	}
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint NOT NULL PRIMARY KEY,
+		name varchar(255) NOT NULL,
+		applied_at ` + timestamp + ` NOT NULL)`
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = appliedAt
+	}
+
+	return result, rows.Err()
+}
+
+// current returns the latest applied version, checking no earlier migration
+// was skipped.
+func (m *Migrator) current(applied map[int64]time.Time) (int64, error) {
+	current := int64(0)
+	for version := range applied {
+		if m.index(version) < 0 {
+			return 0, ErrDirty
+		}
+		if version > current {
+			current = version
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version > current {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			return 0, ErrDirty
+		}
+	}
+
+	return current, nil
+}
+
+func (m *Migrator) index(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *Migrator) placeholder(n int) string {
+	if m.driver == "postgres" {
+		return "$" + strconv.Itoa(n)
+	}
+
+	return "?"
+}
+
+// statements splits a migration script into its statements, each one ending
+// with a semicolon at the end of a line.
+func statements(script string) []string {
+	result := make([]string, 0)
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+
+	return result
+}
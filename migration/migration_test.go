@@ -0,0 +1,188 @@
+package migration_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/migration"
+)
+
+func openSqlite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+
+	return db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	return schemaExists(t, db, "table", name)
+}
+
+func indexExists(t *testing.T, db *sql.DB, name string) bool {
+	return schemaExists(t, db, "index", name)
+}
+
+func columnExists(t *testing.T, db *sql.DB, table, name string) bool {
+	var count int
+	err := db.QueryRow(`SELECT count(*) FROM pragma_table_info(?) WHERE name = ?`, table, name).Scan(&count)
+	require.NoError(t, err)
+
+	return count == 1
+}
+
+func schemaExists(t *testing.T, db *sql.DB, kind, name string) bool {
+	var count int
+	err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = ? AND name = ?`, kind, name).Scan(&count)
+	require.NoError(t, err)
+
+	return count == 1
+}
+
+func TestLoadSameVersionsForEveryDriver(t *testing.T) {
+	mysql, err := migration.Load("mysql")
+	require.NoError(t, err)
+	require.NotEmpty(t, mysql)
+
+	for _, driver := range []string{"postgres", "sqlite3"} {
+		migrations, err := migration.Load(driver)
+		require.NoError(t, err)
+		require.Len(t, migrations, len(mysql), driver)
+		for i := range migrations {
+			assert.Equal(t, mysql[i].Version, migrations[i].Version, driver)
+			assert.Equal(t, mysql[i].Name, migrations[i].Name, driver)
+		}
+	}
+
+	_, err = migration.Load("oracle")
+	assert.Equal(t, migration.ErrUnknownDriver, err)
+}
+
+func TestUpStatusDown(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	require.NoError(t, err)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range status {
+		assert.False(t, s.Applied)
+	}
+
+	run, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, run, len(status))
+	assert.True(t, tableExists(t, db, "payment"))
+	assert.True(t, tableExists(t, db, "webhook_delivery"))
+	assert.True(t, indexExists(t, db, "payment_payment_id"))
+	assert.True(t, columnExists(t, db, "payment", "deleted_at"))
+	assert.True(t, columnExists(t, db, "idempotent_response", "headers"))
+
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range status {
+		assert.True(t, s.Applied)
+		assert.False(t, s.AppliedAt.IsZero())
+	}
+
+	run, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, run, 0)
+
+	run, err = m.Down(ctx)
+	require.NoError(t, err)
+	if assert.Len(t, run, 1) {
+		assert.Equal(t, status[len(status)-1].Version, run[0].Version)
+	}
+	assert.False(t, columnExists(t, db, "idempotent_response", "headers"))
+	assert.True(t, columnExists(t, db, "payment", "deleted_at"))
+
+	version, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, status[len(status)-2].Version, version)
+}
+
+func TestTo(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	require.NoError(t, err)
+
+	run, err := m.To(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, run, 1)
+	assert.True(t, tableExists(t, db, "payment"))
+	assert.False(t, tableExists(t, db, "payment_status_history"))
+
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	run, err = m.To(ctx, 0)
+	require.NoError(t, err)
+	assert.True(t, len(run) > 1)
+	assert.False(t, tableExists(t, db, "payment"))
+
+	_, err = m.To(ctx, 9999)
+	assert.Equal(t, migration.ErrUnknownVersion, err)
+}
+
+func TestDirtyTracking(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`DELETE FROM schema_migrations WHERE version = 1`)
+	require.NoError(t, err)
+
+	_, err = m.Up(ctx)
+	assert.Equal(t, migration.ErrDirty, err)
+}
+
+func TestBaseline(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	ctx := context.TODO()
+
+	// The schema of the db.sql scripts the migrations replaced.
+	legacy, err := migration.Load("sqlite3")
+	require.NoError(t, err)
+	for _, l := range legacy[:3] {
+		_, err = db.Exec(l.Up)
+		require.NoError(t, err)
+	}
+
+	m, err := migration.NewMigrator(db, "sqlite3")
+	require.NoError(t, err)
+	_, err = m.Up(ctx)
+	assert.Error(t, err, "the existing tables cannot be created again")
+
+	_, err = m.Baseline(ctx, 9999)
+	assert.Equal(t, migration.ErrUnknownVersion, err)
+
+	run, err := m.Baseline(ctx, 3)
+	require.NoError(t, err)
+	assert.Len(t, run, 3)
+
+	run, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, run, len(legacy)-3)
+	assert.True(t, columnExists(t, db, "payment", "deleted_at"))
+
+	_, err = m.Baseline(ctx, 3)
+	assert.Equal(t, migration.ErrAlreadyApplied, err)
+}
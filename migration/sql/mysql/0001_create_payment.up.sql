@@ -0,0 +1,22 @@
+CREATE TABLE `payment` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `organisation` varchar(45) COLLATE utf8_unicode_ci NOT NULL,
+  `payment_id` varchar(45) COLLATE utf8_unicode_ci NOT NULL,
+  `amount` decimal(19,4) NOT NULL,
+  `currency` char(3) COLLATE utf8_unicode_ci NOT NULL,
+  `debtor_name` varchar(140) COLLATE utf8_unicode_ci NOT NULL,
+  `debtor_account_number` varchar(34) COLLATE utf8_unicode_ci NOT NULL,
+  `debtor_account_scheme` varchar(4) COLLATE utf8_unicode_ci NOT NULL,
+  `debtor_bank_id` varchar(11) COLLATE utf8_unicode_ci NOT NULL,
+  `beneficiary_name` varchar(140) COLLATE utf8_unicode_ci NOT NULL,
+  `beneficiary_account_number` varchar(34) COLLATE utf8_unicode_ci NOT NULL,
+  `beneficiary_account_scheme` varchar(4) COLLATE utf8_unicode_ci NOT NULL,
+  `beneficiary_bank_id` varchar(11) COLLATE utf8_unicode_ci NOT NULL,
+  `scheme` varchar(5) COLLATE utf8_unicode_ci NOT NULL,
+  `reference` varchar(140) COLLATE utf8_unicode_ci NOT NULL DEFAULT '',
+  `end_to_end_id` varchar(35) COLLATE utf8_unicode_ci NOT NULL DEFAULT '',
+  `status` varchar(16) COLLATE utf8_unicode_ci NOT NULL DEFAULT 'created',
+  `updated_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
@@ -0,0 +1,11 @@
+CREATE TABLE `payment_status_history` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `payment` int(11) NOT NULL,
+  `from_status` varchar(16) COLLATE utf8_unicode_ci NOT NULL,
+  `to_status` varchar(16) COLLATE utf8_unicode_ci NOT NULL,
+  `event` varchar(16) COLLATE utf8_unicode_ci NOT NULL,
+  `reason` varchar(255) COLLATE utf8_unicode_ci NOT NULL DEFAULT '',
+  `created_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `payment_status_history_payment` (`payment`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
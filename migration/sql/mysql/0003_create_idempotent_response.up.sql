@@ -0,0 +1,10 @@
+CREATE TABLE `idempotent_response` (
+  `idempotency_key` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `request_hash` char(64) COLLATE utf8_unicode_ci NOT NULL,
+  `status_code` int(11) NOT NULL,
+  `body` mediumblob NOT NULL,
+  `created_at` datetime NOT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`idempotency_key`),
+  KEY `idempotent_response_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
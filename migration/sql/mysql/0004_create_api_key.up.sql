@@ -0,0 +1,14 @@
+CREATE TABLE `api_key` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `organisation` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `name` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `prefix` char(16) COLLATE utf8_unicode_ci NOT NULL,
+  `scopes` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `salt` char(32) COLLATE utf8_unicode_ci NOT NULL,
+  `hash` char(64) COLLATE utf8_unicode_ci NOT NULL,
+  `created_at` datetime NOT NULL,
+  `revoked_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `api_key_prefix` (`prefix`),
+  KEY `api_key_organisation` (`organisation`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
@@ -0,0 +1,14 @@
+CREATE TABLE `outbox_event` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `type` varchar(64) COLLATE utf8_unicode_ci NOT NULL,
+  `payment` int(11) NOT NULL,
+  `organisation` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `payload` mediumtext COLLATE utf8_unicode_ci NOT NULL,
+  `created_at` datetime NOT NULL,
+  `attempts` int(11) NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_error` varchar(1024) COLLATE utf8_unicode_ci NOT NULL DEFAULT '',
+  `published_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `outbox_event_pending` (`published_at`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
@@ -0,0 +1,29 @@
+CREATE TABLE `webhook` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `organisation` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `url` varchar(2048) COLLATE utf8_unicode_ci NOT NULL,
+  `event_types` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `secret` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `webhook_organisation` (`organisation`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
+
+CREATE TABLE `webhook_delivery` (
+  `id` int(11) NOT NULL AUTO_INCREMENT,
+  `webhook` int(11) NOT NULL,
+  `event` int(11) NOT NULL,
+  `event_type` varchar(64) COLLATE utf8_unicode_ci NOT NULL,
+  `organisation` varchar(255) COLLATE utf8_unicode_ci NOT NULL,
+  `payload` mediumtext COLLATE utf8_unicode_ci NOT NULL,
+  `status` varchar(16) COLLATE utf8_unicode_ci NOT NULL,
+  `attempts` int(11) NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime NOT NULL,
+  `last_error` varchar(1024) COLLATE utf8_unicode_ci NOT NULL DEFAULT '',
+  `response_status` int(11) NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `webhook_delivery_event` (`webhook`, `event`),
+  KEY `webhook_delivery_due` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8 COLLATE=utf8_unicode_ci;
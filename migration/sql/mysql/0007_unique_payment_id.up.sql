@@ -0,0 +1 @@
+CREATE UNIQUE INDEX `payment_payment_id` ON `payment` (`organisation`, `payment_id`);
@@ -0,0 +1 @@
+ALTER TABLE `payment` ADD COLUMN `version` int(11) NOT NULL DEFAULT 1;
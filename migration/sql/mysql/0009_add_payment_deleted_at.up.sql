@@ -0,0 +1,2 @@
+ALTER TABLE `payment` ADD COLUMN `deleted_at` datetime DEFAULT NULL;
+CREATE INDEX `payment_deleted_at` ON `payment` (`deleted_at`);
@@ -0,0 +1 @@
+ALTER TABLE `idempotent_response` DROP COLUMN `headers`;
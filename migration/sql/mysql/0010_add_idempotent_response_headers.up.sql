@@ -0,0 +1 @@
+ALTER TABLE `idempotent_response` ADD COLUMN `headers` text;
@@ -0,0 +1,10 @@
+CREATE TABLE payment_status_history (
+  id bigserial PRIMARY KEY,
+  payment bigint NOT NULL,
+  from_status varchar(16) NOT NULL,
+  to_status varchar(16) NOT NULL,
+  event varchar(16) NOT NULL,
+  reason varchar(255) NOT NULL DEFAULT '',
+  created_at timestamptz
+);
+CREATE INDEX payment_status_history_payment ON payment_status_history (payment);
@@ -0,0 +1 @@
+DROP TABLE idempotent_response;
@@ -0,0 +1,27 @@
+CREATE TABLE webhook (
+  id bigserial PRIMARY KEY,
+  organisation varchar(255) NOT NULL,
+  url varchar(2048) NOT NULL,
+  event_types varchar(255) NOT NULL,
+  secret varchar(255) NOT NULL,
+  created_at timestamptz NOT NULL
+);
+CREATE INDEX webhook_organisation ON webhook (organisation);
+
+CREATE TABLE webhook_delivery (
+  id bigserial PRIMARY KEY,
+  webhook bigint NOT NULL,
+  event bigint NOT NULL,
+  event_type varchar(64) NOT NULL,
+  organisation varchar(255) NOT NULL,
+  payload text NOT NULL,
+  status varchar(16) NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL,
+  last_error varchar(1024) NOT NULL DEFAULT '',
+  response_status integer NOT NULL DEFAULT 0,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz NOT NULL
+);
+CREATE UNIQUE INDEX webhook_delivery_event ON webhook_delivery (webhook, event);
+CREATE INDEX webhook_delivery_due ON webhook_delivery (status, next_attempt_at);
@@ -0,0 +1 @@
+DROP INDEX payment_payment_id;
@@ -0,0 +1 @@
+ALTER TABLE payment ADD COLUMN version bigint NOT NULL DEFAULT 1;
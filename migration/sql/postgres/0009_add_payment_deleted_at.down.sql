@@ -0,0 +1,2 @@
+DROP INDEX payment_deleted_at;
+ALTER TABLE payment DROP COLUMN deleted_at;
@@ -0,0 +1,2 @@
+ALTER TABLE payment ADD COLUMN deleted_at timestamptz;
+CREATE INDEX payment_deleted_at ON payment (deleted_at);
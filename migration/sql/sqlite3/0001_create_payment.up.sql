@@ -0,0 +1,22 @@
+-- Amounts are stored as text so they keep their exact decimal value.
+CREATE TABLE payment (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  organisation varchar(45) NOT NULL,
+  payment_id varchar(45) NOT NULL,
+  amount text NOT NULL,
+  currency char(3) NOT NULL,
+  debtor_name varchar(140) NOT NULL,
+  debtor_account_number varchar(34) NOT NULL,
+  debtor_account_scheme varchar(4) NOT NULL,
+  debtor_bank_id varchar(11) NOT NULL,
+  beneficiary_name varchar(140) NOT NULL,
+  beneficiary_account_number varchar(34) NOT NULL,
+  beneficiary_account_scheme varchar(4) NOT NULL,
+  beneficiary_bank_id varchar(11) NOT NULL,
+  scheme varchar(5) NOT NULL,
+  reference varchar(140) NOT NULL DEFAULT '',
+  end_to_end_id varchar(35) NOT NULL DEFAULT '',
+  status varchar(16) NOT NULL DEFAULT 'created',
+  updated_at datetime,
+  created_at datetime
+);
@@ -0,0 +1,10 @@
+CREATE TABLE payment_status_history (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  payment integer NOT NULL,
+  from_status varchar(16) NOT NULL,
+  to_status varchar(16) NOT NULL,
+  event varchar(16) NOT NULL,
+  reason varchar(255) NOT NULL DEFAULT '',
+  created_at datetime
+);
+CREATE INDEX payment_status_history_payment ON payment_status_history (payment);
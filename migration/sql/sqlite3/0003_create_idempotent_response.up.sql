@@ -0,0 +1,9 @@
+CREATE TABLE idempotent_response (
+  idempotency_key varchar(255) PRIMARY KEY,
+  request_hash char(64) NOT NULL,
+  status_code integer NOT NULL,
+  body blob NOT NULL,
+  created_at datetime NOT NULL,
+  expires_at datetime NOT NULL
+);
+CREATE INDEX idempotent_response_expires_at ON idempotent_response (expires_at);
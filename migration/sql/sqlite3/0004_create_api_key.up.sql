@@ -0,0 +1,13 @@
+CREATE TABLE api_key (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  organisation varchar(255) NOT NULL,
+  name varchar(255) NOT NULL,
+  prefix char(16) NOT NULL,
+  scopes varchar(255) NOT NULL,
+  salt char(32) NOT NULL,
+  hash char(64) NOT NULL,
+  created_at datetime NOT NULL,
+  revoked_at datetime
+);
+CREATE UNIQUE INDEX api_key_prefix ON api_key (prefix);
+CREATE INDEX api_key_organisation ON api_key (organisation);
@@ -0,0 +1,13 @@
+CREATE TABLE outbox_event (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  type varchar(64) NOT NULL,
+  payment integer NOT NULL,
+  organisation varchar(255) NOT NULL,
+  payload text NOT NULL,
+  created_at datetime NOT NULL,
+  attempts integer NOT NULL DEFAULT 0,
+  next_attempt_at datetime NOT NULL,
+  last_error varchar(1024) NOT NULL DEFAULT '',
+  published_at datetime
+);
+CREATE INDEX outbox_event_pending ON outbox_event (published_at, next_attempt_at);
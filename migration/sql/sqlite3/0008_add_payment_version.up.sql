@@ -0,0 +1 @@
+ALTER TABLE payment ADD COLUMN version integer NOT NULL DEFAULT 1;
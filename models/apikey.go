@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// Scopes API keys can be granted.
+const (
+	ScopePaymentsRead  = "payments:read"
+	ScopePaymentsWrite = "payments:write"
+	ScopeAPIKeysRead   = "apikeys:read"
+	ScopeAPIKeysWrite  = "apikeys:write"
+	ScopeWebhooksRead  = "webhooks:read"
+	ScopeWebhooksWrite = "webhooks:write"
+)
+
+// APIKey struct representation of an API key authenticating the calls of an
+// organisation. Only a salted hash of its secret is stored.
+type APIKey struct {
+	ID           int64      `json:"id"`
+	Organisation string     `json:"organisation_id" validate:"required,max=255"`
+	Name         string     `json:"name" validate:"required,max=255"`
+	Prefix       string     `json:"prefix"`
+	Scopes       []string   `json:"scopes" validate:"required,dive,oneof=payments:read payments:write apikeys:read apikeys:write webhooks:read webhooks:write"`
+	Salt         string     `json:"-"`
+	Hash         string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Validate checks the API key attributes against their validation tags.
+func (k *APIKey) Validate() error {
+	return validator.New().Struct(k)
+}
+
+// Revoked reports whether the API key was revoked.
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
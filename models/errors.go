@@ -11,4 +11,31 @@ var (
 
 	// ErrConflict Conflict error
 	ErrConflict = errors.New("Your Item already exist")
+
+	// ErrStaleVersion Item modified since the version being updated error
+	ErrStaleVersion = errors.New("Your Item was modified since you read it")
+
+	// ErrInvalidTransition Invalid payment status transition error
+	ErrInvalidTransition = errors.New("Your Item status does not allow this action")
+
+	// ErrIdempotencyKeyReused Idempotency key reused with a different request error
+	ErrIdempotencyKeyReused = errors.New("Your Idempotency-Key was already used for a different request")
+
+	// ErrIdempotentRequestInProgress Request with the same idempotency key still being handled error
+	ErrIdempotentRequestInProgress = errors.New("Your request with this Idempotency-Key is still being processed")
+
+	// ErrUnknownEvent Unknown payment status event error
+	ErrUnknownEvent = errors.New("Your requested action is not known")
+
+	// ErrInvalidAPIKey Unknown, revoked or malformed API key error
+	ErrInvalidAPIKey = errors.New("Your API key is not valid")
+
+	// ErrScopeNotGranted Scope not granted to the caller error
+	ErrScopeNotGranted = errors.New("Your requested scope is not granted to you")
+
+	// ErrWebhookURLNotAllowed Webhook URL not https or not reaching a public address error
+	ErrWebhookURLNotAllowed = errors.New("Your webhook URL must use https and reach a public address")
+
+	// ErrInvalidSignature Missing, malformed, expired or not matching webhook signature error
+	ErrInvalidSignature = errors.New("Your webhook signature is not valid")
 )
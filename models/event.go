@@ -0,0 +1,66 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EventType type of a domain event.
+type EventType string
+
+// Payment domain events.
+const (
+	PaymentCreated       EventType = "PaymentCreated"
+	PaymentUpdated       EventType = "PaymentUpdated"
+	PaymentStatusChanged EventType = "PaymentStatusChanged"
+	PaymentDeleted       EventType = "PaymentDeleted"
+	PaymentRestored      EventType = "PaymentRestored"
+)
+
+// Event domain event, recorded on the outbox along with the change causing it
+// and published to downstream systems afterwards, at least once.
+type Event struct {
+	ID           int64           `json:"id"`
+	Type         EventType       `json:"type"`
+	Payment      int64           `json:"payment"`
+	Organisation string          `json:"organisation_id"`
+	Payload      json.RawMessage `json:"payload"`
+	CreatedAt    time.Time       `json:"created_at"`
+	// Attempts publications attempted so far, the next one being due at
+	// NextAttemptAt.
+	Attempts      int        `json:"-"`
+	NextAttemptAt time.Time  `json:"-"`
+	LastError     string     `json:"-"`
+	PublishedAt   *time.Time `json:"-"`
+}
+
+// PaymentEvent payload of the payment events, holding the payment as left by
+// the change along with the status change, if any.
+type PaymentEvent struct {
+	Payment      *Payment      `json:"payment"`
+	StatusChange *StatusChange `json:"status_change,omitempty"`
+}
+
+// NewPaymentEvent builds an event of the given type about p, due to be
+// published right away.
+func NewPaymentEvent(t EventType, p *Payment, change *StatusChange) (*Event, error) {
+	payload, err := json.Marshal(PaymentEvent{Payment: p, StatusChange: change})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &Event{
+		Type:          t,
+		Payment:       p.ID,
+		Organisation:  p.Organisation,
+		Payload:       payload,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}, nil
+}
+
+// Published reports whether the event was already published.
+func (e *Event) Published() bool {
+	return e.PublishedAt != nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// IdempotentResponse struct representation of a response stored to be
+// replayed to requests retried with the same idempotency key. Its StatusCode
+// is zero while the first request is still being handled. Headers holds the
+// response headers replayed along with its body.
+type IdempotentResponse struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Pending tells whether the request the response belongs to is still being
+// handled.
+func (r *IdempotentResponse) Pending() bool {
+	return r.StatusCode == 0
+}
@@ -0,0 +1,26 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidAmount(t *testing.T) {
+	for _, amount := range []string{"1", "100.21", "0.01", "123456789012345.1234"} {
+		assert.True(t, models.IsValidAmount(amount), amount)
+	}
+
+	for _, amount := range []string{"", "0", "0.00", "-1", "1.", ".5", "01", "1e5", "1.23456", "1,00"} {
+		assert.False(t, models.IsValidAmount(amount), amount)
+	}
+}
+
+func TestIsValidCurrency(t *testing.T) {
+	assert.True(t, models.IsValidCurrency("GBP"))
+	assert.True(t, models.IsValidCurrency("EUR"))
+	assert.False(t, models.IsValidCurrency("gbp"))
+	assert.False(t, models.IsValidCurrency("XXX"))
+	assert.False(t, models.IsValidCurrency(""))
+}
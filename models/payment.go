@@ -4,11 +4,38 @@ import (
 	"time"
 )
 
-// Payment struct representation of a payment resource.
+// InitialVersion version of newly stored payments.
+const InitialVersion int64 = 1
+
+// Payment struct representation of a payment resource. Its version is bumped
+// by every change, updates of an older version failing with ErrStaleVersion.
 type Payment struct {
-	ID           int64     `json:"id"`
-	PaymentID    string    `json:"payment_id" validate:"required"`
-	Organisation string    `json:"organisation_id" validate:"required"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int64         `json:"id"`
+	PaymentID    string        `json:"payment_id" validate:"required"`
+	Organisation string        `json:"organisation_id" validate:"required"`
+	Amount       string        `json:"amount" validate:"required,amount"`
+	Currency     string        `json:"currency" validate:"required,currency"`
+	Debtor       Party         `json:"debtor_party" validate:"required"`
+	Beneficiary  Party         `json:"beneficiary_party" validate:"required"`
+	Scheme       string        `json:"payment_scheme" validate:"required,oneof=FPS BACS CHAPS SEPA"`
+	Reference    string        `json:"reference" validate:"max=140"`
+	EndToEndID   string        `json:"end_to_end_id" validate:"max=35"`
+	Status       PaymentStatus `json:"status"`
+	Version      int64         `json:"version"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+	CreatedAt    time.Time     `json:"created_at"`
+	DeletedAt    *time.Time    `json:"deleted_at,omitempty"`
+}
+
+// Deleted reports whether the payment was deleted, and can still be restored.
+func (p *Payment) Deleted() bool {
+	return p.DeletedAt != nil
+}
+
+// Party struct representation of the debtor or beneficiary of a payment.
+type Party struct {
+	Name          string `json:"name" validate:"required,max=140"`
+	AccountNumber string `json:"account_number" validate:"required,max=34"`
+	AccountScheme string `json:"account_scheme" validate:"required,oneof=IBAN BBAN"`
+	BankID        string `json:"bank_id" validate:"required,max=11"`
 }
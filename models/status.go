@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+// PaymentStatus payment lifecycle status.
+type PaymentStatus string
+
+// Payment lifecycle statuses.
+const (
+	StatusCreated   PaymentStatus = "created"
+	StatusPending   PaymentStatus = "pending"
+	StatusSubmitted PaymentStatus = "submitted"
+	StatusAccepted  PaymentStatus = "accepted"
+	StatusRejected  PaymentStatus = "rejected"
+	StatusSettled   PaymentStatus = "settled"
+	StatusCancelled PaymentStatus = "cancelled"
+	StatusReturned  PaymentStatus = "returned"
+)
+
+// StatusEvent event moving a payment from one status to another.
+type StatusEvent string
+
+// Payment lifecycle events.
+const (
+	EventApprove StatusEvent = "approve"
+	EventSubmit  StatusEvent = "submit"
+	EventAccept  StatusEvent = "accept"
+	EventReject  StatusEvent = "reject"
+	EventSettle  StatusEvent = "settle"
+	EventCancel  StatusEvent = "cancel"
+	EventReturn  StatusEvent = "return"
+)
+
+// transitions allowed status transitions indexed by source status and event.
+var transitions = map[PaymentStatus]map[StatusEvent]PaymentStatus{
+	StatusCreated: {
+		EventApprove: StatusPending,
+		EventCancel:  StatusCancelled,
+	},
+	StatusPending: {
+		EventSubmit: StatusSubmitted,
+		EventCancel: StatusCancelled,
+	},
+	StatusSubmitted: {
+		EventAccept: StatusAccepted,
+		EventReject: StatusRejected,
+	},
+	StatusAccepted: {
+		EventSettle: StatusSettled,
+	},
+	StatusSettled: {
+		EventReturn: StatusReturned,
+	},
+}
+
+// IsValid checks the event is a known payment lifecycle event.
+func (e StatusEvent) IsValid() bool {
+	switch e {
+	case EventApprove, EventSubmit, EventAccept, EventReject, EventSettle, EventCancel, EventReturn:
+		return true
+	}
+
+	return false
+}
+
+// Next calculates the status a payment reaches when the given event is
+// applied to it.
+func (s PaymentStatus) Next(e StatusEvent) (PaymentStatus, error) {
+	if !e.IsValid() {
+		return s, ErrUnknownEvent
+	}
+
+	next, ok := transitions[s][e]
+	if !ok {
+		return s, ErrInvalidTransition
+	}
+
+	return next, nil
+}
+
+// StatusChange struct representation of a payment status history entry.
+type StatusChange struct {
+	ID        int64         `json:"id"`
+	Payment   int64         `json:"payment"`
+	From      PaymentStatus `json:"from"`
+	To        PaymentStatus `json:"to"`
+	Event     StatusEvent   `json:"event"`
+	Reason    string        `json:"reason"`
+	CreatedAt time.Time     `json:"created_at"`
+}
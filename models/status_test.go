@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusNext(t *testing.T) {
+	status := models.StatusCreated
+	for _, step := range []struct {
+		event models.StatusEvent
+		want  models.PaymentStatus
+	}{
+		{models.EventApprove, models.StatusPending},
+		{models.EventSubmit, models.StatusSubmitted},
+		{models.EventAccept, models.StatusAccepted},
+		{models.EventSettle, models.StatusSettled},
+		{models.EventReturn, models.StatusReturned},
+	} {
+		next, err := status.Next(step.event)
+		assert.NoError(t, err)
+		assert.Equal(t, step.want, next)
+		status = next
+	}
+}
+
+func TestStatusNextNotAllowed(t *testing.T) {
+	_, err := models.StatusSubmitted.Next(models.EventCancel)
+	assert.Equal(t, models.ErrInvalidTransition, err)
+
+	_, err = models.StatusCancelled.Next(models.EventApprove)
+	assert.Equal(t, models.ErrInvalidTransition, err)
+
+	_, err = models.StatusCreated.Next(models.StatusEvent("teleport"))
+	assert.Equal(t, models.ErrUnknownEvent, err)
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// Validate checks the payment attributes against their validation tags.
+func (p *Payment) Validate() error {
+	validate := validator.New()
+	_ = validate.RegisterValidation("amount", func(fl validator.FieldLevel) bool {
+		return IsValidAmount(fl.Field().String())
+	})
+	_ = validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return IsValidCurrency(fl.Field().String())
+	})
+
+	return validate.Struct(p)
+}
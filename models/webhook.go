@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	validator "gopkg.in/go-playground/validator.v9"
+)
+
+// DeliveryStatus status of the delivery of an event to a webhook.
+type DeliveryStatus string
+
+// Statuses of webhook deliveries. Dead deliveries exhausted their attempts
+// and are only retried when redelivered.
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryDead      DeliveryStatus = "dead"
+)
+
+// Webhook struct representation of the subscription of an organisation to
+// the events of its payments, POSTed to URL and signed with Secret.
+type Webhook struct {
+	ID           int64     `json:"id"`
+	Organisation string    `json:"organisation_id" validate:"required,max=255"`
+	URL          string    `json:"url" validate:"required,url,max=2048"`
+	EventTypes   []string  `json:"event_types" validate:"required,dive,oneof=PaymentCreated PaymentUpdated PaymentStatusChanged PaymentDeleted"`
+	Secret       string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Validate checks the webhook attributes against their validation tags.
+func (w *Webhook) Validate() error {
+	return validator.New().Struct(w)
+}
+
+// Subscribes reports whether the webhook subscribes to events of type t.
+func (w *Webhook) Subscribes(t EventType) bool {
+	for _, eventType := range w.EventTypes {
+		if EventType(eventType) == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookDelivery struct representation of the delivery of an event to a
+// webhook, along with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	Webhook        int64           `json:"webhook"`
+	Event          int64           `json:"event"`
+	EventType      EventType       `json:"event_type"`
+	Organisation   string          `json:"organisation_id"`
+	Payload        json.RawMessage `json:"-"`
+	Status         DeliveryStatus  `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastError      string          `json:"last_error,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
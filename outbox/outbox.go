@@ -0,0 +1,24 @@
+// Package outbox relays the domain events recorded on the outbox, in the same
+// transaction as the changes causing them, to downstream systems.
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Repository repository interface to interact with the outbox events
+type Repository interface {
+	Append(ctx context.Context, events ...*models.Event) error
+	FetchPending(ctx context.Context, now time.Time, num int64) ([]*models.Event, error)
+	MarkPublished(ctx context.Context, id int64, at time.Time) error
+	MarkFailed(ctx context.Context, id int64, lastError string, next time.Time) error
+}
+
+// Publisher publishes events to downstream systems, such as a message broker.
+// Events may be published more than once, consumers telling them apart by id.
+type Publisher interface {
+	Publish(ctx context.Context, e *models.Event) error
+}
@@ -0,0 +1,33 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+)
+
+type logPublisher struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogPublisher publisher writing every event to the given logger, for
+// log shippers to forward downstream.
+func NewLogPublisher(logger logrus.FieldLogger) outbox.Publisher {
+	return &logPublisher{logger}
+}
+
+// Publish logs the given event along with its payload.
+func (p *logPublisher) Publish(ctx context.Context, e *models.Event) error {
+	p.logger.WithFields(logrus.Fields{
+		"event":        e.ID,
+		"type":         e.Type,
+		"payment":      e.Payment,
+		"organisation": e.Organisation,
+		"payload":      string(e.Payload),
+	}).Info("event published")
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package publisher
+
+import (
+	"context"
+	"sync"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// MemoryPublisher keeps the published events in memory, meant for tests and
+// local development.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*models.Event
+}
+
+// NewMemoryPublisher in-memory publisher constructor.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish keeps the given event.
+func (p *MemoryPublisher) Publish(ctx context.Context, e *models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, e)
+	return nil
+}
+
+// Events lists the events published so far, in publication order.
+func (p *MemoryPublisher) Events() []*models.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*models.Event(nil), p.events...)
+}
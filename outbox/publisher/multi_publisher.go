@@ -0,0 +1,30 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+)
+
+type multiPublisher struct {
+	publishers []outbox.Publisher
+}
+
+// NewMultiPublisher publisher handing every event to each of the given ones
+// in turn, failing on the first error. As failed events are published again,
+// the publishers should tolerate duplicates.
+func NewMultiPublisher(publishers ...outbox.Publisher) outbox.Publisher {
+	return &multiPublisher{publishers}
+}
+
+// Publish publishes the given event with every publisher.
+func (p *multiPublisher) Publish(ctx context.Context, e *models.Event) error {
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
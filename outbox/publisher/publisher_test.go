@@ -0,0 +1,49 @@
+package publisher_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox/publisher"
+)
+
+func TestLogPublisher(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	p := publisher.NewLogPublisher(logger)
+
+	e := &models.Event{ID: 3, Type: models.PaymentDeleted, Payment: 12, Organisation: "org-a", Payload: json.RawMessage(`{"payment":{"id":12}}`)}
+	assert.NoError(t, p.Publish(context.TODO(), e))
+
+	if entry := hook.LastEntry(); assert.NotNil(t, entry) {
+		assert.Equal(t, logrus.InfoLevel, entry.Level)
+		assert.Equal(t, models.PaymentDeleted, entry.Data["type"])
+		assert.Equal(t, `{"payment":{"id":12}}`, entry.Data["payload"])
+	}
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	p := publisher.NewMemoryPublisher()
+
+	assert.NoError(t, p.Publish(context.TODO(), &models.Event{ID: 1}))
+	assert.NoError(t, p.Publish(context.TODO(), &models.Event{ID: 2}))
+
+	if events := p.Events(); assert.Len(t, events, 2) {
+		assert.Equal(t, int64(2), events[1].ID)
+	}
+}
+
+func TestMultiPublisher(t *testing.T) {
+	first, second := publisher.NewMemoryPublisher(), publisher.NewMemoryPublisher()
+	p := publisher.NewMultiPublisher(first, second)
+
+	assert.NoError(t, p.Publish(context.TODO(), &models.Event{ID: 1}))
+
+	assert.Len(t, first.Events(), 1)
+	assert.Len(t, second.Events(), 1)
+}
@@ -0,0 +1,146 @@
+// Package relay publishes the events recorded on the outbox, at least once.
+package relay
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/outbox"
+)
+
+// MaxBackoff longest delay between the publication attempts of an event.
+const MaxBackoff = 5 * time.Minute
+
+// maxErrorLength longest publication error recorded on an event.
+const maxErrorLength = 1024
+
+// Relay polls the outbox for pending events and publishes them oldest first,
+// retrying the failed ones with an exponential backoff until published. An
+// event published but not yet marked as such when the relay stops is
+// published again, so consumers should discard the events ids already seen.
+type Relay struct {
+	repo      outbox.Repository
+	publisher outbox.Publisher
+	interval  time.Duration
+	batchSize int64
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRelay relay constructor, polling the outbox every interval for up to
+// batchSize events.
+func NewRelay(repo outbox.Repository, publisher outbox.Publisher, interval time.Duration, batchSize int64) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		interval:  interval,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Start relays the pending events in the background until Shutdown.
+func (r *Relay) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		r.Run(ctx)
+	}()
+}
+
+// Shutdown stops the relay started by Start, waiting for the batch being
+// published until ctx is done.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	if r.cancel == nil {
+		return nil
+	}
+
+	r.cancel()
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run relays the pending events every interval until ctx is done.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayPending(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).WithError(err).Error("relaying outbox events")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending publishes a batch of the events due, returning how many were
+// published. Failed events are rescheduled rather than reported as an error.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	events, err := r.repo.FetchPending(ctx, r.now(), r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, e := range events {
+		if err = ctx.Err(); err != nil {
+			return published, err
+		}
+
+		if perr := r.publisher.Publish(ctx, e); perr != nil {
+			next := r.now().Add(r.backoff(e.Attempts))
+			logging.FromContext(ctx).WithFields(logrus.Fields{
+				"event":    e.ID,
+				"type":     e.Type,
+				"attempts": e.Attempts + 1,
+				"retry_at": next,
+			}).WithError(perr).Warn("event publication failed")
+
+			msg := perr.Error()
+			if len(msg) > maxErrorLength {
+				msg = msg[:maxErrorLength]
+			}
+			if err = r.repo.MarkFailed(ctx, e.ID, msg, next); err != nil {
+				return published, err
+			}
+			continue
+		}
+
+		if err = r.repo.MarkPublished(ctx, e.ID, r.now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+// backoff delay before the next publication attempt of an event which failed
+// the given number of times before, doubling on every failure.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.interval
+	for i := 0; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+
+	return d
+}
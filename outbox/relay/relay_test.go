@@ -0,0 +1,109 @@
+package relay_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox/mocks"
+	"github.com/adriacidre/go-clean-arch/outbox/publisher"
+	"github.com/adriacidre/go-clean-arch/outbox/relay"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+)
+
+func TestRelayPending(t *testing.T) {
+	repo := outboxRepo.NewMemoryOutbox()
+	pub := publisher.NewMemoryPublisher()
+	now := time.Now()
+	err := repo.Append(context.TODO(),
+		&models.Event{Type: models.PaymentCreated, Payment: 1, NextAttemptAt: now},
+		&models.Event{Type: models.PaymentStatusChanged, Payment: 1, NextAttemptAt: now},
+	)
+	assert.NoError(t, err)
+
+	r := relay.NewRelay(repo, pub, time.Second, 10)
+
+	published, err := r.RelayPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, published)
+	if events := pub.Events(); assert.Len(t, events, 2) {
+		assert.Equal(t, models.PaymentCreated, events[0].Type)
+		assert.Equal(t, models.PaymentStatusChanged, events[1].Type)
+	}
+
+	published, err = r.RelayPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+}
+
+func TestRelayPendingRetriesWithBackoff(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	mockPublisher := new(mocks.Publisher)
+	failing := &models.Event{ID: 1, Type: models.PaymentCreated, Attempts: 2}
+	next := &models.Event{ID: 2, Type: models.PaymentUpdated}
+
+	start := time.Now()
+	mockRepo.On("FetchPending", mock.Anything, mock.AnythingOfType("time.Time"), int64(10)).Return([]*models.Event{failing, next}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, failing).Return(errors.New(strings.Repeat("x", 2000))).Once()
+	mockPublisher.On("Publish", mock.Anything, next).Return(nil).Once()
+	mockRepo.On("MarkFailed", mock.Anything, int64(1), strings.Repeat("x", 1024), mock.MatchedBy(func(at time.Time) bool {
+		// Third attempt failed: the interval doubled twice.
+		return !at.Before(start.Add(4*time.Second)) && at.Before(time.Now().Add(4*time.Second))
+	})).Return(nil).Once()
+	mockRepo.On("MarkPublished", mock.Anything, int64(2), mock.AnythingOfType("time.Time")).Return(nil).Once()
+
+	r := relay.NewRelay(mockRepo, mockPublisher, time.Second, 10)
+
+	published, err := r.RelayPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestRelayPendingBackoffIsCapped(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	mockPublisher := new(mocks.Publisher)
+	e := &models.Event{ID: 1, Type: models.PaymentCreated, Attempts: 50}
+
+	mockRepo.On("FetchPending", mock.Anything, mock.AnythingOfType("time.Time"), int64(10)).Return([]*models.Event{e}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, e).Return(errors.New("broker down")).Once()
+	mockRepo.On("MarkFailed", mock.Anything, int64(1), "broker down", mock.MatchedBy(func(at time.Time) bool {
+		return !at.After(time.Now().Add(relay.MaxBackoff))
+	})).Return(nil).Once()
+
+	r := relay.NewRelay(mockRepo, mockPublisher, time.Second, 10)
+
+	_, err := r.RelayPending(context.TODO())
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRelayPendingRepositoryError(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	mockRepo.On("FetchPending", mock.Anything, mock.AnythingOfType("time.Time"), int64(10)).Return(nil, errors.New("database down")).Once()
+
+	r := relay.NewRelay(mockRepo, new(mocks.Publisher), time.Second, 10)
+
+	_, err := r.RelayPending(context.TODO())
+	assert.EqualError(t, err, "database down")
+}
+
+func TestRelayStartShutdown(t *testing.T) {
+	repo := outboxRepo.NewMemoryOutbox()
+	pub := publisher.NewMemoryPublisher()
+	r := relay.NewRelay(repo, pub, 10*time.Millisecond, 10)
+
+	r.Start()
+	err := repo.Append(context.TODO(), &models.Event{Type: models.PaymentCreated, NextAttemptAt: time.Now()})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return len(pub.Events()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, r.Shutdown(context.TODO()))
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+)
+
+type memoryOutbox struct {
+	mu     sync.Mutex
+	lastID int64
+	events []models.Event
+}
+
+// NewMemoryOutbox in-memory outbox constructor, meant for local development
+// and tests as nothing is persisted across restarts.
+func NewMemoryOutbox() outbox.Repository {
+	return &memoryOutbox{}
+}
+
+// Append records the given events on the outbox.
+func (m *memoryOutbox) Append(ctx context.Context, events ...*models.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range events {
+		m.lastID++
+		e.ID = m.lastID
+		m.events = append(m.events, *e)
+	}
+
+	return nil
+}
+
+// FetchPending lists up to num unpublished events due by now, oldest first.
+func (m *memoryOutbox) FetchPending(ctx context.Context, now time.Time, num int64) ([]*models.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*models.Event, 0)
+	for _, e := range m.events {
+		if int64(len(result)) == num {
+			break
+		}
+		if !e.Published() && !e.NextAttemptAt.After(now) {
+			e := e
+			result = append(result, &e)
+		}
+	}
+
+	return result, nil
+}
+
+// MarkPublished records the event as published at the given time.
+func (m *memoryOutbox) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.find(id); e != nil {
+		e.Attempts++
+		e.PublishedAt = &at
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed publication of the event, to be retried at next.
+func (m *memoryOutbox) MarkFailed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e := m.find(id); e != nil {
+		e.Attempts++
+		e.LastError = lastError
+		e.NextAttemptAt = next
+	}
+
+	return nil
+}
+
+// find returns the stored event with the given id, if any.
+func (m *memoryOutbox) find(id int64) *models.Event {
+	for i := range m.events {
+		if m.events[i].ID == id {
+			return &m.events[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+)
+
+func TestMemoryOutbox(t *testing.T) {
+	a := outboxRepo.NewMemoryOutbox()
+	ctx := context.TODO()
+	now := time.Now()
+
+	events := []*models.Event{
+		{Type: models.PaymentCreated, NextAttemptAt: now},
+		{Type: models.PaymentUpdated, NextAttemptAt: now},
+		{Type: models.PaymentDeleted, NextAttemptAt: now},
+	}
+	assert.NoError(t, a.Append(ctx, events...))
+	assert.Equal(t, int64(3), events[2].ID)
+
+	assert.NoError(t, a.MarkPublished(ctx, 1, now))
+	assert.NoError(t, a.MarkFailed(ctx, 2, "broker down", now.Add(time.Hour)))
+
+	list, err := a.FetchPending(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, int64(3), list[0].ID)
+	}
+
+	list, err = a.FetchPending(ctx, now.Add(time.Hour), 1)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, int64(2), list[0].ID)
+		assert.Equal(t, 1, list[0].Attempts)
+		assert.Equal(t, "broker down", list[0].LastError)
+	}
+}
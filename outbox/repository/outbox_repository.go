@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+// eventColumns columns selected when fetching events, in scan order.
+const eventColumns = `id,type,payment,organisation,payload,created_at,attempts,next_attempt_at,last_error`
+
+type mysqlOutbox struct {
+	Conn *sql.DB
+}
+
+// NewMysqlOutbox mysql outbox constructor.
+func NewMysqlOutbox(Conn *sql.DB) outbox.Repository {
+	return &mysqlOutbox{Conn}
+}
+
+// Append records the given events on the outbox.
+func (m *mysqlOutbox) Append(ctx context.Context, events ...*models.Event) error {
+	query := `INSERT outbox_event SET type=? , payment=? , organisation=? , payload=? , created_at=? , next_attempt_at=?`
+
+	for _, e := range events {
+		tracing.Statement(ctx, query)
+		res, err := m.Conn.ExecContext(ctx, query, e.Type, e.Payment, e.Organisation, string(e.Payload), e.CreatedAt, e.NextAttemptAt)
+		if err != nil {
+			return err
+		}
+		if e.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchPending lists up to num unpublished events due by now, oldest first.
+func (m *mysqlOutbox) FetchPending(ctx context.Context, now time.Time, num int64) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + `
+  						FROM outbox_event WHERE published_at IS NULL AND next_attempt_at <= ? ORDER BY id LIMIT ?`
+
+	return fetchEvents(ctx, m.Conn, query, now, num)
+}
+
+// MarkPublished records the event as published at the given time.
+func (m *mysqlOutbox) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE outbox_event SET published_at=? , attempts=attempts+1 WHERE id = ?`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// MarkFailed records a failed publication of the event, to be retried at next.
+func (m *mysqlOutbox) MarkFailed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	query := `UPDATE outbox_event SET attempts=attempts+1 , last_error=? , next_attempt_at=? WHERE id = ?`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, lastError, next, id)
+	return err
+}
+
+// fetchEvents runs the given events query, scanning eventColumns rows.
+func fetchEvents(ctx context.Context, conn *sql.DB, query string, args ...interface{}) ([]*models.Event, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.Event, 0)
+	for rows.Next() {
+		e := new(models.Event)
+		var payload string
+		err = rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.Payment,
+			&e.Organisation,
+			&payload,
+			&e.CreatedAt,
+			&e.Attempts,
+			&e.NextAttemptAt,
+			&e.LastError,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil, err
+		}
+		e.Payload = json.RawMessage(payload)
+		result = append(result, e)
+	}
+
+	return result, rows.Err()
+}
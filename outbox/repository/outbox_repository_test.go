@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+)
+
+var columns = []string{"id", "type", "payment", "organisation", "payload", "created_at", "attempts", "next_attempt_at", "last_error"}
+
+// eventRow builds an event row matching columns.
+func eventRow(id int64, t models.EventType, attempts int) []driver.Value {
+	return []driver.Value{id, string(t), 12, "org-a", `{"payment":{"id":12}}`, time.Now(), attempts, time.Now(), ""}
+}
+
+func TestAppend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := &models.Event{Type: models.PaymentCreated, Payment: 12, Organisation: "org-a", Payload: json.RawMessage(`{}`)}
+	mock.ExpectExec("INSERT outbox_event SET type=\\? , payment=\\? , (.+)").
+		WithArgs(string(models.PaymentCreated), int64(12), "org-a", "{}", e.CreatedAt, e.NextAttemptAt).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	a := outboxRepo.NewMysqlOutbox(db)
+
+	err = a.Append(context.TODO(), e)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), e.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFetchPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(columns).
+		AddRow(eventRow(1, models.PaymentCreated, 0)...).
+		AddRow(eventRow(2, models.PaymentStatusChanged, 3)...)
+	mock.ExpectQuery("SELECT (.+) FROM outbox_event WHERE published_at IS NULL AND next_attempt_at <= \\? ORDER BY id LIMIT \\?").
+		WithArgs(now, int64(10)).WillReturnRows(rows)
+
+	a := outboxRepo.NewMysqlOutbox(db)
+
+	list, err := a.FetchPending(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, models.PaymentStatusChanged, list[1].Type)
+		assert.Equal(t, 3, list[1].Attempts)
+		assert.JSONEq(t, `{"payment":{"id":12}}`, string(list[1].Payload))
+	}
+}
+
+func TestMarkPublishedAndFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE outbox_event SET published_at=\\? , attempts=attempts\\+1 WHERE id = \\?").
+		WithArgs(now, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE outbox_event SET attempts=attempts\\+1 , last_error=\\? , next_attempt_at=\\? WHERE id = \\?").
+		WithArgs("broker down", now, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := outboxRepo.NewMysqlOutbox(db)
+
+	assert.NoError(t, a.MarkPublished(context.TODO(), 1, now))
+	assert.NoError(t, a.MarkFailed(context.TODO(), 2, "broker down", now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type pgOutbox struct {
+	Conn *sql.DB
+}
+
+// NewPgOutbox postgres outbox constructor.
+func NewPgOutbox(Conn *sql.DB) outbox.Repository {
+	return &pgOutbox{Conn}
+}
+
+// Append records the given events on the outbox.
+func (m *pgOutbox) Append(ctx context.Context, events ...*models.Event) error {
+	query := `INSERT INTO outbox_event (type, payment, organisation, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	for _, e := range events {
+		tracing.Statement(ctx, query)
+		err := m.Conn.QueryRowContext(ctx, query, e.Type, e.Payment, e.Organisation, string(e.Payload), e.CreatedAt, e.NextAttemptAt).Scan(&e.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchPending lists up to num unpublished events due by now, oldest first.
+func (m *pgOutbox) FetchPending(ctx context.Context, now time.Time, num int64) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + `
+  						FROM outbox_event WHERE published_at IS NULL AND next_attempt_at <= $1 ORDER BY id LIMIT $2`
+
+	return fetchEvents(ctx, m.Conn, query, now, num)
+}
+
+// MarkPublished records the event as published at the given time.
+func (m *pgOutbox) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE outbox_event SET published_at=$1, attempts=attempts+1 WHERE id = $2`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// MarkFailed records a failed publication of the event, to be retried at next.
+func (m *pgOutbox) MarkFailed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	query := `UPDATE outbox_event SET attempts=attempts+1, last_error=$1, next_attempt_at=$2 WHERE id = $3`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, lastError, next, id)
+	return err
+}
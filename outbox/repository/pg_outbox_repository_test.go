@@ -0,0 +1,69 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+)
+
+func TestPgAppend(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	e := &models.Event{Type: models.PaymentDeleted, Payment: 12, Organisation: "org-a", Payload: json.RawMessage(`{}`)}
+	mock.ExpectQuery("INSERT INTO outbox_event (.+) VALUES \\(\\$1, (.+)\\) RETURNING id").
+		WithArgs(string(models.PaymentDeleted), int64(12), "org-a", "{}", e.CreatedAt, e.NextAttemptAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	a := outboxRepo.NewPgOutbox(db)
+
+	err = a.Append(context.TODO(), e)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), e.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgFetchPending(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("SELECT (.+) FROM outbox_event WHERE published_at IS NULL AND next_attempt_at <= \\$1 ORDER BY id LIMIT \\$2").
+		WithArgs(now, int64(10)).WillReturnRows(sqlmock.NewRows(columns).AddRow(eventRow(1, models.PaymentCreated, 0)...))
+
+	a := outboxRepo.NewPgOutbox(db)
+
+	list, err := a.FetchPending(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestPgMarkFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectExec("UPDATE outbox_event SET attempts=attempts\\+1, last_error=\\$1, next_attempt_at=\\$2 WHERE id = \\$3").
+		WithArgs("broker down", now, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := outboxRepo.NewPgOutbox(db)
+
+	assert.NoError(t, a.MarkFailed(context.TODO(), 2, "broker down", now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type sqliteOutbox struct {
+	Conn *sql.DB
+}
+
+// NewSqliteOutbox sqlite outbox constructor.
+func NewSqliteOutbox(Conn *sql.DB) outbox.Repository {
+	return &sqliteOutbox{Conn}
+}
+
+// Append records the given events on the outbox.
+func (m *sqliteOutbox) Append(ctx context.Context, events ...*models.Event) error {
+	query := `INSERT INTO outbox_event (type, payment, organisation, payload, created_at, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	for _, e := range events {
+		tracing.Statement(ctx, query)
+		res, err := m.Conn.ExecContext(ctx, query, e.Type, e.Payment, e.Organisation, string(e.Payload), e.CreatedAt, e.NextAttemptAt)
+		if err != nil {
+			return err
+		}
+		if e.ID, err = res.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FetchPending lists up to num unpublished events due by now, oldest first.
+func (m *sqliteOutbox) FetchPending(ctx context.Context, now time.Time, num int64) ([]*models.Event, error) {
+	query := `SELECT ` + eventColumns + `
+  						FROM outbox_event WHERE published_at IS NULL AND next_attempt_at <= ? ORDER BY id LIMIT ?`
+
+	return fetchEvents(ctx, m.Conn, query, now, num)
+}
+
+// MarkPublished records the event as published at the given time.
+func (m *sqliteOutbox) MarkPublished(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE outbox_event SET published_at=?, attempts=attempts+1 WHERE id = ?`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// MarkFailed records a failed publication of the event, to be retried at next.
+func (m *sqliteOutbox) MarkFailed(ctx context.Context, id int64, lastError string, next time.Time) error {
+	query := `UPDATE outbox_event SET attempts=attempts+1, last_error=?, next_attempt_at=? WHERE id = ?`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, lastError, next, id)
+	return err
+}
@@ -0,0 +1,49 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	"github.com/adriacidre/go-clean-arch/testutil"
+)
+
+func TestSqliteOutboxRoundTrip(t *testing.T) {
+	db := testutil.OpenSqlite(t, ":memory:")
+
+	a := outboxRepo.NewSqliteOutbox(db)
+	ctx := context.TODO()
+
+	var events []*models.Event
+	for _, eventType := range []models.EventType{models.PaymentCreated, models.PaymentUpdated, models.PaymentDeleted} {
+		e, err := models.NewPaymentEvent(eventType, &models.Payment{ID: 12, Organisation: "org-a"}, nil)
+		assert.NoError(t, err)
+		events = append(events, e)
+	}
+	assert.NoError(t, a.Append(ctx, events...))
+	assert.Equal(t, int64(3), events[2].ID)
+
+	now := time.Now()
+	assert.NoError(t, a.MarkPublished(ctx, events[0].ID, now))
+	assert.NoError(t, a.MarkFailed(ctx, events[1].ID, "broker down", now.Add(time.Hour)))
+
+	list, err := a.FetchPending(ctx, now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, models.PaymentDeleted, list[0].Type)
+		assert.Equal(t, "org-a", list[0].Organisation)
+		assert.JSONEq(t, string(events[2].Payload), string(list[0].Payload))
+	}
+
+	list, err = a.FetchPending(ctx, now.Add(2*time.Hour), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, events[1].ID, list[0].ID)
+		assert.Equal(t, 1, list[0].Attempts)
+		assert.Equal(t, "broker down", list[0].LastError)
+	}
+}
@@ -0,0 +1,18 @@
+package payment
+
+import "context"
+
+type deletedKey struct{}
+
+// WithDeleted returns a copy of ctx whose payment reads include the deleted
+// payments.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deletedKey{}, true)
+}
+
+// IncludesDeleted reports whether the payment reads on ctx include the deleted
+// payments.
+func IncludesDeleted(ctx context.Context) bool {
+	included, _ := ctx.Value(deletedKey{}).(bool)
+	return included
+}
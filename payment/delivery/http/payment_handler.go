@@ -4,18 +4,21 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
-	"github.com/davecgh/go-spew/spew"
-	"github.com/sirupsen/logrus"
-
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
 	models "github.com/adriacidre/go-clean-arch/models"
 
 	paymentUcase "github.com/adriacidre/go-clean-arch/payment"
 	"github.com/labstack/echo"
-
-	validator "gopkg.in/go-playground/validator.v9"
 )
 
+// TransitionRequest request struct representing a payment action.
+type TransitionRequest struct {
+	Reason string `json:"reason"`
+}
+
 // ResponseError response struct representing an error.
 type ResponseError struct {
 	Message string `json:"message"`
@@ -26,16 +29,20 @@ type PaymentHandler struct {
 	Usecase paymentUcase.Usecase
 }
 
-// NewPaymentHTTPHandler payment http handler constructor.
-func NewPaymentHTTPHandler(e *echo.Echo, us paymentUcase.Usecase) {
+// NewPaymentHTTPHandler payment http handler constructor, running the given
+// middleware, such as authentication, on every payment route.
+func NewPaymentHTTPHandler(e *echo.Echo, us paymentUcase.Usecase, m ...echo.MiddlewareFunc) {
 	handler := &PaymentHandler{
 		Usecase: us,
 	}
-	e.GET("/payment", handler.FetchPayment)
-	e.POST("/payment", handler.Store)
-	e.PATCH("/payment/:id", handler.Update)
-	e.GET("/payment/:id", handler.GetByID)
-	e.DELETE("/payment/:id", handler.Delete)
+	e.GET("/payment", handler.FetchPayment, m...)
+	e.POST("/payment", handler.Store, m...)
+	e.PATCH("/payment/:id", handler.Update, m...)
+	e.GET("/payment/:id", handler.GetByID, m...)
+	e.DELETE("/payment/:id", handler.Delete, m...)
+	e.POST("/payment/:id/actions/:action", handler.Transition, m...)
+	e.GET("/payment/:id/history", handler.StatusHistory, m...)
+	e.POST("/payment/:id/restore", handler.Restore, m...)
 }
 
 // FetchPayment handles fetching lists of payments.
@@ -46,10 +53,11 @@ func (h *PaymentHandler) FetchPayment(c echo.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = readContext(ctx, c)
 
 	listAr, nextCursor, err := h.Usecase.Fetch(ctx, cursor, int64(num))
 	if err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
 	}
 	c.Response().Header().Set(`X-Cursor`, nextCursor)
 
@@ -68,10 +76,17 @@ func (h *PaymentHandler) GetByID(c echo.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	ctx = readContext(ctx, c)
 
 	art, err := h.Usecase.GetByID(ctx, id)
 	if err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	tag := etag(art)
+	c.Response().Header().Set(`ETag`, tag)
+	if matchesETag(c.Request().Header.Get(`If-None-Match`), tag) {
+		return c.NoContent(http.StatusNotModified)
 	}
 
 	return c.JSON(http.StatusOK, art)
@@ -79,9 +94,7 @@ func (h *PaymentHandler) GetByID(c echo.Context) error {
 
 // isRequestValid validates request mapped payment.
 func isRequestValid(m *models.Payment) (bool, error) {
-	validate := validator.New()
-
-	err := validate.Struct(m)
+	err := m.Validate()
 	if err != nil {
 		return false, err
 	}
@@ -108,8 +121,9 @@ func (h *PaymentHandler) Store(c echo.Context) error {
 
 	ar, err := h.Usecase.Store(ctx, &payment)
 	if err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
 	}
+	c.Response().Header().Set(`ETag`, etag(ar))
 
 	return c.JSON(http.StatusCreated, ar)
 }
@@ -128,19 +142,21 @@ func (h *PaymentHandler) Delete(c echo.Context) error {
 	}
 
 	if _, err = h.Usecase.Delete(ctx, id); err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
 	}
 
 	return c.NoContent(http.StatusNoContent)
 }
 
-// Update handles the payment storage updates.
+// Update handles the payment storage updates. Clients send the ETag of the
+// payment they read as If-Match, so that it is not updated if modified since,
+// If-Match not listing the current one failing with 412; without it, only
+// changes made while the update is in progress are detected.
 func (h *PaymentHandler) Update(c echo.Context) error {
 	var input models.Payment
 
 	if err := c.Bind(&input); err != nil {
-		spew.Dump(input)
-		println(err.Error())
+		logging.FromContext(c.Request().Context()).WithError(err).Warn("invalid payment update")
 		return c.JSON(http.StatusUnprocessableEntity, err.Error())
 	}
 
@@ -161,34 +177,170 @@ func (h *PaymentHandler) Update(c echo.Context) error {
 
 	payment, err := h.Usecase.GetByID(ctx, id)
 	if err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+	if match := c.Request().Header.Get(`If-Match`); match != "" && !matchesStrongETag(match, etag(payment)) {
+		return c.JSON(getStatusCode(ctx, models.ErrStaleVersion), ResponseError{Message: models.ErrStaleVersion.Error()})
 	}
 
 	payment.Organisation = input.Organisation
+	payment.Amount = input.Amount
+	payment.Currency = input.Currency
+	payment.Debtor = input.Debtor
+	payment.Beneficiary = input.Beneficiary
+	payment.Scheme = input.Scheme
+	payment.Reference = input.Reference
+	payment.EndToEndID = input.EndToEndID
 	ar, err := h.Usecase.Update(ctx, payment)
 	if err != nil {
-		return c.JSON(getStatusCode(err), ResponseError{Message: err.Error()})
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
 	}
+	c.Response().Header().Set(`ETag`, etag(ar))
 
 	return c.JSON(http.StatusOK, ar)
 }
 
+// Transition handles payment lifecycle action requests.
+func (h *PaymentHandler) Transition(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+
+	var input TransitionRequest
+	if c.Request().ContentLength > 0 {
+		if err = c.Bind(&input); err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, err.Error())
+		}
+	}
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	payment, err := h.Usecase.Transition(ctx, id, models.StatusEvent(c.Param("action")), input.Reason)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, payment)
+}
+
+// StatusHistory handles payment status history requests.
+func (h *PaymentHandler) StatusHistory(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = readContext(ctx, c)
+
+	history, err := h.Usecase.StatusHistory(ctx, id)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
+
+// Restore handles deleted payment restoration requests.
+func (h *PaymentHandler) Restore(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	payment, err := h.Usecase.Restore(ctx, id)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+	c.Response().Header().Set(`ETag`, etag(payment))
+
+	return c.JSON(http.StatusOK, payment)
+}
+
+// readContext returns a copy of ctx including the deleted payments when the
+// include_deleted query parameter is set.
+func readContext(ctx context.Context, c echo.Context) context.Context {
+	if deleted, _ := strconv.ParseBool(c.QueryParam("include_deleted")); deleted {
+		return paymentUcase.WithDeleted(ctx)
+	}
+
+	return ctx
+}
+
+// etag entity tag of the given payment, changing along with its version.
+func etag(p *models.Payment) string {
+	return `"` + strconv.FormatInt(p.Version, 10) + `"`
+}
+
+// matchesStrongETag reports whether the given If-Match header lists tag, weak
+// entity tags never matching as If-Match compares them strongly.
+func matchesStrongETag(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesETag reports whether the given If-None-Match header lists tag, weak
+// entity tags matching their strong counterparts.
+func matchesETag(header, tag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimPrefix(strings.TrimSpace(t), "W/")
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
 // getStatusCode based on the useacse output error calculates the http response
 // status code.
-func getStatusCode(err error) int {
+func getStatusCode(ctx context.Context, err error) int {
 	if err == nil {
 		return http.StatusOK
 	}
 
-	logrus.Error(err)
+	status := http.StatusInternalServerError
+	if _, ok := err.(*auth.PermissionError); ok {
+		status = http.StatusForbidden
+	}
 	switch err {
-	case models.ErrInternalServer:
-		return http.StatusInternalServerError
 	case models.ErrNotFound:
-		return http.StatusNotFound
-	case models.ErrConflict:
-		return http.StatusConflict
-	default:
-		return http.StatusInternalServerError
+		status = http.StatusNotFound
+	case models.ErrConflict, models.ErrInvalidTransition:
+		status = http.StatusConflict
+	case models.ErrStaleVersion:
+		status = http.StatusPreconditionFailed
+	case models.ErrUnknownEvent:
+		status = http.StatusBadRequest
+	}
+
+	// Client errors are expected, only server ones are logged as errors.
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error(err)
+	} else {
+		logging.FromContext(ctx).Debug(err)
 	}
+
+	return status
 }
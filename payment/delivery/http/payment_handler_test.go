@@ -2,6 +2,7 @@ package http_test
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -9,10 +10,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/adriacidre/go-clean-arch/logging"
 	models "github.com/adriacidre/go-clean-arch/models"
 	paymentHttp "github.com/adriacidre/go-clean-arch/payment/delivery/http"
 	"github.com/adriacidre/go-clean-arch/payment/mocks"
 	"github.com/labstack/echo"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
@@ -108,6 +112,7 @@ func TestStore(t *testing.T) {
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
 	}
+	withPaymentAttributes(&mockPayment)
 
 	tempMockPayment := mockPayment
 	tempMockPayment.ID = 0
@@ -136,10 +141,45 @@ func TestStore(t *testing.T) {
 	mockUCase.AssertExpectations(t)
 }
 
+func TestStoreInvalidAttributes(t *testing.T) {
+	mockPayment := models.Payment{
+		PaymentID:    "Payment",
+		Organisation: "ORG",
+	}
+	withPaymentAttributes(&mockPayment)
+	mockPayment.Amount = "-10.5"
+	mockPayment.Currency = "XXX"
+
+	mockUCase := new(mocks.Payment)
+
+	j, err := json.Marshal(mockPayment)
+	assert.NoError(t, err)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/payment", strings.NewReader(string(j)))
+	assert.NoError(t, err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("/payment")
+
+	handler := paymentHttp.PaymentHandler{
+		Usecase: mockUCase,
+	}
+	assert.Nil(t, handler.Store(c))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Amount")
+	assert.Contains(t, rec.Body.String(), "Currency")
+	mockUCase.AssertExpectations(t)
+}
+
 func TestUpdate(t *testing.T) {
 	var mockPayment models.Payment
 	err := faker.FakeData(&mockPayment)
 	assert.NoError(t, err)
+	withPaymentAttributes(&mockPayment)
 
 	mockUCase := new(mocks.Payment)
 
@@ -202,3 +242,144 @@ func TestDelete(t *testing.T) {
 	mockUCase.AssertExpectations(t)
 
 }
+
+func TestTransition(t *testing.T) {
+	var mockPayment models.Payment
+	err := faker.FakeData(&mockPayment)
+	assert.NoError(t, err)
+	mockPayment.Status = models.StatusSubmitted
+
+	mockUCase := new(mocks.Payment)
+
+	num := int(mockPayment.ID)
+
+	mockUCase.On("Transition", mock.Anything, int64(num), models.EventSubmit, "all checks passed").Return(&mockPayment, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/payment/"+strconv.Itoa(num)+"/actions/submit", strings.NewReader(`{"reason":"all checks passed"}`))
+	assert.NoError(t, err)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("payment/:id/actions/:action")
+	c.SetParamNames("id", "action")
+	c.SetParamValues(strconv.Itoa(num), "submit")
+	handler := paymentHttp.PaymentHandler{
+		Usecase: mockUCase,
+	}
+	assert.Nil(t, handler.Transition(c))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"submitted"`)
+	mockUCase.AssertExpectations(t)
+}
+
+func TestTransitionNotAllowed(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+
+	mockUCase.On("Transition", mock.Anything, int64(7), models.EventSettle, "").Return(nil, models.ErrInvalidTransition)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.POST, "/payment/7/actions/settle", strings.NewReader(""))
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("payment/:id/actions/:action")
+	c.SetParamNames("id", "action")
+	c.SetParamValues("7", "settle")
+	handler := paymentHttp.PaymentHandler{
+		Usecase: mockUCase,
+	}
+	assert.Nil(t, handler.Transition(c))
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	mockUCase.AssertExpectations(t)
+}
+
+func TestStatusHistory(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+	mockHistory := []*models.StatusChange{
+		{Payment: 7, From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove, Reason: "ok"},
+	}
+
+	mockUCase.On("StatusHistory", mock.Anything, int64(7)).Return(mockHistory, nil)
+
+	e := echo.New()
+	req, err := http.NewRequest(echo.GET, "/payment/7/history", strings.NewReader(""))
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath("payment/:id/history")
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+	handler := paymentHttp.PaymentHandler{
+		Usecase: mockUCase,
+	}
+	assert.Nil(t, handler.StatusHistory(c))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"to":"pending"`)
+	mockUCase.AssertExpectations(t)
+}
+
+// withPaymentAttributes fills the given payment with valid payment attributes.
+func withPaymentAttributes(p *models.Payment) {
+	p.Amount = "100.21"
+	p.Currency = "GBP"
+	p.Debtor = models.Party{
+		Name:          "EJ Brown Black",
+		AccountNumber: "GB29XABC10161234567801",
+		AccountScheme: "IBAN",
+		BankID:        "203301",
+	}
+	p.Beneficiary = models.Party{
+		Name:          "Wilfred Jeremiah Owens",
+		AccountNumber: "31926819",
+		AccountScheme: "BBAN",
+		BankID:        "403000",
+	}
+	p.Scheme = "FPS"
+	p.Reference = "Payment for Em's piano lessons"
+	p.EndToEndID = "Wil piano Jan"
+}
+
+func TestErrorLogLevel(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+	mockUCase.On("GetByID", mock.Anything, int64(7)).Return(nil, models.ErrNotFound).Once()
+	mockUCase.On("GetByID", mock.Anything, int64(7)).Return(nil, errors.New("database down")).Once()
+
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	e := echo.New()
+	handler := paymentHttp.PaymentHandler{
+		Usecase: mockUCase,
+	}
+
+	for _, expected := range []struct {
+		status int
+		level  logrus.Level
+	}{
+		{http.StatusNotFound, logrus.DebugLevel},
+		{http.StatusInternalServerError, logrus.ErrorLevel},
+	} {
+		req, err := http.NewRequest(echo.GET, "/payment/7", strings.NewReader(""))
+		assert.NoError(t, err)
+		req = req.WithContext(logging.NewContext(req.Context(), logger))
+
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("payment/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+		assert.Nil(t, handler.GetByID(c))
+
+		assert.Equal(t, expected.status, rec.Code)
+		if assert.NotNil(t, hook.LastEntry()) {
+			assert.Equal(t, expected.level, hook.LastEntry().Level)
+		}
+	}
+	mockUCase.AssertExpectations(t)
+}
@@ -0,0 +1,314 @@
+package http_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/middleware"
+	models "github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	paymentHttp "github.com/adriacidre/go-clean-arch/payment/delivery/http"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+	"github.com/adriacidre/go-clean-arch/testutil"
+	"github.com/adriacidre/go-clean-arch/transaction"
+)
+
+// newTestServer serves the payment http handler backed by an in-memory
+// repository.
+func newTestServer() *httptest.Server {
+	e := echo.New()
+	u := ucase.NewPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), transaction.NewMemoryManager(), time.Second*2)
+	paymentHttp.NewPaymentHTTPHandler(e, u)
+
+	return httptest.NewServer(e)
+}
+
+func TestPaymentLifecycle(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	input := models.Payment{PaymentID: "p-1", Organisation: "org-1"}
+	withPaymentAttributes(&input)
+	j, err := json.Marshal(input)
+	assert.NoError(t, err)
+
+	var created models.Payment
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), nil, &created)
+	assert.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, int64(1), created.ID)
+	assert.Equal(t, models.StatusCreated, created.Status)
+
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), nil, nil)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	url := srv.URL + "/payment/" + strconv.Itoa(int(created.ID))
+
+	input.Amount = "99.99"
+	j, err = json.Marshal(input)
+	assert.NoError(t, err)
+	var updated models.Payment
+	res = testutil.DoJSON(t, echo.PATCH, url, string(j), nil, &updated)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	var fetched models.Payment
+	res = testutil.DoJSON(t, echo.GET, url, "", nil, &fetched)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "99.99", fetched.Amount)
+
+	var approved models.Payment
+	res = testutil.DoJSON(t, echo.POST, url+"/actions/approve", `{"reason":"checked"}`, nil, &approved)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, models.StatusPending, approved.Status)
+
+	res = testutil.DoJSON(t, echo.POST, url+"/actions/settle", "", nil, nil)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	var history []models.StatusChange
+	res = testutil.DoJSON(t, echo.GET, url+"/history", "", nil, &history)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, "checked", history[0].Reason)
+	}
+
+	var list []models.Payment
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", nil, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 1)
+
+	res = testutil.DoJSON(t, echo.DELETE, url, "", nil, nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+	res = testutil.DoJSON(t, echo.GET, url, "", nil, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
+
+func TestPaymentOptimisticConcurrency(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+
+	input := models.Payment{PaymentID: "p-1", Organisation: "org-1"}
+	withPaymentAttributes(&input)
+	j, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	var created models.Payment
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), nil, &created)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, models.InitialVersion, created.Version)
+	url := srv.URL + "/payment/" + strconv.Itoa(int(created.ID))
+
+	res = testutil.DoJSON(t, echo.GET, url, "", nil, &models.Payment{})
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	read := res.Header.Get("ETag")
+	assert.Equal(t, `"1"`, read)
+
+	res = testutil.DoJSON(t, echo.GET, url, "", map[string]string{"If-None-Match": read}, nil)
+	assert.Equal(t, http.StatusNotModified, res.StatusCode)
+
+	input.Amount = "99.99"
+	j, err = json.Marshal(input)
+	require.NoError(t, err)
+	var updated models.Payment
+	res = testutil.DoJSON(t, echo.PATCH, url, string(j), map[string]string{"If-Match": read}, &updated)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, models.InitialVersion+1, updated.Version)
+	assert.Equal(t, `"2"`, res.Header.Get("ETag"))
+
+	input.Amount = "11.11"
+	j, err = json.Marshal(input)
+	require.NoError(t, err)
+	res = testutil.DoJSON(t, echo.PATCH, url, string(j), map[string]string{"If-Match": read}, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode)
+
+	for _, match := range []string{"2", `W/"2"`, `"1", W/"2"`} {
+		res = testutil.DoJSON(t, echo.PATCH, url, string(j), map[string]string{"If-Match": match}, nil)
+		assert.Equal(t, http.StatusPreconditionFailed, res.StatusCode, match)
+	}
+
+	var fetched models.Payment
+	res = testutil.DoJSON(t, echo.GET, url, "", map[string]string{"If-None-Match": read}, &fetched)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "99.99", fetched.Amount)
+
+	res = testutil.DoJSON(t, echo.PATCH, url, string(j), map[string]string{"If-Match": `"1", "2"`}, &updated)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "11.11", updated.Amount)
+}
+
+func TestPaymentTenantIsolation(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = testutil.Verifier()
+
+	e := echo.New()
+	u := ucase.NewPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), transaction.NewMemoryManager(), time.Second*2)
+	paymentHttp.NewPaymentHTTPHandler(e, u, m.JWT, m.Tenant)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	orgA, orgB := testutil.Bearer(t, "org-a"), testutil.Bearer(t, "org-b")
+
+	input := models.Payment{PaymentID: "p-1", Organisation: "org-a"}
+	withPaymentAttributes(&input)
+	j, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), orgB, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode, "payments cannot be created for other organisations")
+
+	var created models.Payment
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), orgA, &created)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	url := srv.URL + "/payment/" + strconv.Itoa(int(created.ID))
+
+	for _, req := range []struct{ method, url, body string }{
+		{echo.GET, url, ""},
+		{echo.PATCH, url, string(j)},
+		{echo.POST, url + "/actions/approve", ""},
+		{echo.GET, url + "/history", ""},
+		{echo.DELETE, url, ""},
+	} {
+		res = testutil.DoJSON(t, req.method, req.url, req.body, orgB, nil)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode, "%s %s", req.method, req.url)
+	}
+
+	var list []models.Payment
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", orgB, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 0)
+
+	res = testutil.DoJSON(t, echo.GET, url, "", orgA, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Only admins can choose the organisation, and they must do it explicitly.
+	withOrganisation := func(headers map[string]string, organisation string) map[string]string {
+		headers[middleware.OrganisationHeader] = organisation
+		return headers
+	}
+	res = testutil.DoJSON(t, echo.GET, url, "", withOrganisation(testutil.Bearer(t, "org-b"), "org-a"), nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, url, "", testutil.Bearer(t, "ops", auth.RoleAdmin), nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, url, "", withOrganisation(testutil.Bearer(t, "ops", auth.RoleAdmin), "org-a"), nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", withOrganisation(testutil.Bearer(t, "ops", auth.RoleAdmin), "*"), &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 1)
+}
+
+func TestPaymentRolePolicy(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = testutil.Verifier()
+
+	e := echo.New()
+	u := ucase.NewPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), transaction.NewMemoryManager(), time.Second*2)
+	u = ucase.NewAuthorizedPayment(u, auth.NewPolicy(map[string][]string{
+		"operator": {"payments:read", "payments:create", "payments:approve"},
+		"approver": {"payments:read", "payments:submit"},
+		"auditor":  {"payments:read"},
+	}))
+	paymentHttp.NewPaymentHTTPHandler(e, u, m.JWT, m.Tenant)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	operator, approver, auditor := testutil.Bearer(t, "org-a", "operator"), testutil.Bearer(t, "org-a", "approver"), testutil.Bearer(t, "org-a", "auditor")
+
+	input := models.Payment{PaymentID: "p-1", Organisation: "org-a"}
+	withPaymentAttributes(&input)
+	j, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	var denied paymentHttp.ResponseError
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), auditor, &denied)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "Missing permission payments:create", denied.Message)
+
+	var created models.Payment
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), operator, &created)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	url := srv.URL + "/payment/" + strconv.Itoa(int(created.ID))
+
+	res = testutil.DoJSON(t, echo.POST, url+"/actions/approve", "", operator, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, url+"/actions/submit", "", operator, &denied)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Equal(t, "Missing permission payments:submit", denied.Message)
+	res = testutil.DoJSON(t, echo.POST, url+"/actions/submit", "", approver, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res = testutil.DoJSON(t, echo.GET, url+"/history", "", auditor, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	res = testutil.DoJSON(t, echo.DELETE, url, "", auditor, nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+}
+
+func TestPaymentSoftDelete(t *testing.T) {
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = testutil.Verifier()
+
+	e := echo.New()
+	u := ucase.NewPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), transaction.NewMemoryManager(), time.Second*2)
+	paymentHttp.NewPaymentHTTPHandler(e, ucase.NewAuthorizedPayment(u, nil), m.JWT, m.Tenant)
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	user, admin := testutil.Bearer(t, "org-1"), testutil.Bearer(t, "org-1", auth.RoleAdmin)
+
+	input := models.Payment{PaymentID: "p-1", Organisation: "org-1"}
+	withPaymentAttributes(&input)
+	j, err := json.Marshal(input)
+	require.NoError(t, err)
+
+	var created models.Payment
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), user, &created)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	url := srv.URL + "/payment/" + strconv.Itoa(int(created.ID))
+
+	res = testutil.DoJSON(t, echo.POST, url+"/restore", "", admin, nil)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+	res = testutil.DoJSON(t, echo.DELETE, url, "", user, nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, url, "", user, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	var list []models.Payment
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment", "", user, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Empty(t, list)
+
+	// Only admins see and restore deleted payments, even without a policy.
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment?include_deleted=true", "", user, nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, url+"/restore", "", user, nil)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, url+"/restore", "", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/payment?include_deleted=true", "", admin, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, list, 1)
+	assert.True(t, list[0].Deleted())
+
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/payment", string(j), user, nil)
+	assert.Equal(t, http.StatusConflict, res.StatusCode, "deleted payments keep their payment ID until purged")
+
+	var restored models.Payment
+	res = testutil.DoJSON(t, echo.POST, url+"/restore", "", admin, &restored)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.False(t, restored.Deleted())
+	assert.Equal(t, models.InitialVersion+2, restored.Version)
+	assert.Equal(t, `"3"`, res.Header.Get("ETag"))
+
+	res = testutil.DoJSON(t, echo.GET, url, "", user, nil)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+}
@@ -4,6 +4,7 @@ package mocks
 import context "context"
 import mock "github.com/stretchr/testify/mock"
 import models "github.com/adriacidre/go-clean-arch/models"
+import time "time"
 
 // Repository is an autogenerated mock type for the Payment type
 type Repository struct {
@@ -54,6 +55,29 @@ func (_m *Repository) Fetch(ctx context.Context, cursor string, num int64) ([]*m
 	return r0, r1
 }
 
+// FetchStatusHistory provides a mock function with given fields: ctx, id
+func (_m *Repository) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*models.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*models.StatusChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByID provides a mock function with given fields: ctx, id
 func (_m *Repository) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
 	ret := _m.Called(ctx, id)
@@ -100,6 +124,41 @@ func (_m *Repository) GetByPaymentID(ctx context.Context, title string) (*models
 	return r0, r1
 }
 
+// Purge provides a mock function with given fields: ctx, before, num
+func (_m *Repository) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	ret := _m.Called(ctx, before, num)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int64) int64); ok {
+		r0 = rf(ctx, before, num)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int64) error); ok {
+		r1 = rf(ctx, before, num)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Repository) Restore(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Store provides a mock function with given fields: ctx, a
 func (_m *Repository) Store(ctx context.Context, a *models.Payment) (int64, error) {
 	ret := _m.Called(ctx, a)
@@ -143,3 +202,17 @@ func (_m *Repository) Update(ctx context.Context, _a1 *models.Payment) (*models.
 
 	return r0, r1
 }
+
+// UpdateStatus provides a mock function with given fields: ctx, p, change
+func (_m *Repository) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	ret := _m.Called(ctx, p, change)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *models.Payment, *models.StatusChange) error); ok {
+		r0 = rf(ctx, p, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -107,6 +107,52 @@ func (_m *Payment) GetByPaymentID(ctx context.Context, title string) (*models.Pa
 	return r0, r1
 }
 
+// Restore provides a mock function with given fields: ctx, id
+func (_m *Payment) Restore(ctx context.Context, id int64) (*models.Payment, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *models.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *models.Payment); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// StatusHistory provides a mock function with given fields: ctx, id
+func (_m *Payment) StatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*models.StatusChange
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*models.StatusChange); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.StatusChange)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Store provides a mock function with given fields: _a0, _a1
 func (_m *Payment) Store(_a0 context.Context, _a1 *models.Payment) (*models.Payment, error) {
 	ret := _m.Called(_a0, _a1)
@@ -130,6 +176,29 @@ func (_m *Payment) Store(_a0 context.Context, _a1 *models.Payment) (*models.Paym
 	return r0, r1
 }
 
+// Transition provides a mock function with given fields: ctx, id, event, reason
+func (_m *Payment) Transition(ctx context.Context, id int64, event models.StatusEvent, reason string) (*models.Payment, error) {
+	ret := _m.Called(ctx, id, event, reason)
+
+	var r0 *models.Payment
+	if rf, ok := ret.Get(0).(func(context.Context, int64, models.StatusEvent, string) *models.Payment); ok {
+		r0 = rf(ctx, id, event, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Payment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, int64, models.StatusEvent, string) error); ok {
+		r1 = rf(ctx, id, event, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, ar
 func (_m *Payment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
 	ret := _m.Called(ctx, ar)
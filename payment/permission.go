@@ -0,0 +1,47 @@
+package payment
+
+import (
+	model "github.com/adriacidre/go-clean-arch/models"
+)
+
+// Permissions authorising the payment use cases, those of the lifecycle
+// events being named after them, e.g. payments:approve.
+const (
+	PermissionRead   = "payments:read"
+	PermissionCreate = "payments:create"
+	PermissionUpdate = "payments:update"
+	PermissionDelete = "payments:delete"
+	// PermissionReadDeleted allows reading the deleted payments along with
+	// the others.
+	PermissionReadDeleted = "payments:read_deleted"
+	PermissionRestore     = "payments:restore"
+)
+
+// TransitionPermission permission authorising the given lifecycle event.
+func TransitionPermission(event model.StatusEvent) string {
+	return "payments:" + string(event)
+}
+
+// Permissions every permission authorising a payment use case.
+func Permissions() []string {
+	return append([]string{PermissionRead, PermissionReadDeleted, PermissionRestore}, WritePermissions()...)
+}
+
+// WritePermissions permissions authorising the payment use cases changing
+// payments, bar restoring them.
+func WritePermissions() []string {
+	permissions := []string{PermissionCreate, PermissionUpdate, PermissionDelete}
+	for _, event := range []model.StatusEvent{
+		model.EventApprove,
+		model.EventSubmit,
+		model.EventAccept,
+		model.EventReject,
+		model.EventSettle,
+		model.EventCancel,
+		model.EventReturn,
+	} {
+		permissions = append(permissions, TransitionPermission(event))
+	}
+
+	return permissions
+}
@@ -0,0 +1,131 @@
+// Package purger hard-deletes the payments deleted longer ago than their
+// retention period and the expired idempotent responses.
+package purger
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/idempotency"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/payment"
+)
+
+// Purger polls the repository for the payments deleted before the retention
+// period, of every organisation, and purges them along with their status
+// history. Purged payments cannot be restored anymore. It also deletes the
+// idempotent responses expired, so their table does not grow unbounded.
+type Purger struct {
+	repo      payment.Repository
+	responses idempotency.Repository
+	retention time.Duration
+	interval  time.Duration
+	batchSize int64
+	now       func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewPurger purger constructor, purging every interval up to batchSize of the
+// payments deleted longer than retention ago and of the expired idempotent
+// responses. A nil repository is not purged.
+func NewPurger(repo payment.Repository, responses idempotency.Repository, retention, interval time.Duration, batchSize int64) *Purger {
+	return &Purger{
+		repo:      repo,
+		responses: responses,
+		retention: retention,
+		interval:  interval,
+		batchSize: batchSize,
+		now:       time.Now,
+	}
+}
+
+// Start purges the expired payments and responses in the background until Shutdown.
+func (p *Purger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+	go func() {
+		defer close(p.done)
+		p.Run(ctx)
+	}()
+}
+
+// Shutdown stops the purger started by Start, waiting for the batch being
+// purged until ctx is done.
+func (p *Purger) Shutdown(ctx context.Context) error {
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.cancel()
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run purges the expired payments and responses every interval until ctx is
+// done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if p.repo != nil {
+			if _, err := p.PurgeExpired(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).WithError(err).Error("purging deleted payments")
+			}
+		}
+		if p.responses != nil {
+			if _, err := p.PurgeResponses(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).WithError(err).Error("purging expired idempotent responses")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeExpired purges a batch of the payments deleted longer than the
+// retention period ago, returning how many were purged.
+func (p *Purger) PurgeExpired(ctx context.Context) (int64, error) {
+	before := p.now().Add(-p.retention)
+	purged, err := p.repo.Purge(ctx, before, p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"purged":         purged,
+			"deleted_before": before,
+		}).Info("deleted payments purged")
+	}
+
+	return purged, nil
+}
+
+// PurgeResponses deletes a batch of the expired idempotent responses,
+// returning how many were deleted.
+func (p *Purger) PurgeResponses(ctx context.Context) (int64, error) {
+	purged, err := p.responses.Purge(ctx, p.now(), p.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	if purged > 0 {
+		logging.FromContext(ctx).WithField("purged", purged).Info("expired idempotent responses purged")
+	}
+
+	return purged, nil
+}
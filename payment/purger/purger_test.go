@@ -0,0 +1,119 @@
+package purger_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	idempotencyRepo "github.com/adriacidre/go-clean-arch/idempotency/repository"
+	"github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/payment/mocks"
+	"github.com/adriacidre/go-clean-arch/payment/purger"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+)
+
+// deletedPayment stores a payment and deletes it, returning its id.
+func deletedPayment(t *testing.T, repo payment.Repository, paymentID string) int64 {
+	id, err := repo.Store(context.TODO(), &models.Payment{PaymentID: paymentID, Organisation: "org-1"})
+	require.NoError(t, err)
+	_, err = repo.Delete(context.TODO(), id)
+	require.NoError(t, err)
+
+	return id
+}
+
+func TestPurgeExpired(t *testing.T) {
+	repo := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	first := deletedPayment(t, repo, "p-1")
+	second := deletedPayment(t, repo, "p-2")
+	kept, err := repo.Store(context.TODO(), &models.Payment{PaymentID: "p-3", Organisation: "org-1"})
+	require.NoError(t, err)
+
+	p := purger.NewPurger(repo, nil, 0, time.Second, 1)
+
+	purged, err := p.PurgeExpired(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = repo.GetByID(payment.WithDeleted(context.TODO()), first)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	purged, err = p.PurgeExpired(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = repo.GetByID(payment.WithDeleted(context.TODO()), second)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	purged, err = p.PurgeExpired(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	_, err = repo.GetByID(context.TODO(), kept)
+	assert.NoError(t, err, "payments not deleted are never purged")
+}
+
+func TestPurgeExpiredKeepsRetainedPayments(t *testing.T) {
+	repo := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	id := deletedPayment(t, repo, "p-1")
+
+	p := purger.NewPurger(repo, nil, time.Hour, time.Second, 10)
+
+	purged, err := p.PurgeExpired(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), purged)
+	_, err = repo.GetByID(payment.WithDeleted(context.TODO()), id)
+	assert.NoError(t, err)
+}
+
+func TestPurgeExpiredRepositoryError(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	start := time.Now()
+	mockRepo.On("Purge", mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return !before.Before(start.Add(-24*time.Hour)) && !before.After(time.Now().Add(-24*time.Hour))
+	}), int64(10)).Return(int64(0), errors.New("database down")).Once()
+
+	p := purger.NewPurger(mockRepo, nil, 24*time.Hour, time.Second, 10)
+
+	_, err := p.PurgeExpired(context.TODO())
+	assert.EqualError(t, err, "database down")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPurgeResponses(t *testing.T) {
+	responses := idempotencyRepo.NewMemoryIdempotency()
+	now := time.Now()
+	err := responses.Store(context.TODO(), &models.IdempotentResponse{
+		Key:       "expired",
+		CreatedAt: now.Add(-2 * time.Hour),
+		ExpiresAt: now.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+	err = responses.Store(context.TODO(), &models.IdempotentResponse{Key: "kept", CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	require.NoError(t, err)
+
+	p := purger.NewPurger(nil, responses, 0, time.Second, 10)
+
+	purged, err := p.PurgeResponses(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	_, err = responses.Get(context.TODO(), "kept")
+	assert.NoError(t, err)
+}
+
+func TestPurgerStartShutdown(t *testing.T) {
+	repo := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	id := deletedPayment(t, repo, "p-1")
+	p := purger.NewPurger(repo, nil, 0, 10*time.Millisecond, 10)
+
+	p.Start()
+	assert.Eventually(t, func() bool {
+		_, err := repo.GetByID(payment.WithDeleted(context.TODO()), id)
+		return err == models.ErrNotFound
+	}, time.Second, 5*time.Millisecond)
+	assert.NoError(t, p.Shutdown(context.TODO()))
+}
@@ -2,11 +2,14 @@ package payment
 
 import (
 	"context"
+	"time"
 
 	"github.com/adriacidre/go-clean-arch/models"
 )
 
-// Repository repository interface to interact with payment model
+// Repository repository interface to interact with payment model. Deleted
+// payments are kept, and left out of the reads unless the context includes
+// them, until purged.
 type Repository interface {
 	Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error)
 	GetByID(ctx context.Context, id int64) (*models.Payment, error)
@@ -14,4 +17,8 @@ type Repository interface {
 	Update(ctx context.Context, payment *models.Payment) (*models.Payment, error)
 	Store(ctx context.Context, p *models.Payment) (int64, error)
 	Delete(ctx context.Context, id int64) (bool, error)
+	UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error
+	FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error)
+	Restore(ctx context.Context, id int64) error
+	Purge(ctx context.Context, before time.Time, num int64) (int64, error)
 }
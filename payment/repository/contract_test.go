@@ -0,0 +1,81 @@
+package repository_test
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/payment/repository/repositorytest"
+	"github.com/adriacidre/go-clean-arch/testutil"
+)
+
+func TestMemoryContract(t *testing.T) {
+	repositorytest.RunContract(t, func(t *testing.T) payment.Repository {
+		return paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	})
+}
+
+func TestSqliteContract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	repositorytest.RunContract(t, func(t *testing.T) payment.Repository {
+		return paymentRepo.NewSqlitePayment(testutil.OpenSqlite(t, filepath.Join(dir, t.Name()[len("TestSqliteContract/"):]+".db")))
+	})
+}
+
+// TestMysqlContract runs the contract against the database at
+// PAYMENT_TEST_MYSQL_DSN, migrating it and emptying its tables.
+func TestMysqlContract(t *testing.T) {
+	dsn := os.Getenv("PAYMENT_TEST_MYSQL_DSN")
+	if dsn == "" || testing.Short() {
+		t.Skip("PAYMENT_TEST_MYSQL_DSN not set")
+	}
+
+	repositorytest.RunContract(t, func(t *testing.T) payment.Repository {
+		return paymentRepo.NewMysqlPayment(openEmptyDatabase(t, "mysql", dsn))
+	})
+}
+
+// TestPgContract runs the contract against the database at
+// PAYMENT_TEST_POSTGRES_DSN, migrating it and emptying its tables.
+func TestPgContract(t *testing.T) {
+	dsn := os.Getenv("PAYMENT_TEST_POSTGRES_DSN")
+	if dsn == "" || testing.Short() {
+		t.Skip("PAYMENT_TEST_POSTGRES_DSN not set")
+	}
+
+	repositorytest.RunContract(t, func(t *testing.T) payment.Repository {
+		return paymentRepo.NewPgPayment(openEmptyDatabase(t, "postgres", dsn))
+	})
+}
+
+// openEmptyDatabase connects to the given database, migrates it and empties
+// the payment tables, closing the connection when the test finishes.
+func openEmptyDatabase(t *testing.T, driver, dsn string) *sql.DB {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	testutil.Migrate(t, db, driver)
+
+	for _, table := range []string{"outbox_event", "payment_status_history", "payment"} {
+		if _, err = db.Exec("DELETE FROM " + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+	models "github.com/adriacidre/go-clean-arch/models"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+)
+
+type instrumentedPayment struct {
+	next  payment.Repository
+	calls *metrics.Calls
+}
+
+// NewInstrumentedPayment decorates a payment repository recording the latency
+// and errors of every call.
+func NewInstrumentedPayment(next payment.Repository, calls *metrics.Calls) payment.Repository {
+	return &instrumentedPayment{
+		next:  next,
+		calls: calls,
+	}
+}
+
+func (m *instrumentedPayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
+	start := time.Now()
+	res, err := m.next.Fetch(ctx, cursor, num)
+	m.calls.Observe("Fetch", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	start := time.Now()
+	res, err := m.next.GetByID(ctx, id)
+	m.calls.Observe("GetByID", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	start := time.Now()
+	res, err := m.next.GetByPaymentID(ctx, paymentID)
+	m.calls.Observe("GetByPaymentID", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) Update(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	start := time.Now()
+	res, err := m.next.Update(ctx, p)
+	m.calls.Observe("Update", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) Store(ctx context.Context, p *models.Payment) (int64, error) {
+	start := time.Now()
+	res, err := m.next.Store(ctx, p)
+	m.calls.Observe("Store", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) Delete(ctx context.Context, id int64) (bool, error) {
+	start := time.Now()
+	res, err := m.next.Delete(ctx, id)
+	m.calls.Observe("Delete", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	start := time.Now()
+	err := m.next.UpdateStatus(ctx, p, change)
+	m.calls.Observe("UpdateStatus", start, err)
+
+	return err
+}
+
+func (m *instrumentedPayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	start := time.Now()
+	res, err := m.next.FetchStatusHistory(ctx, id)
+	m.calls.Observe("FetchStatusHistory", start, err)
+
+	return res, err
+}
+
+func (m *instrumentedPayment) Restore(ctx context.Context, id int64) error {
+	start := time.Now()
+	err := m.next.Restore(ctx, id)
+	m.calls.Observe("Restore", start, err)
+
+	return err
+}
+
+func (m *instrumentedPayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	start := time.Now()
+	res, err := m.next.Purge(ctx, before, num)
+	m.calls.Observe("Purge", start, err)
+
+	return res, err
+}
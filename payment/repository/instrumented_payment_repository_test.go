@@ -0,0 +1,41 @@
+package repository_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+	models "github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+)
+
+func TestInstrumentedPayment(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	repo := paymentRepo.NewInstrumentedPayment(paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox()), metrics.NewCalls(reg, "repository"))
+
+	id, err := repo.Store(context.TODO(), &models.Payment{PaymentID: "instrumented"})
+	assert.NoError(t, err)
+
+	p, err := repo.GetByID(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "instrumented", p.PaymentID)
+
+	_, err = repo.GetByID(context.TODO(), id+1)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	expected := `
+# HELP payment_repository_call_errors_total Method calls returning an error.
+# TYPE payment_repository_call_errors_total counter
+payment_repository_call_errors_total{method="GetByID"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "payment_repository_call_errors_total"))
+	count, err := testutil.GatherAndCount(reg, "payment_repository_call_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
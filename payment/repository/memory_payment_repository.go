@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+type memoryPayment struct {
+	mu        sync.RWMutex
+	lastID    int64
+	payments  map[int64]models.Payment
+	lastEntry int64
+	history   map[int64][]models.StatusChange
+	events    outbox.Repository
+}
+
+// NewMemoryPayment in-memory payment constructor, meant for local development
+// and tests as nothing is persisted across restarts. Domain events are
+// recorded on the given outbox while holding the payments lock, so that they
+// are never relayed ahead of the change causing them.
+func NewMemoryPayment(events outbox.Repository) payment.Repository {
+	return &memoryPayment{
+		payments: make(map[int64]models.Payment),
+		history:  make(map[int64][]models.StatusChange),
+		events:   events,
+	}
+}
+
+func (m *memoryPayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	from := cursorID(cursor)
+	ids := make([]int64, 0, len(m.payments))
+	for id, p := range m.payments {
+		if id > from && visible(ctx, p) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	result := make([]*models.Payment, 0)
+	for _, id := range ids {
+		if int64(len(result)) == num {
+			break
+		}
+		p := m.payments[id]
+		result = append(result, &p)
+	}
+
+	return result, nil
+}
+
+func (m *memoryPayment) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.payments[id]
+	if !ok || !visible(ctx, p) {
+		return nil, models.ErrNotFound
+	}
+
+	return &p, nil
+}
+
+func (m *memoryPayment) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var found *models.Payment
+	for id, p := range m.payments {
+		if p.PaymentID == paymentID && visible(ctx, p) && (found == nil || id < found.ID) {
+			p := p
+			found = &p
+		}
+	}
+
+	if found == nil {
+		return nil, models.ErrNotFound
+	}
+
+	return found, nil
+}
+
+func (m *memoryPayment) Store(ctx context.Context, a *models.Payment) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.duplicated(0, a) {
+		return 0, models.ErrConflict
+	}
+
+	m.lastID++
+	p := *a
+	p.ID = m.lastID
+	p.Version = models.InitialVersion
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = p.CreatedAt
+	if err := m.appendEvent(ctx, models.PaymentCreated, &p, nil); err != nil {
+		return 0, err
+	}
+	m.payments[p.ID] = p
+
+	return p.ID, nil
+}
+
+func (m *memoryPayment) Delete(ctx context.Context, id int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.payments[id]
+	if !ok || !tenant.Allows(ctx, p.Organisation) || p.Deleted() {
+		return false, models.ErrNotFound
+	}
+
+	now := time.Now()
+	p.DeletedAt = &now
+	p.UpdatedAt = now
+	p.Version++
+	if err := m.appendEvent(ctx, models.PaymentDeleted, &p, nil); err != nil {
+		return false, err
+	}
+	m.payments[id] = p
+
+	return true, nil
+}
+
+func (m *memoryPayment) Restore(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.payments[id]
+	if !ok || !tenant.Allows(ctx, p.Organisation) || !p.Deleted() {
+		return models.ErrNotFound
+	}
+
+	p.DeletedAt = nil
+	p.UpdatedAt = time.Now()
+	p.Version++
+	if err := m.appendEvent(ctx, models.PaymentRestored, &p, nil); err != nil {
+		return err
+	}
+	m.payments[id] = p
+
+	return nil
+}
+
+func (m *memoryPayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]int64, 0)
+	for id, p := range m.payments {
+		if p.Deleted() && p.DeletedAt.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if int64(len(ids)) > num {
+		ids = ids[:num]
+	}
+
+	for _, id := range ids {
+		delete(m.payments, id)
+		delete(m.history, id)
+	}
+
+	return int64(len(ids)), nil
+}
+
+func (m *memoryPayment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.payments[ar.ID]
+	if !ok || !tenant.Allows(ctx, stored.Organisation) || stored.Deleted() {
+		return nil, models.ErrNotFound
+	}
+	if stored.Version != ar.Version {
+		return nil, models.ErrStaleVersion
+	}
+	if m.duplicated(ar.ID, ar) {
+		return nil, models.ErrConflict
+	}
+
+	p := *ar
+	p.Status = stored.Status
+	p.Version++
+	p.CreatedAt = stored.CreatedAt
+	p.UpdatedAt = time.Now()
+	if err := m.appendEvent(ctx, models.PaymentUpdated, &p, nil); err != nil {
+		return nil, err
+	}
+	m.payments[p.ID] = p
+
+	updated := *ar
+	updated.Version = p.Version
+	return &updated, nil
+}
+
+func (m *memoryPayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.payments[p.ID]
+	if !ok || !tenant.Allows(ctx, stored.Organisation) || stored.Deleted() || stored.Status != change.From {
+		return models.ErrInvalidTransition
+	}
+
+	stored.Status = change.To
+	stored.Version++
+	stored.UpdatedAt = p.UpdatedAt
+	m.lastEntry++
+	change.ID = m.lastEntry
+	if err := m.appendEvent(ctx, models.PaymentStatusChanged, &stored, change); err != nil {
+		return err
+	}
+	m.payments[p.ID] = stored
+	m.history[p.ID] = append(m.history[p.ID], *change)
+
+	return nil
+}
+
+func (m *memoryPayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if p, ok := m.payments[id]; ok && !tenant.Allows(ctx, p.Organisation) {
+		return make([]*models.StatusChange, 0), nil
+	}
+
+	result := make([]*models.StatusChange, 0, len(m.history[id]))
+	for _, c := range m.history[id] {
+		c := c
+		result = append(result, &c)
+	}
+
+	return result, nil
+}
+
+// visible reports whether ctx may read p, deleted payments being left out
+// unless it includes them.
+func visible(ctx context.Context, p models.Payment) bool {
+	return tenant.Allows(ctx, p.Organisation) && (!p.Deleted() || payment.IncludesDeleted(ctx))
+}
+
+// duplicated reports whether a payment other than the one with the given id
+// has the organisation and payment ID of p, which the SQL backends forbid with
+// a unique index.
+func (m *memoryPayment) duplicated(id int64, p *models.Payment) bool {
+	for storedID, stored := range m.payments {
+		if storedID != id && stored.Organisation == p.Organisation && stored.PaymentID == p.PaymentID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendEvent records an event of the given type about p on the outbox.
+func (m *memoryPayment) appendEvent(ctx context.Context, t models.EventType, p *models.Payment, change *models.StatusChange) error {
+	e, err := models.NewPaymentEvent(t, p, change)
+	if err != nil {
+		return err
+	}
+
+	return m.events.Append(ctx, e)
+}
@@ -0,0 +1,136 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+)
+
+func TestMemoryStoreAndGet(t *testing.T) {
+	a := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	ar := &models.Payment{PaymentID: "payment 1", Organisation: "Organisation 1", Status: models.StatusCreated}
+
+	id, err := a.Store(context.TODO(), ar)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), id)
+
+	byID, err := a.GetByID(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "payment 1", byID.PaymentID)
+	assert.False(t, byID.CreatedAt.IsZero())
+
+	byPaymentID, err := a.GetByPaymentID(context.TODO(), "payment 1")
+	assert.NoError(t, err)
+	assert.Equal(t, id, byPaymentID.ID)
+
+	_, err = a.GetByID(context.TODO(), id+1)
+	assert.Equal(t, models.ErrNotFound, err)
+}
+
+func TestMemoryFetch(t *testing.T) {
+	a := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	for i := 1; i <= 5; i++ {
+		_, err := a.Store(context.TODO(), &models.Payment{PaymentID: "payment " + strconv.Itoa(i)})
+		assert.NoError(t, err)
+	}
+
+	list, err := a.Fetch(context.TODO(), "", 2)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, int64(1), list[0].ID)
+		assert.Equal(t, int64(2), list[1].ID)
+	}
+
+	list, err = a.Fetch(context.TODO(), "4", 2)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, int64(5), list[0].ID)
+	}
+}
+
+func TestMemoryUpdateAndDelete(t *testing.T) {
+	a := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	id, err := a.Store(context.TODO(), &models.Payment{PaymentID: "payment 1", Organisation: "Organisation 1"})
+	assert.NoError(t, err)
+
+	_, err = a.Update(context.TODO(), &models.Payment{ID: id, PaymentID: "payment 1", Organisation: "modified", Version: models.InitialVersion})
+	assert.NoError(t, err)
+
+	stored, err := a.GetByID(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "modified", stored.Organisation)
+
+	deleted, err := a.Delete(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = a.Delete(context.TODO(), id)
+	assert.Error(t, err)
+
+	_, err = a.Update(context.TODO(), stored)
+	assert.Error(t, err)
+}
+
+func TestMemoryUpdateStatus(t *testing.T) {
+	a := paymentRepo.NewMemoryPayment(outboxRepo.NewMemoryOutbox())
+	ar := &models.Payment{PaymentID: "payment 1", Status: models.StatusCreated}
+	id, err := a.Store(context.TODO(), ar)
+	assert.NoError(t, err)
+	ar.ID = id
+
+	change := &models.StatusChange{Payment: id, From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove}
+	assert.NoError(t, a.UpdateStatus(context.TODO(), ar, change))
+	assert.Equal(t, int64(1), change.ID)
+
+	stale := &models.StatusChange{Payment: id, From: models.StatusCreated, To: models.StatusCancelled, Event: models.EventCancel}
+	assert.Equal(t, models.ErrInvalidTransition, a.UpdateStatus(context.TODO(), ar, stale))
+
+	history, err := a.FetchStatusHistory(context.TODO(), id)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, models.StatusPending, history[0].To)
+	}
+
+	stored, err := a.GetByID(context.TODO(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, stored.Status)
+}
+
+func TestMemoryOutboxEvents(t *testing.T) {
+	events := outboxRepo.NewMemoryOutbox()
+	a := paymentRepo.NewMemoryPayment(events)
+	ar := &models.Payment{PaymentID: "payment 1", Organisation: "Organisation 1", Status: models.StatusCreated}
+
+	id, err := a.Store(context.TODO(), ar)
+	assert.NoError(t, err)
+	ar.ID, ar.Version = id, models.InitialVersion
+	_, err = a.Update(context.TODO(), ar)
+	assert.NoError(t, err)
+	change := &models.StatusChange{Payment: id, From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove}
+	assert.NoError(t, a.UpdateStatus(context.TODO(), ar, change))
+	_, err = a.Delete(context.TODO(), id)
+	assert.NoError(t, err)
+
+	list, err := events.FetchPending(context.TODO(), time.Now(), 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 4) {
+		assert.Equal(t, models.PaymentCreated, list[0].Type)
+		assert.Equal(t, models.PaymentUpdated, list[1].Type)
+		assert.Equal(t, models.PaymentStatusChanged, list[2].Type)
+		assert.Equal(t, models.PaymentDeleted, list[3].Type)
+		assert.Equal(t, "Organisation 1", list[3].Organisation)
+
+		var payload models.PaymentEvent
+		assert.NoError(t, json.Unmarshal(list[2].Payload, &payload))
+		assert.Equal(t, models.StatusPending, payload.Payment.Status)
+		assert.Equal(t, models.EventApprove, payload.StatusChange.Event)
+	}
+}
@@ -3,15 +3,34 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
 	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/go-sql-driver/mysql"
 
+	"github.com/adriacidre/go-clean-arch/logging"
 	models "github.com/adriacidre/go-clean-arch/models"
 	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/transaction"
 )
 
+// organisationFilter restricts a statement to the organisation of the caller,
+// taking it twice as arguments, an empty one matching every organisation.
+const organisationFilter = `(? = '' OR organisation = ?)`
+
+// paymentColumns columns selected when fetching payments, in scan order.
+const paymentColumns = `id,payment_id,organisation,amount,currency,
+	debtor_name,debtor_account_number,debtor_account_scheme,debtor_bank_id,
+	beneficiary_name,beneficiary_account_number,beneficiary_account_scheme,beneficiary_bank_id,
+	scheme,reference,end_to_end_id,status,version,updated_at,created_at,deleted_at`
+
+// paymentExists counts the payments with the given ID visible to the caller.
+const paymentExists = `SELECT COUNT(*) FROM payment WHERE ID = ? AND deleted_at IS NULL AND ` + organisationFilter
+
+// mysqlInsertEvent records a domain event on the outbox.
+const mysqlInsertEvent = `INSERT outbox_event SET type=? , payment=? , organisation=? , payload=? , created_at=? , next_attempt_at=?`
+
 type mysqlPayment struct {
 	Conn *sql.DB
 }
@@ -22,10 +41,16 @@ func NewMysqlPayment(Conn *sql.DB) payment.Repository {
 }
 
 func (m *mysqlPayment) fetch(ctx context.Context, query string, args ...interface{}) ([]*models.Payment, error) {
-	rows, err := m.Conn.QueryContext(ctx, query, args...)
+	return fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, args...)
+}
+
+// fetchPayments runs the given payments query, scanning paymentColumns rows.
+func fetchPayments(ctx context.Context, conn transaction.DB, query string, args ...interface{}) ([]*models.Payment, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
 
 	if err != nil {
-		logrus.Error(err)
+		logging.FromContext(ctx).Error(err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -36,12 +61,28 @@ func (m *mysqlPayment) fetch(ctx context.Context, query string, args ...interfac
 			&t.ID,
 			&t.PaymentID,
 			&t.Organisation,
+			&t.Amount,
+			&t.Currency,
+			&t.Debtor.Name,
+			&t.Debtor.AccountNumber,
+			&t.Debtor.AccountScheme,
+			&t.Debtor.BankID,
+			&t.Beneficiary.Name,
+			&t.Beneficiary.AccountNumber,
+			&t.Beneficiary.AccountScheme,
+			&t.Beneficiary.BankID,
+			&t.Scheme,
+			&t.Reference,
+			&t.EndToEndID,
+			&t.Status,
+			&t.Version,
 			&t.UpdatedAt,
 			&t.CreatedAt,
+			&t.DeletedAt,
 		)
 
 		if err != nil {
-			logrus.Error(err)
+			logging.FromContext(ctx).Error(err)
 			return nil, err
 		}
 		result = append(result, t)
@@ -51,17 +92,19 @@ func (m *mysqlPayment) fetch(ctx context.Context, query string, args ...interfac
 }
 
 func (m *mysqlPayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
-	query := `SELECT id,payment_id,organisation, updated_at, created_at
-  						FROM payment WHERE ID > ? LIMIT ?`
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE ID > ? AND ` + deletedFilter(ctx) + organisationFilter + ` ORDER BY ID LIMIT ?`
 
-	return m.fetch(ctx, query, cursor, num)
+	org := tenant.FromContext(ctx)
+	return m.fetch(ctx, query, cursor, org, org, num)
 }
 
 func (m *mysqlPayment) GetByID(ctx context.Context, id int64) (a *models.Payment, err error) {
-	query := `SELECT id,payment_id,organisation, updated_at, created_at
-  						FROM payment WHERE ID = ?`
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE ID = ? AND ` + deletedFilter(ctx) + organisationFilter
 
-	list, err := m.fetch(ctx, query, id)
+	org := tenant.FromContext(ctx)
+	list, err := m.fetch(ctx, query, id, org, org)
 	if err != nil {
 		return nil, err
 	}
@@ -76,10 +119,11 @@ func (m *mysqlPayment) GetByID(ctx context.Context, id int64) (a *models.Payment
 }
 
 func (m *mysqlPayment) GetByPaymentID(ctx context.Context, payment string) (a *models.Payment, err error) {
-	query := `SELECT id,payment_id,organisation, updated_at, created_at
-  						FROM payment WHERE payment_id = ?`
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE payment_id = ? AND ` + deletedFilter(ctx) + organisationFilter
 
-	list, err := m.fetch(ctx, query, payment)
+	org := tenant.FromContext(ctx)
+	list, err := m.fetch(ctx, query, payment, org, org)
 	if err != nil {
 		return nil, err
 	}
@@ -94,68 +138,377 @@ func (m *mysqlPayment) GetByPaymentID(ctx context.Context, payment string) (a *m
 }
 
 func (m *mysqlPayment) Store(ctx context.Context, a *models.Payment) (int64, error) {
-	query := `INSERT payment SET payment_id=? , organisation=? , updated_at=? , created_at=?`
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	tx, err := transaction.Begin(ctx, m.Conn)
 	if err != nil {
-
 		return 0, err
 	}
 
-	logrus.Debug("Created At: ", a.CreatedAt)
-	res, err := stmt.ExecContext(ctx, a.PaymentID, a.Organisation, time.Now(), time.Now())
+	query := `INSERT payment SET payment_id=? , organisation=? , amount=? , currency=? ,
+		debtor_name=? , debtor_account_number=? , debtor_account_scheme=? , debtor_bank_id=? ,
+		beneficiary_name=? , beneficiary_account_number=? , beneficiary_account_scheme=? , beneficiary_bank_id=? ,
+		scheme=? , reference=? , end_to_end_id=? , status=? , updated_at=? , created_at=?`
+	tracing.Statement(ctx, query)
+	logging.FromContext(ctx).Debug("Created At: ", a.CreatedAt)
+	now := time.Now()
+	res, err := tx.ExecContext(ctx, query, a.PaymentID, a.Organisation, a.Amount, a.Currency,
+		a.Debtor.Name, a.Debtor.AccountNumber, a.Debtor.AccountScheme, a.Debtor.BankID,
+		a.Beneficiary.Name, a.Beneficiary.AccountNumber, a.Beneficiary.AccountScheme, a.Beneficiary.BankID,
+		a.Scheme, a.Reference, a.EndToEndID, a.Status, now, now)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, mysqlError(err)
+	}
+	id, err := res.LastInsertId()
 	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
 
+	stored := *a
+	stored.ID, stored.Version, stored.CreatedAt, stored.UpdatedAt = id, models.InitialVersion, now, now
+	if err = appendEvent(ctx, tx, mysqlInsertEvent, models.PaymentCreated, &stored, nil); err != nil {
+		_ = tx.Rollback()
 		return 0, err
 	}
-	return res.LastInsertId()
+
+	return id, tx.Commit()
 }
 
 func (m *mysqlPayment) Delete(ctx context.Context, id int64) (bool, error) {
-	query := "DELETE FROM payment WHERE id = ?"
-
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	tx, err := transaction.Begin(ctx, m.Conn)
 	if err != nil {
 		return false, err
 	}
-	res, err := stmt.ExecContext(ctx, id)
-	if err != nil {
 
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE ID = ? AND deleted_at IS NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, tx, query, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
 		return false, err
 	}
-	rowsAfected, err := res.RowsAffected()
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return false, models.ErrNotFound
+	}
+
+	now := time.Now()
+	query = "UPDATE payment SET deleted_at=?, updated_at=?, version=version+1 WHERE id = ? AND deleted_at IS NULL AND " + organisationFilter
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, now, id, org, org)
 	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
 		return false, err
 	}
-	if rowsAfected != 1 {
-		err = fmt.Errorf("Weird  Behaviour. Total Affected: %d", rowsAfected)
-		logrus.Error(err)
+
+	if err = appendEvent(ctx, tx, mysqlInsertEvent, models.PaymentDeleted, deleted(list[0], &now, now), nil); err != nil {
+		_ = tx.Rollback()
 		return false, err
 	}
 
-	return true, nil
+	return true, tx.Commit()
 }
 
-func (m *mysqlPayment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
-	query := `UPDATE payment set payment_id=?, organisation=?, updated_at=? WHERE ID = ?`
+func (m *mysqlPayment) Restore(ctx context.Context, id int64) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
 
-	stmt, err := m.Conn.PrepareContext(ctx, query)
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE ID = ? AND deleted_at IS NOT NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, tx, query, id, org, org)
 	if err != nil {
-		return nil, err
+		_ = tx.Rollback()
+		return err
+	}
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return models.ErrNotFound
 	}
 
-	res, err := stmt.ExecContext(ctx, ar.PaymentID, ar.Organisation, time.Now(), ar.ID)
+	now := time.Now()
+	query = "UPDATE payment SET deleted_at=NULL, updated_at=?, version=version+1 WHERE id = ? AND deleted_at IS NOT NULL AND " + organisationFilter
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, mysqlInsertEvent, models.PaymentRestored, deleted(list[0], nil, now), nil); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *mysqlPayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	return purgePayments(ctx, m.Conn,
+		`SELECT id FROM payment WHERE deleted_at < ? ORDER BY id LIMIT ?`,
+		`DELETE FROM payment_status_history WHERE payment = ?`,
+		`DELETE FROM payment WHERE id = ?`,
+		before, num)
+}
+
+func (m *mysqlPayment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
 	if err != nil {
 		return nil, err
 	}
+
+	query := `UPDATE payment set payment_id=?, organisation=?, amount=?, currency=?,
+		debtor_name=?, debtor_account_number=?, debtor_account_scheme=?, debtor_bank_id=?,
+		beneficiary_name=?, beneficiary_account_number=?, beneficiary_account_scheme=?, beneficiary_bank_id=?,
+		scheme=?, reference=?, end_to_end_id=?, updated_at=?, version=version+1
+		WHERE ID = ? AND version = ? AND deleted_at IS NULL AND ` + organisationFilter
+
+	tracing.Statement(ctx, query)
+	org := tenant.FromContext(ctx)
+	res, err := tx.ExecContext(ctx, query, ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, time.Now(), ar.ID, ar.Version, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, mysqlError(err)
+	}
 	affect, err := res.RowsAffected()
 	if err != nil {
+		_ = tx.Rollback()
 		return nil, err
 	}
 	if affect != 1 {
-		err = fmt.Errorf("Weird  Behaviour. Total Affected: %d", affect)
-		logrus.Error(err)
+		err = staleOrMissing(ctx, tx, paymentExists, ar.ID, org, org)
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	updated := *ar
+	updated.Version++
+	if err = appendEvent(ctx, tx, mysqlInsertEvent, models.PaymentUpdated, &updated, nil); err != nil {
+		_ = tx.Rollback()
 		return nil, err
 	}
 
-	return ar, nil
+	return &updated, tx.Commit()
+}
+
+func (m *mysqlPayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE payment set status=?, updated_at=?, version=version+1 WHERE ID = ? AND status = ? AND deleted_at IS NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, change.To, p.UpdatedAt, p.ID, change.From, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect != 1 {
+		_ = tx.Rollback()
+		return models.ErrInvalidTransition
+	}
+
+	query = `INSERT payment_status_history SET payment=? , from_status=? , to_status=? , event=? , reason=? , created_at=?`
+	tracing.Statement(ctx, query)
+	res, err = tx.ExecContext(ctx, query, p.ID, change.From, change.To, change.Event, change.Reason, change.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if change.ID, err = res.LastInsertId(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, mysqlInsertEvent, models.PaymentStatusChanged, changed(p, change), change); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *mysqlPayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	query := `SELECT id,payment,from_status,to_status,event,reason,created_at
+  						FROM payment_status_history WHERE payment = ?
+  						AND payment IN (SELECT id FROM payment WHERE ` + organisationFilter + `) ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchStatusHistory(ctx, transaction.Conn(ctx, m.Conn), query, id, org, org)
+}
+
+// fetchStatusHistory runs the given status history query.
+func fetchStatusHistory(ctx context.Context, conn transaction.DB, query string, args ...interface{}) ([]*models.StatusChange, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.StatusChange, 0)
+	for rows.Next() {
+		c := new(models.StatusChange)
+		err = rows.Scan(
+			&c.ID,
+			&c.Payment,
+			&c.From,
+			&c.To,
+			&c.Event,
+			&c.Reason,
+			&c.CreatedAt,
+		)
+
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil, err
+		}
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+// appendEvent records an event of the given type about p on the outbox within
+// tx, running the given insert statement, so that it is only published once
+// the change causing it is committed.
+func appendEvent(ctx context.Context, tx transaction.DB, query string, t models.EventType, p *models.Payment, change *models.StatusChange) error {
+	e, err := models.NewPaymentEvent(t, p, change)
+	if err != nil {
+		return err
+	}
+
+	tracing.Statement(ctx, query)
+	_, err = tx.ExecContext(ctx, query, e.Type, e.Payment, e.Organisation, string(e.Payload), e.CreatedAt, e.NextAttemptAt)
+	return err
+}
+
+// changed returns a copy of p as left by the given status change.
+func changed(p *models.Payment, change *models.StatusChange) *models.Payment {
+	c := *p
+	c.Status = change.To
+	c.Version++
+	return &c
+}
+
+// deleted returns a copy of p as left at the given time by deleting it, when
+// deletedAt is set, or by restoring it.
+func deleted(p *models.Payment, deletedAt *time.Time, now time.Time) *models.Payment {
+	c := *p
+	c.DeletedAt = deletedAt
+	c.Version++
+	c.UpdatedAt = now
+	return &c
+}
+
+// deletedFilter restricts a payments query to those not deleted, unless ctx
+// includes the deleted ones.
+func deletedFilter(ctx context.Context) string {
+	if payment.IncludesDeleted(ctx) {
+		return ""
+	}
+
+	return `deleted_at IS NULL AND `
+}
+
+// purgePayments hard-deletes, within a transaction, up to num of the
+// payments deleted before the given time along with their status history.
+// The select statement lists them, taking the time and num, while the delete
+// ones take the id of each.
+func purgePayments(ctx context.Context, db *sql.DB, selectQuery, deleteHistory, deletePayment string, before time.Time, num int64) (int64, error) {
+	tx, err := transaction.Begin(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+
+	tracing.Statement(ctx, selectQuery)
+	rows, err := tx.QueryContext(ctx, selectQuery, before, num)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			_ = tx.Rollback()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	for _, id := range ids {
+		for _, query := range []string{deleteHistory, deletePayment} {
+			tracing.Statement(ctx, query)
+			if _, err = tx.ExecContext(ctx, query, id); err != nil {
+				_ = tx.Rollback()
+				return 0, err
+			}
+		}
+	}
+
+	return int64(len(ids)), tx.Commit()
+}
+
+// staleOrMissing explains an update of a payment affecting no rows, running
+// the given count query within tx: ErrStaleVersion when the payment is still
+// there under another version, ErrNotFound when it is gone.
+func staleOrMissing(ctx context.Context, tx transaction.DB, query string, args ...interface{}) error {
+	var count int64
+	tracing.Statement(ctx, query)
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return models.ErrStaleVersion
+	}
+
+	return models.ErrNotFound
+}
+
+// mysqlDuplicateEntry MySQL error number of unique index violations.
+const mysqlDuplicateEntry = 1062
+
+// mysqlError maps unique index violations, such as storing a payment ID twice,
+// to ErrConflict.
+func mysqlError(err error) error {
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return models.ErrConflict
+	}
+
+	return err
 }
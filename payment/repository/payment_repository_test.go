@@ -3,11 +3,16 @@ package repository_test
 import (
 	"context"
 	"database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
 	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
 	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/transaction"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
@@ -18,11 +23,11 @@ func TestFetch(t *testing.T) {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"id", "payment_id", "organisation_id", "updated_at", "created_at"}).
-		AddRow(1, "payment 1", "Organisation 1", time.Now(), time.Now()).
-		AddRow(2, "payment 2", "Organisation 2", time.Now(), time.Now())
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...).
+		AddRow(paymentRow(2, "payment 2", "Organisation 2")...)
 
-	query := "SELECT id,payment_id,organisation, updated_at, created_at FROM payment WHERE ID > \\? LIMIT \\?"
+	query := "SELECT (.+) FROM payment WHERE ID > \\? AND (.+) ORDER BY ID LIMIT \\?"
 
 	mock.ExpectQuery(query).WillReturnRows(rows)
 	a := paymentRepo.NewMysqlPayment(db)
@@ -39,10 +44,10 @@ func TestGetByID(t *testing.T) {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"id", "payment_id", "organisation_id", "updated_at", "created_at"}).
-		AddRow(1, "payment 1", "Organisation 1", time.Now(), time.Now())
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...)
 
-	query := "SELECT id,payment_id,organisation, updated_at, created_at FROM payment WHERE ID = \\?"
+	query := "SELECT (.+) FROM payment WHERE ID = \\?"
 
 	mock.ExpectQuery(query).WillReturnRows(rows)
 	a := paymentRepo.NewMysqlPayment(db)
@@ -58,6 +63,14 @@ func TestStore(t *testing.T) {
 	ar := &models.Payment{
 		PaymentID:    "Judul",
 		Organisation: "Organisation",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor:       debtor,
+		Beneficiary:  beneficiary,
+		Scheme:       "FPS",
+		Reference:    "Payment for Em's piano lessons",
+		EndToEndID:   "Wil piano Jan",
+		Status:       models.StatusCreated,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -67,15 +80,89 @@ func TestStore(t *testing.T) {
 	}
 	defer db.Close()
 
-	query := "INSERT  payment SET payment_id=\\? , organisation=\\? , updated_at=\\? , created_at=\\?"
-	prep := mock.ExpectPrepare(query)
-	prep.ExpectExec().WithArgs(ar.PaymentID, ar.Organisation, AnyTime{}, AnyTime{}).WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectBegin()
+	query := "INSERT  payment SET payment_id=\\? , organisation=\\? , amount=\\? , currency=\\? , (.+) , status=\\? , updated_at=\\? , created_at=\\?"
+	mock.ExpectExec(query).WithArgs(ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, ar.Status, AnyTime{}, AnyTime{}).WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET type=\\? , payment=\\? , (.+)").
+		WithArgs(string(models.PaymentCreated), int64(12), ar.Organisation, sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	a := paymentRepo.NewMysqlPayment(db)
 
 	lastID, err := a.Store(context.TODO(), ar)
 	assert.NoError(t, err)
 	assert.Equal(t, int64(12), lastID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreRollsBackWhenOutboxFails(t *testing.T) {
+	ar := &models.Payment{PaymentID: "Judul", Organisation: "Organisation", Status: models.StatusCreated}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT  payment SET (.+)").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").WillReturnError(errors.New("outbox unavailable"))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	_, err = a.Store(context.TODO(), ar)
+	assert.EqualError(t, err, "outbox unavailable")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreDuplicatePaymentID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT  payment SET (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'Judul' for key 'payment_payment_id'"})
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	_, err = a.Store(context.TODO(), &models.Payment{PaymentID: "Judul", Organisation: "Organisation"})
+	assert.Equal(t, models.ErrConflict, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreJoinsAmbientTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE payment_id = \\? (.+)").
+		WithArgs("Judul", "Organisation", "Organisation").WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectExec("INSERT  payment SET (.+)").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	err = transaction.NewSQLManager(db).WithinTx(context.TODO(), func(ctx context.Context) error {
+		if _, err := a.GetByPaymentID(tenant.NewContext(ctx, "Organisation"), "Judul"); err != models.ErrNotFound {
+			return err
+		}
+		_, err := a.Store(ctx, &models.Payment{PaymentID: "Judul", Organisation: "Organisation"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet(), "a single transaction is begun and committed")
 }
 
 func TestGetByPaymentID(t *testing.T) {
@@ -84,10 +171,10 @@ func TestGetByPaymentID(t *testing.T) {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"id", "payment_id", "organisation_id", "updated_at", "created_at"}).
-		AddRow(1, "payment 1", "Organisation 1", time.Now(), time.Now())
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...)
 
-	query := "SELECT id,payment_id,organisation, updated_at, created_at FROM payment WHERE payment_id = \\?"
+	query := "SELECT (.+) FROM payment WHERE payment_id = \\?"
 
 	mock.ExpectQuery(query).WillReturnRows(rows)
 	a := paymentRepo.NewMysqlPayment(db)
@@ -105,17 +192,137 @@ func TestDelete(t *testing.T) {
 	}
 	defer db.Close()
 
-	query := "DELETE FROM payment WHERE id = \\?"
+	query := "UPDATE payment SET deleted_at=\\?, updated_at=\\?, version=version\\+1 WHERE id = \\? AND deleted_at IS NULL AND \\(\\? = '' OR organisation = \\?\\)"
 
-	prep := mock.ExpectPrepare(query)
-	prep.ExpectExec().WithArgs(12).WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\?").WithArgs(12, "org-a", "org-a").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(paymentRow(12, "payment 12", "org-a")...))
+	mock.ExpectExec(query).WithArgs(AnyTime{}, AnyTime{}, 12, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").
+		WithArgs(string(models.PaymentDeleted), int64(12), "org-a", sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	a := paymentRepo.NewMysqlPayment(db)
 
 	num := int64(12)
-	anPaymentStatus, err := a.Delete(context.TODO(), num)
+	anPaymentStatus, err := a.Delete(tenant.NewContext(context.TODO(), "org-a"), num)
 	assert.NoError(t, err)
 	assert.True(t, anPaymentStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\? AND deleted_at IS NULL").WithArgs(12, "", "").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	deleted, err := a.Delete(context.TODO(), 12)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.False(t, deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetByIDIncludingDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	deletedAt := time.Now()
+	row := paymentRow(12, "payment 12", "org-a")
+	row[len(row)-1] = deletedAt
+
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\? AND \\(").WithArgs(12, "org-a", "org-a").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+	a := paymentRepo.NewMysqlPayment(db)
+
+	anPayment, err := a.GetByID(payment.WithDeleted(tenant.NewContext(context.TODO(), "org-a")), 12)
+	assert.NoError(t, err)
+	assert.True(t, anPayment.Deleted())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	row := paymentRow(12, "payment 12", "org-a")
+	row[len(row)-1] = time.Now()
+
+	query := "UPDATE payment SET deleted_at=NULL, updated_at=\\?, version=version\\+1 WHERE id = \\? AND deleted_at IS NOT NULL AND \\(\\? = '' OR organisation = \\?\\)"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\? AND deleted_at IS NOT NULL").WithArgs(12, "org-a", "org-a").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(row...))
+	mock.ExpectExec(query).WithArgs(AnyTime{}, 12, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").
+		WithArgs(string(models.PaymentRestored), int64(12), "org-a", sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	err = a.Restore(tenant.NewContext(context.TODO(), "org-a"), 12)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRestoreNotDeleted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\? AND deleted_at IS NOT NULL").WithArgs(12, "", "").
+		WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	err = a.Restore(context.TODO(), 12)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPurge(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	before := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM payment WHERE deleted_at < \\? ORDER BY id LIMIT \\?").WithArgs(before, int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(3)).AddRow(int64(7)))
+	for _, id := range []int64{3, 7} {
+		mock.ExpectExec("DELETE FROM payment_status_history WHERE payment = \\?").WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("DELETE FROM payment WHERE id = \\?").WithArgs(id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	purged, err := a.Purge(context.TODO(), before, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestUpdate(t *testing.T) {
@@ -124,6 +331,15 @@ func TestUpdate(t *testing.T) {
 		ID:           12,
 		PaymentID:    "Judul",
 		Organisation: "Organisation",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor:       debtor,
+		Beneficiary:  beneficiary,
+		Scheme:       "FPS",
+		Reference:    "Payment for Em's piano lessons",
+		EndToEndID:   "Wil piano Jan",
+		Status:       models.StatusCreated,
+		Version:      3,
 		CreatedAt:    now,
 		UpdatedAt:    now,
 	}
@@ -134,16 +350,157 @@ func TestUpdate(t *testing.T) {
 	}
 	defer db.Close()
 
-	query := "UPDATE payment set payment_id=\\?, organisation=\\?, updated_at=\\? WHERE ID = \\?"
+	query := "UPDATE payment set payment_id=\\?, organisation=\\?, amount=\\?, currency=\\?, (.+), updated_at=\\?, version=version\\+1\\s+WHERE ID = \\? AND version = \\?"
 
-	prep := mock.ExpectPrepare(query)
-	prep.ExpectExec().WithArgs(ar.PaymentID, ar.Organisation, AnyTime{}, ar.ID).WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(query).WithArgs(ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, AnyTime{}, ar.ID, ar.Version, "", "").WillReturnResult(sqlmock.NewResult(12, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").
+		WithArgs(string(models.PaymentUpdated), ar.ID, ar.Organisation, sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
 	a := paymentRepo.NewMysqlPayment(db)
 
 	s, err := a.Update(context.TODO(), ar)
 	assert.NoError(t, err)
-	assert.NotNil(t, s)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, int64(4), s.Version)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStaleVersion(t *testing.T) {
+	ar := &models.Payment{ID: 12, PaymentID: "Judul", Organisation: "Organisation", Version: 3}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment set (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM payment WHERE ID = \\?").
+		WithArgs(ar.ID, "", "").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	_, err = a.Update(context.TODO(), ar)
+	assert.Equal(t, models.ErrStaleVersion, err)
+	assert.Equal(t, int64(3), ar.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatus(t *testing.T) {
+	now := time.Now()
+	ar := &models.Payment{ID: 12, Status: models.StatusCreated, UpdatedAt: now}
+	change := &models.StatusChange{
+		Payment:   ar.ID,
+		From:      models.StatusCreated,
+		To:        models.StatusPending,
+		Event:     models.EventApprove,
+		Reason:    "approved by operator",
+		CreatedAt: now,
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment set status=\\?, updated_at=\\?, version=version\\+1 WHERE ID = \\? AND status = \\?").
+		WithArgs(change.To, now, ar.ID, change.From, "", "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT payment_status_history SET (.+)").
+		WithArgs(ar.ID, change.From, change.To, change.Event, change.Reason, now).WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT outbox_event SET (.+)").
+		WithArgs(string(models.PaymentStatusChanged), ar.ID, "", sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	err = a.UpdateStatus(context.TODO(), ar, change)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), change.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateStatusConcurrentChange(t *testing.T) {
+	ar := &models.Payment{ID: 12, Status: models.StatusCreated, UpdatedAt: time.Now()}
+	change := &models.StatusChange{From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment set status=(.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewMysqlPayment(db)
+
+	err = a.UpdateStatus(context.TODO(), ar, change)
+	assert.Equal(t, models.ErrInvalidTransition, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFetchStatusHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "payment", "from_status", "to_status", "event", "reason", "created_at"}).
+		AddRow(1, 12, "created", "pending", "approve", "", time.Now()).
+		AddRow(2, 12, "pending", "submitted", "submit", "sent to scheme", time.Now())
+
+	query := "SELECT (.+) FROM payment_status_history WHERE payment = \\?\\s+AND payment IN \\(SELECT id FROM payment WHERE (.+)\\) ORDER BY id"
+
+	mock.ExpectQuery(query).WithArgs(12, "org-a", "org-a").WillReturnRows(rows)
+	a := paymentRepo.NewMysqlPayment(db)
+
+	history, err := a.FetchStatusHistory(tenant.NewContext(context.TODO(), "org-a"), 12)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, models.StatusSubmitted, history[1].To)
+	assert.Equal(t, "sent to scheme", history[1].Reason)
+}
+
+var (
+	columns = []string{"id", "payment_id", "organisation", "amount", "currency",
+		"debtor_name", "debtor_account_number", "debtor_account_scheme", "debtor_bank_id",
+		"beneficiary_name", "beneficiary_account_number", "beneficiary_account_scheme", "beneficiary_bank_id",
+		"scheme", "reference", "end_to_end_id", "status", "version", "updated_at", "created_at", "deleted_at"}
+
+	debtor = models.Party{
+		Name:          "EJ Brown Black",
+		AccountNumber: "GB29XABC10161234567801",
+		AccountScheme: "IBAN",
+		BankID:        "203301",
+	}
+
+	beneficiary = models.Party{
+		Name:          "Wilfred Jeremiah Owens",
+		AccountNumber: "31926819",
+		AccountScheme: "BBAN",
+		BankID:        "403000",
+	}
+)
+
+// paymentRow builds a payment row matching columns.
+func paymentRow(id int64, paymentID, organisation string) []driver.Value {
+	return []driver.Value{id, paymentID, organisation, "100.2100", "GBP",
+		debtor.Name, debtor.AccountNumber, debtor.AccountScheme, debtor.BankID,
+		beneficiary.Name, beneficiary.AccountNumber, beneficiary.AccountScheme, beneficiary.BankID,
+		"FPS", "reference", "end to end", "created", int64(1), time.Now(), time.Now(), nil}
 }
 
 type AnyTime struct{}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/transaction"
+)
+
+// pgPaymentExists counts the payments with the given ID visible to the caller.
+const pgPaymentExists = `SELECT COUNT(*) FROM payment WHERE id = $1 AND deleted_at IS NULL AND ($2::text = '' OR organisation = $2::text)`
+
+// pgInsertEvent records a domain event on the outbox.
+const pgInsertEvent = `INSERT INTO outbox_event (type, payment, organisation, payload, created_at, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+type pgPayment struct {
+	Conn *sql.DB
+}
+
+// NewPgPayment postgres payment constructor.
+func NewPgPayment(Conn *sql.DB) payment.Repository {
+	return &pgPayment{Conn}
+}
+
+func (m *pgPayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id > $1 AND ` + deletedFilter(ctx) + `($2::text = '' OR organisation = $2::text) ORDER BY id LIMIT $3`
+
+	return fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, cursorID(cursor), tenant.FromContext(ctx), num)
+}
+
+func (m *pgPayment) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = $1 AND ` + deletedFilter(ctx) + `($2::text = '' OR organisation = $2::text)`
+
+	list, err := fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, id, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+func (m *pgPayment) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE payment_id = $1 AND ` + deletedFilter(ctx) + `($2::text = '' OR organisation = $2::text)`
+
+	list, err := fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, paymentID, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+func (m *pgPayment) Store(ctx context.Context, a *models.Payment) (int64, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO payment (payment_id, organisation, amount, currency,
+		debtor_name, debtor_account_number, debtor_account_scheme, debtor_bank_id,
+		beneficiary_name, beneficiary_account_number, beneficiary_account_scheme, beneficiary_bank_id,
+		scheme, reference, end_to_end_id, status, updated_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id`
+
+	now := time.Now()
+	var id int64
+	tracing.Statement(ctx, query)
+	err = tx.QueryRowContext(ctx, query, a.PaymentID, a.Organisation, a.Amount, a.Currency,
+		a.Debtor.Name, a.Debtor.AccountNumber, a.Debtor.AccountScheme, a.Debtor.BankID,
+		a.Beneficiary.Name, a.Beneficiary.AccountNumber, a.Beneficiary.AccountScheme, a.Beneficiary.BankID,
+		a.Scheme, a.Reference, a.EndToEndID, a.Status, now, now).Scan(&id)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, pgError(err)
+	}
+
+	stored := *a
+	stored.ID, stored.Version, stored.CreatedAt, stored.UpdatedAt = id, models.InitialVersion, now, now
+	if err = appendEvent(ctx, tx, pgInsertEvent, models.PaymentCreated, &stored, nil); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (m *pgPayment) Delete(ctx context.Context, id int64) (bool, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return false, err
+	}
+
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = $1 AND deleted_at IS NULL AND ($2::text = '' OR organisation = $2::text)`
+	list, err := fetchPayments(ctx, tx, query, id, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return false, models.ErrNotFound
+	}
+
+	now := time.Now()
+	query = `UPDATE payment SET deleted_at=$1, updated_at=$1, version=version+1
+		WHERE id = $2 AND deleted_at IS NULL AND ($3::text = '' OR organisation = $3::text)`
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, id, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
+		return false, err
+	}
+
+	if err = appendEvent(ctx, tx, pgInsertEvent, models.PaymentDeleted, deleted(list[0], &now, now), nil); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (m *pgPayment) Restore(ctx context.Context, id int64) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = $1 AND deleted_at IS NOT NULL AND ($2::text = '' OR organisation = $2::text)`
+	list, err := fetchPayments(ctx, tx, query, id, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return models.ErrNotFound
+	}
+
+	now := time.Now()
+	query = `UPDATE payment SET deleted_at=NULL, updated_at=$1, version=version+1
+		WHERE id = $2 AND deleted_at IS NOT NULL AND ($3::text = '' OR organisation = $3::text)`
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, id, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, pgInsertEvent, models.PaymentRestored, deleted(list[0], nil, now), nil); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *pgPayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	return purgePayments(ctx, m.Conn,
+		`SELECT id FROM payment WHERE deleted_at < $1 ORDER BY id LIMIT $2`,
+		`DELETE FROM payment_status_history WHERE payment = $1`,
+		`DELETE FROM payment WHERE id = $1`,
+		before, num)
+}
+
+func (m *pgPayment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE payment SET payment_id=$1, organisation=$2, amount=$3, currency=$4,
+		debtor_name=$5, debtor_account_number=$6, debtor_account_scheme=$7, debtor_bank_id=$8,
+		beneficiary_name=$9, beneficiary_account_number=$10, beneficiary_account_scheme=$11, beneficiary_bank_id=$12,
+		scheme=$13, reference=$14, end_to_end_id=$15, updated_at=$16, version=version+1
+		WHERE id = $17 AND version = $18 AND deleted_at IS NULL AND ($19::text = '' OR organisation = $19::text)`
+
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, time.Now(), ar.ID, ar.Version, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, pgError(err)
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if affect != 1 {
+		err = staleOrMissing(ctx, tx, pgPaymentExists, ar.ID, tenant.FromContext(ctx))
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	updated := *ar
+	updated.Version++
+	if err = appendEvent(ctx, tx, pgInsertEvent, models.PaymentUpdated, &updated, nil); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return &updated, tx.Commit()
+}
+
+func (m *pgPayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE payment SET status=$1, updated_at=$2, version=version+1
+		WHERE id = $3 AND status = $4 AND deleted_at IS NULL AND ($5::text = '' OR organisation = $5::text)`
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, change.To, p.UpdatedAt, p.ID, change.From, tenant.FromContext(ctx))
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect != 1 {
+		_ = tx.Rollback()
+		return models.ErrInvalidTransition
+	}
+
+	query = `INSERT INTO payment_status_history (payment, from_status, to_status, event, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	tracing.Statement(ctx, query)
+	err = tx.QueryRowContext(ctx, query, p.ID, change.From, change.To, change.Event, change.Reason, change.CreatedAt).Scan(&change.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, pgInsertEvent, models.PaymentStatusChanged, changed(p, change), change); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *pgPayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	query := `SELECT id,payment,from_status,to_status,event,reason,created_at
+  						FROM payment_status_history WHERE payment = $1
+  						AND payment IN (SELECT id FROM payment WHERE $2::text = '' OR organisation = $2::text) ORDER BY id`
+
+	return fetchStatusHistory(ctx, transaction.Conn(ctx, m.Conn), query, id, tenant.FromContext(ctx))
+}
+
+// cursorID parses the id a fetch cursor points at, starting from the
+// beginning when the cursor is empty or malformed.
+func cursorID(cursor string) int64 {
+	id, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+// pgUniqueViolation PostgreSQL error code of unique index violations.
+const pgUniqueViolation = "23505"
+
+// pgError maps unique index violations, such as storing a payment ID twice,
+// to ErrConflict.
+func pgError(err error) error {
+	if e, ok := err.(*pq.Error); ok && e.Code == pgUniqueViolation {
+		return models.ErrConflict
+	}
+
+	return err
+}
@@ -0,0 +1,247 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+func TestPgFetch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...).
+		AddRow(paymentRow(2, "payment 2", "Organisation 2")...)
+
+	query := "SELECT (.+) FROM payment WHERE id > \\$1 AND deleted_at IS NULL AND \\(\\$2::text = '' OR organisation = \\$2::text\\) ORDER BY id LIMIT \\$3"
+
+	mock.ExpectQuery(query).WithArgs(int64(0), "org-a", int64(5)).WillReturnRows(rows)
+	a := paymentRepo.NewPgPayment(db)
+	list, err := a.Fetch(tenant.NewContext(context.TODO(), "org-a"), "", int64(5))
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgGetByIDNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	query := "SELECT (.+) FROM payment WHERE id = \\$1"
+
+	mock.ExpectQuery(query).WithArgs(int64(5), "").WillReturnRows(sqlmock.NewRows(columns))
+	a := paymentRepo.NewPgPayment(db)
+
+	anPayment, err := a.GetByID(context.TODO(), int64(5))
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, anPayment)
+}
+
+func TestPgGetByPaymentID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...)
+
+	query := "SELECT (.+) FROM payment WHERE payment_id = \\$1"
+
+	mock.ExpectQuery(query).WithArgs("payment 1", "").WillReturnRows(rows)
+	a := paymentRepo.NewPgPayment(db)
+
+	anPayment, err := a.GetByPaymentID(context.TODO(), "payment 1")
+	assert.NoError(t, err)
+	assert.Equal(t, "payment 1", anPayment.PaymentID)
+}
+
+func TestPgStore(t *testing.T) {
+	ar := &models.Payment{
+		PaymentID:    "Judul",
+		Organisation: "Organisation",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor:       debtor,
+		Beneficiary:  beneficiary,
+		Scheme:       "FPS",
+		Status:       models.StatusCreated,
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	query := "INSERT INTO payment (.+) VALUES (.+) RETURNING id"
+	mock.ExpectQuery(query).WithArgs(ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, ar.Status, AnyTime{}, AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectExec("INSERT INTO outbox_event (.+) VALUES \\(\\$1, (.+)\\)").
+		WithArgs(string(models.PaymentCreated), int64(12), ar.Organisation, sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	lastID, err := a.Store(context.TODO(), ar)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), lastID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgDelete(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE id = \\$1").WithArgs(12, "").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(paymentRow(12, "payment 12", "Organisation 1")...))
+	mock.ExpectExec("UPDATE payment SET deleted_at=\\$1, updated_at=\\$1, version=version\\+1\\s+WHERE id = \\$2 AND deleted_at IS NULL").
+		WithArgs(AnyTime{}, 12, "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_event (.+)").
+		WithArgs(string(models.PaymentDeleted), int64(12), "Organisation 1", sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	anPaymentStatus, err := a.Delete(context.TODO(), int64(12))
+	assert.NoError(t, err)
+	assert.True(t, anPaymentStatus)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgUpdate(t *testing.T) {
+	ar := &models.Payment{
+		ID:           12,
+		PaymentID:    "Judul",
+		Organisation: "Organisation",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor:       debtor,
+		Beneficiary:  beneficiary,
+		Scheme:       "FPS",
+		Version:      3,
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	query := "UPDATE payment SET payment_id=\\$1, (.+), version=version\\+1\\s+WHERE id = \\$17 AND version = \\$18 AND (.+)"
+	mock.ExpectExec(query).WithArgs(ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, AnyTime{}, ar.ID, ar.Version, "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO outbox_event (.+)").
+		WithArgs(string(models.PaymentUpdated), ar.ID, ar.Organisation, sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	s, err := a.Update(context.TODO(), ar)
+	assert.NoError(t, err)
+	if assert.NotNil(t, s) {
+		assert.Equal(t, int64(4), s.Version)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgUpdateMissingPayment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment SET (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM payment WHERE id = \\$1").
+		WithArgs(int64(12), "").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	_, err = a.Update(context.TODO(), &models.Payment{ID: 12, PaymentID: "Judul", Organisation: "Organisation", Version: 3})
+	assert.Equal(t, models.ErrNotFound, err, "missing payments are not stale")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgUpdateStatus(t *testing.T) {
+	now := time.Now()
+	ar := &models.Payment{ID: 12, Status: models.StatusCreated, UpdatedAt: now}
+	change := &models.StatusChange{
+		Payment:   ar.ID,
+		From:      models.StatusCreated,
+		To:        models.StatusPending,
+		Event:     models.EventApprove,
+		CreatedAt: now,
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment SET status=\\$1, updated_at=\\$2, version=version\\+1\\s+WHERE id = \\$3 AND status = \\$4").
+		WithArgs(change.To, now, ar.ID, change.From, "").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO payment_status_history (.+) RETURNING id").
+		WithArgs(ar.ID, change.From, change.To, change.Event, change.Reason, now).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO outbox_event (.+)").
+		WithArgs(string(models.PaymentStatusChanged), ar.ID, "", sqlmock.AnyArg(), AnyTime{}, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	err = a.UpdateStatus(context.TODO(), ar, change)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), change.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPgUpdateDuplicatePaymentID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE payment SET (.+)").WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	a := paymentRepo.NewPgPayment(db)
+
+	_, err = a.Update(context.TODO(), &models.Payment{ID: 12, PaymentID: "Judul", Organisation: "Organisation"})
+	assert.Equal(t, models.ErrConflict, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,452 @@
+// Package repositorytest holds the payment.Repository conformance suite, run
+// by the tests of every backend.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+)
+
+// RepositoryFactory builds an empty payment.Repository for a contract test.
+type RepositoryFactory func(t *testing.T) payment.Repository
+
+// RunContract runs the payment.Repository conformance suite against the
+// repositories built by the given factory, so every backend can be checked to
+// behave the same way.
+func RunContract(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("NotFound", func(t *testing.T) { contractNotFound(t, newRepository(t)) })
+	t.Run("StoreRoundTrip", func(t *testing.T) { contractStoreRoundTrip(t, newRepository(t)) })
+	t.Run("FetchPagination", func(t *testing.T) { contractFetchPagination(t, newRepository(t)) })
+	t.Run("UpdateRoundTrip", func(t *testing.T) { contractUpdateRoundTrip(t, newRepository(t)) })
+	t.Run("DeleteRoundTrip", func(t *testing.T) { contractDeleteRoundTrip(t, newRepository(t)) })
+	t.Run("StatusHistory", func(t *testing.T) { contractStatusHistory(t, newRepository(t)) })
+	t.Run("ConcurrentStore", func(t *testing.T) { contractConcurrentStore(t, newRepository(t)) })
+	t.Run("TenantIsolation", func(t *testing.T) { contractTenantIsolation(t, newRepository(t)) })
+	t.Run("UniquePaymentID", func(t *testing.T) { contractUniquePaymentID(t, newRepository(t)) })
+	t.Run("OptimisticConcurrency", func(t *testing.T) { contractOptimisticConcurrency(t, newRepository(t)) })
+	t.Run("SoftDelete", func(t *testing.T) { contractSoftDelete(t, newRepository(t)) })
+}
+
+// contractPayment builds a valid payment with the given payment ID.
+func contractPayment(paymentID string) *models.Payment {
+	return &models.Payment{
+		PaymentID:    paymentID,
+		Organisation: "743d5b63-8e6f-432e-a8fa-c5d8d2ee5fcb",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor: models.Party{
+			Name:          "EJ Brown Black",
+			AccountNumber: "GB29XABC10161234567801",
+			AccountScheme: "IBAN",
+			BankID:        "203301",
+		},
+		Beneficiary: models.Party{
+			Name:          "Wilfred Jeremiah Owens",
+			AccountNumber: "31926819",
+			AccountScheme: "BBAN",
+			BankID:        "403000",
+		},
+		Scheme:     "FPS",
+		Reference:  "Payment for Em's piano lessons",
+		EndToEndID: "Wil piano Jan",
+		Status:     models.StatusCreated,
+	}
+}
+
+// assertSamePayment checks the stored payment attributes match the expected
+// ones, comparing amounts by value as backends may pad decimals.
+func assertSamePayment(t *testing.T, expected, actual *models.Payment) {
+	assert.Equal(t, expected.PaymentID, actual.PaymentID)
+	assert.Equal(t, expected.Organisation, actual.Organisation)
+	assert.True(t, sameAmount(expected.Amount, actual.Amount), "amount %s != %s", expected.Amount, actual.Amount)
+	assert.Equal(t, expected.Currency, actual.Currency)
+	assert.Equal(t, expected.Debtor, actual.Debtor)
+	assert.Equal(t, expected.Beneficiary, actual.Beneficiary)
+	assert.Equal(t, expected.Scheme, actual.Scheme)
+	assert.Equal(t, expected.Reference, actual.Reference)
+	assert.Equal(t, expected.EndToEndID, actual.EndToEndID)
+	assert.Equal(t, expected.Status, actual.Status)
+	assert.False(t, actual.CreatedAt.IsZero())
+	assert.False(t, actual.UpdatedAt.IsZero())
+}
+
+func sameAmount(a, b string) bool {
+	x, okX := new(big.Rat).SetString(a)
+	y, okY := new(big.Rat).SetString(b)
+
+	return okX && okY && x.Cmp(y) == 0
+}
+
+func contractNotFound(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+
+	p, err := repo.GetByID(ctx, 42)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, p)
+
+	p, err = repo.GetByPaymentID(ctx, "missing")
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, p)
+
+	list, err := repo.Fetch(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+}
+
+func contractStoreRoundTrip(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	expected := contractPayment("round-trip")
+
+	id, err := repo.Store(ctx, expected)
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	byID, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, byID.ID)
+	assertSamePayment(t, expected, byID)
+
+	byPaymentID, err := repo.GetByPaymentID(ctx, expected.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, id, byPaymentID.ID)
+
+	other, err := repo.Store(ctx, contractPayment("round-trip-2"))
+	require.NoError(t, err)
+	assert.True(t, other > id, "ids must auto increment")
+}
+
+func contractFetchPagination(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	stored := make([]int64, 0, 5)
+	for i := 0; i < 5; i++ {
+		id, err := repo.Store(ctx, contractPayment(fmt.Sprintf("page-%d", i)))
+		require.NoError(t, err)
+		stored = append(stored, id)
+	}
+
+	seen := make([]int64, 0, len(stored))
+	cursor := ""
+	for page := 0; page < 4; page++ {
+		list, err := repo.Fetch(ctx, cursor, 2)
+		require.NoError(t, err)
+		if len(list) == 0 {
+			break
+		}
+		assert.True(t, len(list) <= 2)
+		for _, p := range list {
+			seen = append(seen, p.ID)
+		}
+		cursor = fmt.Sprint(list[len(list)-1].ID)
+	}
+
+	assert.Equal(t, stored, seen, "pages must follow id order without gaps or repeats")
+}
+
+func contractUpdateRoundTrip(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("update"))
+	require.NoError(t, err)
+
+	p, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	p.Organisation = "modified"
+	p.Amount = "0.5"
+	p.Beneficiary.Name = "Someone Else"
+
+	updated, err := repo.Update(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, id, updated.ID)
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assertSamePayment(t, p, stored)
+
+	_, err = repo.Update(ctx, &models.Payment{ID: id + 1000})
+	assert.Error(t, err)
+}
+
+func contractDeleteRoundTrip(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("delete"))
+	require.NoError(t, err)
+
+	deleted, err := repo.Delete(ctx, id)
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, err = repo.GetByID(ctx, id)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	deleted, err = repo.Delete(ctx, id)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.False(t, deleted)
+
+	deleted, err = repo.Delete(ctx, id+1000)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.False(t, deleted)
+}
+
+func contractStatusHistory(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("status"))
+	require.NoError(t, err)
+
+	p, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	p.UpdatedAt = now
+	change := &models.StatusChange{
+		Payment:   id,
+		From:      models.StatusCreated,
+		To:        models.StatusPending,
+		Event:     models.EventApprove,
+		Reason:    "checked",
+		CreatedAt: now,
+	}
+	require.NoError(t, repo.UpdateStatus(ctx, p, change))
+	assert.NotZero(t, change.ID)
+
+	stale := &models.StatusChange{
+		Payment:   id,
+		From:      models.StatusCreated,
+		To:        models.StatusCancelled,
+		Event:     models.EventCancel,
+		CreatedAt: now,
+	}
+	assert.Equal(t, models.ErrInvalidTransition, repo.UpdateStatus(ctx, p, stale))
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusPending, stored.Status)
+
+	history, err := repo.FetchStatusHistory(ctx, id)
+	require.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, change.ID, history[0].ID)
+		assert.Equal(t, id, history[0].Payment)
+		assert.Equal(t, models.StatusCreated, history[0].From)
+		assert.Equal(t, models.StatusPending, history[0].To)
+		assert.Equal(t, models.EventApprove, history[0].Event)
+		assert.Equal(t, "checked", history[0].Reason)
+	}
+}
+
+func contractConcurrentStore(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	const writers = 20
+
+	var wg sync.WaitGroup
+	ids := make([]int64, writers)
+	errs := make([]error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], errs[i] = repo.Store(ctx, contractPayment(fmt.Sprintf("concurrent-%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	unique := make(map[int64]struct{}, writers)
+	for i := range ids {
+		assert.NoError(t, errs[i])
+		unique[ids[i]] = struct{}{}
+	}
+	assert.Len(t, unique, writers, "concurrent stores must get distinct ids")
+
+	list, err := repo.Fetch(ctx, "", writers*2)
+	require.NoError(t, err)
+	assert.Len(t, list, writers)
+}
+
+func contractTenantIsolation(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	mine := contractPayment("mine")
+	mine.Organisation = "org-a"
+	mineID, err := repo.Store(ctx, mine)
+	require.NoError(t, err)
+	theirs := contractPayment("theirs")
+	theirs.Organisation = "org-b"
+	theirsID, err := repo.Store(ctx, theirs)
+	require.NoError(t, err)
+
+	scoped := tenant.NewContext(ctx, "org-a")
+
+	_, err = repo.GetByID(scoped, theirsID)
+	assert.Equal(t, models.ErrNotFound, err)
+	_, err = repo.GetByPaymentID(scoped, "theirs")
+	assert.Equal(t, models.ErrNotFound, err)
+
+	list, err := repo.Fetch(scoped, "", 10)
+	require.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, mineID, list[0].ID)
+	}
+
+	p, err := repo.GetByID(ctx, theirsID)
+	require.NoError(t, err)
+	p.Amount = "1.00"
+	_, err = repo.Update(scoped, p)
+	assert.Error(t, err)
+
+	p.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	change := &models.StatusChange{
+		Payment:   theirsID,
+		From:      models.StatusCreated,
+		To:        models.StatusPending,
+		Event:     models.EventApprove,
+		CreatedAt: p.UpdatedAt,
+	}
+	assert.Equal(t, models.ErrInvalidTransition, repo.UpdateStatus(scoped, p, change))
+	require.NoError(t, repo.UpdateStatus(ctx, p, change))
+
+	history, err := repo.FetchStatusHistory(scoped, theirsID)
+	require.NoError(t, err)
+	assert.Len(t, history, 0)
+
+	deleted, err := repo.Delete(scoped, theirsID)
+	assert.Error(t, err)
+	assert.False(t, deleted)
+
+	stored, err := repo.GetByID(ctx, theirsID)
+	require.NoError(t, err, "other organisations payments must be left untouched")
+	assert.True(t, sameAmount(theirs.Amount, stored.Amount), "amount %s != %s", theirs.Amount, stored.Amount)
+	assert.Equal(t, models.StatusPending, stored.Status)
+
+	list, err = repo.Fetch(tenant.NewContext(ctx, ""), "", 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "an empty organisation lifts the restriction")
+}
+
+func contractUniquePaymentID(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("unique"))
+	require.NoError(t, err)
+
+	_, err = repo.Store(ctx, contractPayment("unique"))
+	assert.Equal(t, models.ErrConflict, err)
+
+	other := contractPayment("unique")
+	other.Organisation = "org-b"
+	_, err = repo.Store(ctx, other)
+	assert.NoError(t, err, "payment IDs are unique per organisation")
+
+	renamed, err := repo.Store(ctx, contractPayment("renamed"))
+	require.NoError(t, err)
+	p, err := repo.GetByID(ctx, renamed)
+	require.NoError(t, err)
+	p.PaymentID = "unique"
+	_, err = repo.Update(ctx, p)
+	assert.Equal(t, models.ErrConflict, err)
+
+	list, err := repo.Fetch(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 3)
+	byPaymentID, err := repo.GetByPaymentID(tenant.NewContext(ctx, contractPayment("").Organisation), "unique")
+	require.NoError(t, err)
+	assert.Equal(t, id, byPaymentID.ID)
+}
+
+func contractOptimisticConcurrency(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("versioned"))
+	require.NoError(t, err)
+
+	p, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, models.InitialVersion, p.Version)
+	stale := *p
+
+	p.Amount = "2.5"
+	updated, err := repo.Update(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, models.InitialVersion+1, updated.Version)
+
+	stale.Amount = "3.5"
+	_, err = repo.Update(ctx, &stale)
+	assert.Equal(t, models.ErrStaleVersion, err)
+
+	stored, err := repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, updated.Version, stored.Version)
+	assert.True(t, sameAmount("2.5", stored.Amount), "amount %s != 2.5", stored.Amount)
+
+	stored.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	change := &models.StatusChange{
+		Payment:   id,
+		From:      models.StatusCreated,
+		To:        models.StatusPending,
+		Event:     models.EventApprove,
+		CreatedAt: stored.UpdatedAt,
+	}
+	require.NoError(t, repo.UpdateStatus(ctx, stored, change))
+	_, err = repo.Update(ctx, updated)
+	assert.Equal(t, models.ErrStaleVersion, err, "status changes bump the version too")
+
+	_, err = repo.Update(ctx, &models.Payment{ID: id + 1000, Version: models.InitialVersion})
+	assert.Equal(t, models.ErrNotFound, err, "missing payments are not stale")
+}
+
+func contractSoftDelete(t *testing.T, repo payment.Repository) {
+	ctx := context.Background()
+	id, err := repo.Store(ctx, contractPayment("soft-delete"))
+	require.NoError(t, err)
+	kept, err := repo.Store(ctx, contractPayment("kept"))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.ErrNotFound, repo.Restore(ctx, id), "payments not deleted cannot be restored")
+
+	deleted, err := repo.Delete(ctx, id)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	list, err := repo.Fetch(ctx, "0", 10)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, kept, list[0].ID)
+	list, err = repo.Fetch(payment.WithDeleted(ctx), "0", 10)
+	require.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	p, err := repo.GetByID(payment.WithDeleted(ctx), id)
+	require.NoError(t, err)
+	assert.True(t, p.Deleted())
+	assert.Equal(t, models.InitialVersion+1, p.Version)
+	_, err = repo.GetByPaymentID(ctx, "soft-delete")
+	assert.Equal(t, models.ErrNotFound, err)
+	_, err = repo.Update(ctx, p)
+	assert.Equal(t, models.ErrNotFound, err, "deleted payments cannot be updated")
+
+	require.NoError(t, repo.Restore(ctx, id))
+	p, err = repo.GetByID(ctx, id)
+	require.NoError(t, err)
+	assert.False(t, p.Deleted())
+	assert.Equal(t, models.InitialVersion+2, p.Version)
+
+	_, err = repo.Delete(ctx, id)
+	require.NoError(t, err)
+	purged, err := repo.Purge(ctx, time.Now().Add(-48*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), purged, "payments deleted after the given time are kept")
+	purged, err = repo.Purge(ctx, time.Now().Add(48*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.GetByID(payment.WithDeleted(ctx), id)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Equal(t, models.ErrNotFound, repo.Restore(ctx, id))
+	_, err = repo.GetByID(ctx, kept)
+	assert.NoError(t, err, "payments not deleted are never purged")
+}
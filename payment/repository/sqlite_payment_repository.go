@@ -0,0 +1,310 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/transaction"
+)
+
+// sqliteInsertEvent records a domain event on the outbox.
+const sqliteInsertEvent = `INSERT INTO outbox_event (type, payment, organisation, payload, created_at, next_attempt_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+type sqlitePayment struct {
+	Conn *sql.DB
+}
+
+// NewSqlitePayment sqlite payment constructor. SQLite allows a single writer
+// at a time, so the given pool is expected to hold one open connection.
+func NewSqlitePayment(Conn *sql.DB) payment.Repository {
+	return &sqlitePayment{Conn}
+}
+
+func (m *sqlitePayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id > ? AND ` + deletedFilter(ctx) + organisationFilter + ` ORDER BY id LIMIT ?`
+
+	org := tenant.FromContext(ctx)
+	return fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, cursorID(cursor), org, org, num)
+}
+
+func (m *sqlitePayment) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = ? AND ` + deletedFilter(ctx) + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, id, org, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+func (m *sqlitePayment) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE payment_id = ? AND ` + deletedFilter(ctx) + organisationFilter + ` ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, transaction.Conn(ctx, m.Conn), query, paymentID, org, org)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+func (m *sqlitePayment) Store(ctx context.Context, a *models.Payment) (int64, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO payment (payment_id, organisation, amount, currency,
+		debtor_name, debtor_account_number, debtor_account_scheme, debtor_bank_id,
+		beneficiary_name, beneficiary_account_number, beneficiary_account_scheme, beneficiary_bank_id,
+		scheme, reference, end_to_end_id, status, updated_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now()
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, a.PaymentID, a.Organisation, a.Amount, a.Currency,
+		a.Debtor.Name, a.Debtor.AccountNumber, a.Debtor.AccountScheme, a.Debtor.BankID,
+		a.Beneficiary.Name, a.Beneficiary.AccountNumber, a.Beneficiary.AccountScheme, a.Beneficiary.BankID,
+		a.Scheme, a.Reference, a.EndToEndID, a.Status, now, now)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, sqliteError(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	stored := *a
+	stored.ID, stored.Version, stored.CreatedAt, stored.UpdatedAt = id, models.InitialVersion, now, now
+	if err = appendEvent(ctx, tx, sqliteInsertEvent, models.PaymentCreated, &stored, nil); err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (m *sqlitePayment) Delete(ctx context.Context, id int64) (bool, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return false, err
+	}
+
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = ? AND deleted_at IS NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, tx, query, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return false, models.ErrNotFound
+	}
+
+	now := time.Now()
+	query = `UPDATE payment SET deleted_at=?, updated_at=?, version=version+1 WHERE id = ? AND deleted_at IS NULL AND ` + organisationFilter
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, now, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
+		return false, err
+	}
+
+	if err = appendEvent(ctx, tx, sqliteInsertEvent, models.PaymentDeleted, deleted(list[0], &now, now), nil); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (m *sqlitePayment) Restore(ctx context.Context, id int64) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT ` + paymentColumns + `
+  						FROM payment WHERE id = ? AND deleted_at IS NOT NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	list, err := fetchPayments(ctx, tx, query, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if len(list) == 0 {
+		_ = tx.Rollback()
+		return models.ErrNotFound
+	}
+
+	now := time.Now()
+	query = `UPDATE payment SET deleted_at=NULL, updated_at=?, version=version+1 WHERE id = ? AND deleted_at IS NOT NULL AND ` + organisationFilter
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, now, id, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect, err := res.RowsAffected(); err != nil || affect != 1 {
+		_ = tx.Rollback()
+		if err == nil {
+			err = models.ErrNotFound
+		}
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, sqliteInsertEvent, models.PaymentRestored, deleted(list[0], nil, now), nil); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *sqlitePayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	return purgePayments(ctx, m.Conn,
+		`SELECT id FROM payment WHERE deleted_at < ? ORDER BY id LIMIT ?`,
+		`DELETE FROM payment_status_history WHERE payment = ?`,
+		`DELETE FROM payment WHERE id = ?`,
+		before, num)
+}
+
+func (m *sqlitePayment) Update(ctx context.Context, ar *models.Payment) (*models.Payment, error) {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `UPDATE payment SET payment_id=?, organisation=?, amount=?, currency=?,
+		debtor_name=?, debtor_account_number=?, debtor_account_scheme=?, debtor_bank_id=?,
+		beneficiary_name=?, beneficiary_account_number=?, beneficiary_account_scheme=?, beneficiary_bank_id=?,
+		scheme=?, reference=?, end_to_end_id=?, updated_at=?, version=version+1
+		WHERE id = ? AND version = ? AND deleted_at IS NULL AND ` + organisationFilter
+
+	tracing.Statement(ctx, query)
+	org := tenant.FromContext(ctx)
+	res, err := tx.ExecContext(ctx, query, ar.PaymentID, ar.Organisation, ar.Amount, ar.Currency,
+		ar.Debtor.Name, ar.Debtor.AccountNumber, ar.Debtor.AccountScheme, ar.Debtor.BankID,
+		ar.Beneficiary.Name, ar.Beneficiary.AccountNumber, ar.Beneficiary.AccountScheme, ar.Beneficiary.BankID,
+		ar.Scheme, ar.Reference, ar.EndToEndID, time.Now(), ar.ID, ar.Version, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, sqliteError(err)
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	if affect != 1 {
+		err = staleOrMissing(ctx, tx, paymentExists, ar.ID, org, org)
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	updated := *ar
+	updated.Version++
+	if err = appendEvent(ctx, tx, sqliteInsertEvent, models.PaymentUpdated, &updated, nil); err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+
+	return &updated, tx.Commit()
+}
+
+func (m *sqlitePayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	tx, err := transaction.Begin(ctx, m.Conn)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE payment SET status=?, updated_at=?, version=version+1 WHERE id = ? AND status = ? AND deleted_at IS NULL AND ` + organisationFilter
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	res, err := tx.ExecContext(ctx, query, change.To, p.UpdatedAt, p.ID, change.From, org, org)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect != 1 {
+		_ = tx.Rollback()
+		return models.ErrInvalidTransition
+	}
+
+	query = `INSERT INTO payment_status_history (payment, from_status, to_status, event, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	tracing.Statement(ctx, query)
+	res, err = tx.ExecContext(ctx, query, p.ID, change.From, change.To, change.Event, change.Reason, change.CreatedAt)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if change.ID, err = res.LastInsertId(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = appendEvent(ctx, tx, sqliteInsertEvent, models.PaymentStatusChanged, changed(p, change), change); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *sqlitePayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	query := `SELECT id,payment,from_status,to_status,event,reason,created_at
+  						FROM payment_status_history WHERE payment = ?
+  						AND payment IN (SELECT id FROM payment WHERE ` + organisationFilter + `) ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchStatusHistory(ctx, transaction.Conn(ctx, m.Conn), query, id, org, org)
+}
+
+// sqliteError maps unique index violations, such as storing a payment ID
+// twice, to ErrConflict.
+func sqliteError(err error) error {
+	if e, ok := err.(sqlite3.Error); ok && e.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return models.ErrConflict
+	}
+
+	return err
+}
@@ -0,0 +1,59 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/testutil"
+)
+
+func TestSqliteOutboxEvents(t *testing.T) {
+	db := testutil.OpenSqlite(t, ":memory:")
+
+	a := paymentRepo.NewSqlitePayment(db)
+	ctx := context.TODO()
+	ar := &models.Payment{
+		PaymentID:    "payment 1",
+		Organisation: "Organisation 1",
+		Amount:       "100.21",
+		Currency:     "GBP",
+		Debtor:       debtor,
+		Beneficiary:  beneficiary,
+		Scheme:       "FPS",
+		Status:       models.StatusCreated,
+	}
+
+	id, err := a.Store(ctx, ar)
+	assert.NoError(t, err)
+	ar.ID = id
+	change := &models.StatusChange{Payment: id, From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove}
+	assert.NoError(t, a.UpdateStatus(ctx, ar, change))
+	_, err = a.Delete(ctx, id)
+	assert.NoError(t, err)
+
+	// A failed change records no event.
+	_, err = a.Delete(ctx, id)
+	assert.Error(t, err)
+
+	rows, err := db.Query(`SELECT type, payment, organisation FROM outbox_event ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var types []models.EventType
+	for rows.Next() {
+		var eventType models.EventType
+		var payment int64
+		var organisation string
+		assert.NoError(t, rows.Scan(&eventType, &payment, &organisation))
+		assert.Equal(t, id, payment)
+		assert.Equal(t, ar.Organisation, organisation)
+		types = append(types, eventType)
+	}
+	assert.Equal(t, []models.EventType{models.PaymentCreated, models.PaymentStatusChanged, models.PaymentDeleted}, types)
+}
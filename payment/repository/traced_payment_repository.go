@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	payment "github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type tracedPayment struct {
+	next   payment.Repository
+	tracer trace.Tracer
+	system string
+}
+
+// NewTracedPayment decorates a payment repository wrapping every call in a
+// client span labelled with the database system, e.g. mysql. The SQL
+// repositories record the statements they run on the span.
+func NewTracedPayment(next payment.Repository, tracer trace.Tracer, system string) payment.Repository {
+	return &tracedPayment{
+		next:   next,
+		tracer: tracer,
+		system: system,
+	}
+}
+
+func (m *tracedPayment) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return m.tracer.Start(ctx, "payment.Repository/"+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(tracing.AttrDBSystem.String(m.system)),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+func (m *tracedPayment) Fetch(ctx context.Context, cursor string, num int64) ([]*models.Payment, error) {
+	ctx, span := m.start(ctx, "Fetch")
+	res, err := m.next.Fetch(ctx, cursor, num)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) GetByID(ctx context.Context, id int64) (*models.Payment, error) {
+	ctx, span := m.start(ctx, "GetByID", attribute.Int64("payment.id", id))
+	res, err := m.next.GetByID(ctx, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) GetByPaymentID(ctx context.Context, paymentID string) (*models.Payment, error) {
+	ctx, span := m.start(ctx, "GetByPaymentID")
+	res, err := m.next.GetByPaymentID(ctx, paymentID)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) Update(ctx context.Context, p *models.Payment) (*models.Payment, error) {
+	ctx, span := m.start(ctx, "Update", attribute.Int64("payment.id", p.ID))
+	res, err := m.next.Update(ctx, p)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) Store(ctx context.Context, p *models.Payment) (int64, error) {
+	ctx, span := m.start(ctx, "Store")
+	res, err := m.next.Store(ctx, p)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) Delete(ctx context.Context, id int64) (bool, error) {
+	ctx, span := m.start(ctx, "Delete", attribute.Int64("payment.id", id))
+	res, err := m.next.Delete(ctx, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) UpdateStatus(ctx context.Context, p *models.Payment, change *models.StatusChange) error {
+	ctx, span := m.start(ctx, "UpdateStatus", attribute.Int64("payment.id", p.ID))
+	err := m.next.UpdateStatus(ctx, p, change)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (m *tracedPayment) FetchStatusHistory(ctx context.Context, id int64) ([]*models.StatusChange, error) {
+	ctx, span := m.start(ctx, "FetchStatusHistory", attribute.Int64("payment.id", id))
+	res, err := m.next.FetchStatusHistory(ctx, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (m *tracedPayment) Restore(ctx context.Context, id int64) error {
+	ctx, span := m.start(ctx, "Restore", attribute.Int64("payment.id", id))
+	err := m.next.Restore(ctx, id)
+	tracing.End(span, err)
+
+	return err
+}
+
+func (m *tracedPayment) Purge(ctx context.Context, before time.Time, num int64) (int64, error) {
+	ctx, span := m.start(ctx, "Purge")
+	res, err := m.next.Purge(ctx, before, num)
+	tracing.End(span, err)
+
+	return res, err
+}
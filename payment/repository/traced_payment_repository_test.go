@@ -0,0 +1,58 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+func TestTracedPayment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows(columns).
+		AddRow(paymentRow(1, "payment 1", "Organisation 1")...)
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\?").WillReturnRows(rows)
+	mock.ExpectQuery("SELECT (.+) FROM payment WHERE ID = \\?").WillReturnRows(sqlmock.NewRows(columns))
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	repo := paymentRepo.NewTracedPayment(paymentRepo.NewMysqlPayment(db), tp.Tracer("test"), "mysql")
+
+	_, err = repo.GetByID(context.TODO(), 1)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(context.TODO(), 2)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.Equal(t, "payment.Repository/GetByID", span.Name())
+		assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+		assert.Contains(t, span.Attributes(), tracing.AttrDBSystem.String("mysql"))
+
+		var statement string
+		for _, kv := range span.Attributes() {
+			if kv.Key == tracing.AttrDBStatement {
+				statement = kv.Value.AsString()
+			}
+		}
+		assert.Contains(t, statement, "FROM payment WHERE ID = ?")
+	}
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
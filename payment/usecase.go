@@ -14,4 +14,7 @@ type Usecase interface {
 	GetByPaymentID(ctx context.Context, name string) (*model.Payment, error)
 	Store(context.Context, *model.Payment) (*model.Payment, error)
 	Delete(ctx context.Context, id int64) (bool, error)
+	Transition(ctx context.Context, id int64, event model.StatusEvent, reason string) (*model.Payment, error)
+	StatusHistory(ctx context.Context, id int64) ([]*model.StatusChange, error)
+	Restore(ctx context.Context, id int64) (*model.Payment, error)
 }
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+)
+
+type authorizedPaymentUsecase struct {
+	next   payment.Usecase
+	policy *auth.Policy
+}
+
+// NewAuthorizedPayment decorates a payment use case checking the caller was
+// granted the permission of every call by the given policy. Without a policy
+// only reading deleted payments and restoring them are restricted, to admins.
+func NewAuthorizedPayment(next payment.Usecase, policy *auth.Policy) payment.Usecase {
+	return &authorizedPaymentUsecase{
+		next:   next,
+		policy: policy,
+	}
+}
+
+// authorizeRead checks the caller may read payments, and the deleted ones
+// too when c includes them.
+func (a *authorizedPaymentUsecase) authorizeRead(c context.Context) error {
+	if err := a.policy.Authorize(c, payment.PermissionRead); err != nil {
+		return err
+	}
+	if payment.IncludesDeleted(c) {
+		return a.policy.AuthorizeAdmin(c, payment.PermissionReadDeleted)
+	}
+
+	return nil
+}
+
+func (a *authorizedPaymentUsecase) Fetch(c context.Context, cursor string, num int64) ([]*models.Payment, string, error) {
+	if err := a.authorizeRead(c); err != nil {
+		return nil, "", err
+	}
+
+	return a.next.Fetch(c, cursor, num)
+}
+
+func (a *authorizedPaymentUsecase) GetByID(c context.Context, id int64) (*models.Payment, error) {
+	if err := a.authorizeRead(c); err != nil {
+		return nil, err
+	}
+
+	return a.next.GetByID(c, id)
+}
+
+func (a *authorizedPaymentUsecase) Update(c context.Context, p *models.Payment) (*models.Payment, error) {
+	if err := a.policy.Authorize(c, payment.PermissionUpdate); err != nil {
+		return nil, err
+	}
+
+	return a.next.Update(c, p)
+}
+
+func (a *authorizedPaymentUsecase) GetByPaymentID(c context.Context, name string) (*models.Payment, error) {
+	if err := a.authorizeRead(c); err != nil {
+		return nil, err
+	}
+
+	return a.next.GetByPaymentID(c, name)
+}
+
+func (a *authorizedPaymentUsecase) Store(c context.Context, p *models.Payment) (*models.Payment, error) {
+	if err := a.policy.Authorize(c, payment.PermissionCreate); err != nil {
+		return nil, err
+	}
+
+	return a.next.Store(c, p)
+}
+
+func (a *authorizedPaymentUsecase) Delete(c context.Context, id int64) (bool, error) {
+	if err := a.policy.Authorize(c, payment.PermissionDelete); err != nil {
+		return false, err
+	}
+
+	return a.next.Delete(c, id)
+}
+
+// Transition checks the permission of the given event, unknown events being
+// reported as such rather than as a missing permission.
+func (a *authorizedPaymentUsecase) Transition(c context.Context, id int64, event models.StatusEvent, reason string) (*models.Payment, error) {
+	if !event.IsValid() {
+		return nil, models.ErrUnknownEvent
+	}
+	if err := a.policy.Authorize(c, payment.TransitionPermission(event)); err != nil {
+		return nil, err
+	}
+
+	return a.next.Transition(c, id, event, reason)
+}
+
+func (a *authorizedPaymentUsecase) StatusHistory(c context.Context, id int64) ([]*models.StatusChange, error) {
+	if err := a.authorizeRead(c); err != nil {
+		return nil, err
+	}
+
+	return a.next.StatusHistory(c, id)
+}
+
+func (a *authorizedPaymentUsecase) Restore(c context.Context, id int64) (*models.Payment, error) {
+	if err := a.policy.AuthorizeAdmin(c, payment.PermissionRestore); err != nil {
+		return nil, err
+	}
+
+	return a.next.Restore(c, id)
+}
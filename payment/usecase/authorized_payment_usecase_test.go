@@ -0,0 +1,95 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/payment/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+)
+
+func TestAuthorizedPayment(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+	mockUCase.On("Store", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(&models.Payment{ID: 1}, nil)
+	mockUCase.On("Transition", mock.Anything, int64(1), models.EventSubmit, "").Return(&models.Payment{ID: 1}, nil)
+	mockUCase.On("GetByID", mock.Anything, int64(1)).Return(&models.Payment{ID: 1}, nil)
+	mockUCase.On("Restore", mock.Anything, int64(1)).Return(&models.Payment{ID: 1}, nil)
+
+	u := ucase.NewAuthorizedPayment(mockUCase, auth.NewPolicy(map[string][]string{
+		"operator": {"payments:read", "payments:create"},
+		"approver": {"payments:read", "payments:approve", "payments:submit"},
+		"auditor":  {"payments:read", "payments:read_deleted"},
+		"admin":    {"payments:read", "payments:restore"},
+	}))
+	as := func(role string) context.Context {
+		return auth.NewContext(context.TODO(), &auth.Principal{Roles: []string{role}})
+	}
+
+	_, err := u.Store(as("operator"), &models.Payment{})
+	assert.NoError(t, err)
+	_, err = u.Transition(as("operator"), 1, models.EventSubmit, "")
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:submit"}, err)
+	_, err = u.Transition(as("approver"), 1, models.EventSubmit, "")
+	assert.NoError(t, err)
+	_, err = u.Transition(as("approver"), 1, models.StatusEvent("launch"), "")
+	assert.Equal(t, models.ErrUnknownEvent, err)
+
+	_, err = u.GetByID(as("auditor"), 1)
+	assert.NoError(t, err)
+	_, err = u.Store(as("auditor"), &models.Payment{})
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:create"}, err)
+	_, err = u.Delete(as("auditor"), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:delete"}, err)
+
+	_, err = u.GetByID(payment.WithDeleted(as("auditor")), 1)
+	assert.NoError(t, err)
+	_, err = u.GetByID(payment.WithDeleted(as("admin")), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:read_deleted"}, err)
+	_, err = u.Restore(as("auditor"), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:restore"}, err)
+	_, err = u.Restore(as("admin"), 1)
+	assert.NoError(t, err)
+
+	mockUCase.AssertNumberOfCalls(t, "Store", 1)
+	mockUCase.AssertNumberOfCalls(t, "Transition", 1)
+	mockUCase.AssertNumberOfCalls(t, "GetByID", 2)
+	mockUCase.AssertNumberOfCalls(t, "Restore", 1)
+	mockUCase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestAuthorizedPaymentWithoutPolicy(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+	mockUCase.On("GetByID", mock.Anything, int64(1)).Return(&models.Payment{ID: 1}, nil)
+	mockUCase.On("Restore", mock.Anything, int64(1)).Return(&models.Payment{ID: 1}, nil)
+
+	u := ucase.NewAuthorizedPayment(mockUCase, nil)
+	as := func(roles ...string) context.Context {
+		return auth.NewContext(context.TODO(), &auth.Principal{Roles: roles})
+	}
+
+	_, err := u.GetByID(context.TODO(), 1)
+	assert.NoError(t, err)
+	_, err = u.GetByID(as("operator"), 1)
+	assert.NoError(t, err)
+
+	_, err = u.GetByID(payment.WithDeleted(context.TODO()), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:read_deleted"}, err)
+	_, err = u.GetByID(payment.WithDeleted(as("operator")), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:read_deleted"}, err)
+	_, err = u.Restore(as("operator"), 1)
+	assert.Equal(t, &auth.PermissionError{Permission: "payments:restore"}, err)
+
+	_, err = u.GetByID(payment.WithDeleted(as(auth.RoleAdmin)), 1)
+	assert.NoError(t, err)
+	_, err = u.Restore(as(auth.RoleAdmin), 1)
+	assert.NoError(t, err)
+
+	mockUCase.AssertNumberOfCalls(t, "GetByID", 3)
+	mockUCase.AssertNumberOfCalls(t, "Restore", 1)
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+)
+
+type instrumentedPaymentUsecase struct {
+	next  payment.Usecase
+	calls *metrics.Calls
+}
+
+// NewInstrumentedPayment decorates a payment use case recording the latency
+// and errors of every call.
+func NewInstrumentedPayment(next payment.Usecase, calls *metrics.Calls) payment.Usecase {
+	return &instrumentedPaymentUsecase{
+		next:  next,
+		calls: calls,
+	}
+}
+
+func (a *instrumentedPaymentUsecase) Fetch(c context.Context, cursor string, num int64) ([]*models.Payment, string, error) {
+	start := time.Now()
+	res, next, err := a.next.Fetch(c, cursor, num)
+	a.calls.Observe("Fetch", start, err)
+
+	return res, next, err
+}
+
+func (a *instrumentedPaymentUsecase) GetByID(c context.Context, id int64) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.GetByID(c, id)
+	a.calls.Observe("GetByID", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) Update(c context.Context, p *models.Payment) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.Update(c, p)
+	a.calls.Observe("Update", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) GetByPaymentID(c context.Context, name string) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.GetByPaymentID(c, name)
+	a.calls.Observe("GetByPaymentID", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) Store(c context.Context, p *models.Payment) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.Store(c, p)
+	a.calls.Observe("Store", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) Delete(c context.Context, id int64) (bool, error) {
+	start := time.Now()
+	res, err := a.next.Delete(c, id)
+	a.calls.Observe("Delete", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) Transition(c context.Context, id int64, event models.StatusEvent, reason string) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.Transition(c, id, event, reason)
+	a.calls.Observe("Transition", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) StatusHistory(c context.Context, id int64) ([]*models.StatusChange, error) {
+	start := time.Now()
+	res, err := a.next.StatusHistory(c, id)
+	a.calls.Observe("StatusHistory", start, err)
+
+	return res, err
+}
+
+func (a *instrumentedPaymentUsecase) Restore(c context.Context, id int64) (*models.Payment, error) {
+	start := time.Now()
+	res, err := a.next.Restore(c, id)
+	a.calls.Observe("Restore", start, err)
+
+	return res, err
+}
@@ -0,0 +1,45 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/adriacidre/go-clean-arch/metrics"
+	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+)
+
+func TestInstrumentedPayment(t *testing.T) {
+	mockUCase := new(mocks.Payment)
+	mockPayment := &models.Payment{ID: 1}
+	mockUCase.On("GetByID", mock.Anything, int64(1)).Return(mockPayment, nil)
+	mockUCase.On("GetByID", mock.Anything, int64(2)).Return(nil, models.ErrNotFound)
+
+	reg := prometheus.NewRegistry()
+	u := ucase.NewInstrumentedPayment(mockUCase, metrics.NewCalls(reg, "usecase"))
+
+	p, err := u.GetByID(context.TODO(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, mockPayment, p)
+
+	_, err = u.GetByID(context.TODO(), 2)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	expected := `
+# HELP payment_usecase_call_errors_total Method calls returning an error.
+# TYPE payment_usecase_call_errors_total counter
+payment_usecase_call_errors_total{method="GetByID"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "payment_usecase_call_errors_total"))
+	count, err := testutil.GatherAndCount(reg, "payment_usecase_call_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	mockUCase.AssertExpectations(t)
+}
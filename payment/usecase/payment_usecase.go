@@ -5,19 +5,27 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/logging"
 	"github.com/adriacidre/go-clean-arch/models"
 	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/transaction"
 )
 
 type paymentUsecase struct {
 	repo           payment.Repository
+	tx             transaction.Manager
 	contextTimeout time.Duration
 }
 
-// NewPayment constructor for the payment use case.
-func NewPayment(a payment.Repository, timeout time.Duration) payment.Usecase {
+// NewPayment constructor for the payment use case, running the calls which
+// must see a consistent repository within the transactions of tx.
+func NewPayment(a payment.Repository, tx transaction.Manager, timeout time.Duration) payment.Usecase {
 	return &paymentUsecase{
 		repo:           a,
+		tx:             tx,
 		contextTimeout: timeout,
 	}
 }
@@ -59,11 +67,20 @@ func (a *paymentUsecase) GetByID(c context.Context, id int64) (*models.Payment,
 	return res, nil
 }
 
-// Update update the given payment.
+// Update update the given payment, as long as it is still at the version
+// given, failing with ErrStaleVersion otherwise. Payments of other
+// organisations than the caller's, or moved to one, are not found.
 func (a *paymentUsecase) Update(c context.Context, ar *models.Payment) (*models.Payment, error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
 
+	if !tenant.Allows(ctx, ar.Organisation) {
+		return nil, models.ErrNotFound
+	}
+	if _, err := a.repo.GetByID(ctx, ar.ID); err != nil {
+		return nil, err
+	}
+
 	ar.UpdatedAt = time.Now()
 	return a.repo.Update(ctx, ar)
 }
@@ -80,25 +97,45 @@ func (a *paymentUsecase) GetByPaymentID(c context.Context, name string) (*models
 	return res, nil
 }
 
-// Store stores the given payment on the repository.
+// Store stores the given payment on the repository, which must belong to the
+// caller's organisation. Payment IDs are unique per organisation, deleted
+// payments included until purged: the check
+// and the insert run within a transaction, and the repository reports the
+// payments stored concurrently by others as ErrConflict too.
 func (a *paymentUsecase) Store(c context.Context, m *models.Payment) (*models.Payment, error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
 
-	if existedPayment, _ := a.GetByPaymentID(ctx, m.PaymentID); existedPayment != nil {
-		return nil, models.ErrConflict
+	if !tenant.Allows(ctx, m.Organisation) {
+		return nil, models.ErrNotFound
 	}
 
-	id, err := a.repo.Store(ctx, m)
+	var id int64
+	err := a.tx.WithinTx(ctx, func(ctx context.Context) error {
+		existedPayment, err := a.repo.GetByPaymentID(payment.WithDeleted(tenant.NewContext(ctx, m.Organisation)), m.PaymentID)
+		if err == nil && existedPayment != nil {
+			return models.ErrConflict
+		}
+		if err != nil && err != models.ErrNotFound {
+			return err
+		}
+
+		m.Status = models.StatusCreated
+		m.Version = models.InitialVersion
+		id, err = a.repo.Store(ctx, m)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	m.ID = id
+	logging.FromContext(ctx).WithField("payment", id).Info("payment created")
 	return m, nil
 }
 
-// Delete removes a payment by id on the repository.
+// Delete marks a payment as deleted on the repository, where it is kept until
+// purged.
 func (a *paymentUsecase) Delete(c context.Context, id int64) (bool, error) {
 	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
 	defer cancel()
@@ -108,5 +145,94 @@ func (a *paymentUsecase) Delete(c context.Context, id int64) (bool, error) {
 		return false, models.ErrNotFound
 	}
 
-	return a.repo.Delete(ctx, id)
+	deleted, err := a.repo.Delete(ctx, id)
+	if err == nil {
+		logging.FromContext(ctx).WithField("payment", id).Info("payment deleted")
+	}
+
+	return deleted, err
+}
+
+// Transition applies the given lifecycle event to a payment, recording the
+// status change and its reason on the payment status history.
+func (a *paymentUsecase) Transition(c context.Context, id int64, event models.StatusEvent, reason string) (*models.Payment, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	p, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := p.Status.Next(event)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	change := &models.StatusChange{
+		Payment:   p.ID,
+		From:      p.Status,
+		To:        next,
+		Event:     event,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+
+	p.UpdatedAt = now
+	if err = a.repo.UpdateStatus(ctx, p, change); err != nil {
+		return nil, err
+	}
+
+	p.Status = next
+	p.Version++
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"payment": p.ID,
+		"event":   event,
+		"from":    change.From,
+		"to":      next,
+	}).Info("payment status changed")
+	return p, nil
+}
+
+// Restore restores a deleted payment, failing with ErrConflict when it is not
+// deleted.
+func (a *paymentUsecase) Restore(c context.Context, id int64) (*models.Payment, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	var restored *models.Payment
+	err := a.tx.WithinTx(ctx, func(ctx context.Context) error {
+		p, err := a.repo.GetByID(payment.WithDeleted(ctx), id)
+		if err != nil {
+			return err
+		}
+		if !p.Deleted() {
+			return models.ErrConflict
+		}
+
+		if err = a.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		restored, err = a.repo.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithField("payment", id).Info("payment restored")
+	return restored, nil
+}
+
+// StatusHistory lists the status changes of a payment, oldest first.
+func (a *paymentUsecase) StatusHistory(c context.Context, id int64) ([]*models.StatusChange, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if _, err := a.repo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return a.repo.FetchStatusHistory(ctx, id)
 }
@@ -8,8 +8,11 @@ import (
 	"time"
 
 	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
 	"github.com/adriacidre/go-clean-arch/payment/mocks"
 	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/transaction"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -24,7 +27,7 @@ func TestFetch(t *testing.T) {
 	mockListArtilce := make([]*models.Payment, 0)
 	mockListArtilce = append(mockListArtilce, mockPayment)
 	mockPaymentRepo.On("Fetch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("int64")).Return(mockListArtilce, nil)
-	u := ucase.NewPayment(mockPaymentRepo, time.Second*2)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
 	num := int64(1)
 	cursor := "12"
 	list, nextCursor, err := u.Fetch(context.TODO(), cursor, num)
@@ -42,7 +45,7 @@ func TestFetchError(t *testing.T) {
 
 	mockPaymentRepo.On("Fetch", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("int64")).Return(nil, errors.New("Unexpexted Error"))
 
-	u := ucase.NewPayment(mockPaymentRepo, time.Second*2)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
 	num := int64(1)
 	cursor := "12"
 	list, nextCursor, err := u.Fetch(context.TODO(), cursor, num)
@@ -62,7 +65,7 @@ func TestGetByID(t *testing.T) {
 
 	mockPaymentRepo.On("GetByID", mock.Anything, mock.AnythingOfType("int64")).Return(&mockPayment, nil)
 
-	u := ucase.NewPayment(mockPaymentRepo, time.Second*2)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
 
 	a, err := u.GetByID(context.TODO(), mockPayment.ID)
 
@@ -85,16 +88,70 @@ func TestStore(t *testing.T) {
 	mockPaymentRepo.On("GetByPaymentID", mock.Anything, mock.AnythingOfType("string")).Return(nil, models.ErrNotFound)
 	mockPaymentRepo.On("Store", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(mockPayment.ID, nil)
 
-	u := ucase.NewPayment(mockPaymentRepo, time.Second*2)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
 
 	a, err := u.Store(context.TODO(), &tempMockPayment)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, a)
+	assert.Equal(t, models.StatusCreated, a.Status)
 	assert.Equal(t, mockPayment.PaymentID, tempMockPayment.PaymentID)
 	mockPaymentRepo.AssertExpectations(t)
 }
 
+func TestStoreConflict(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	existing := &models.Payment{ID: 1, PaymentID: "Hello", Organisation: "org-a"}
+	mockPaymentRepo.On("GetByPaymentID", mock.MatchedBy(func(ctx context.Context) bool {
+		return tenant.FromContext(ctx) == "org-a"
+	}), "Hello").Return(existing, nil).Once()
+	mockPaymentRepo.On("GetByPaymentID", mock.Anything, "Broken").Return(nil, errors.New("Unexpected Error")).Once()
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	_, err := u.Store(context.TODO(), &models.Payment{PaymentID: "Hello", Organisation: "org-a"})
+	assert.Equal(t, models.ErrConflict, err, "payment IDs are checked within the payment organisation")
+
+	_, err = u.Store(context.TODO(), &models.Payment{PaymentID: "Broken", Organisation: "org-a"})
+	assert.EqualError(t, err, "Unexpected Error")
+
+	mockPaymentRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+	mockPaymentRepo.AssertExpectations(t)
+}
+
+func TestStoreOtherOrganisation(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	ctx := tenant.NewContext(context.TODO(), "org-a")
+	_, err := u.Store(ctx, &models.Payment{PaymentID: "Hello", Organisation: "org-b"})
+
+	assert.Equal(t, models.ErrNotFound, err)
+	mockPaymentRepo.AssertExpectations(t)
+}
+
+func TestUpdate(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	mockPayment := &models.Payment{ID: 1, PaymentID: "Hello", Organisation: "org-a"}
+	mockPaymentRepo.On("GetByID", mock.Anything, int64(1)).Return(mockPayment, nil)
+	mockPaymentRepo.On("GetByID", mock.Anything, int64(2)).Return(nil, models.ErrNotFound)
+	mockPaymentRepo.On("Update", mock.Anything, mockPayment).Return(mockPayment, nil)
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+	ctx := tenant.NewContext(context.TODO(), "org-a")
+
+	a, err := u.Update(ctx, mockPayment)
+	assert.NoError(t, err)
+	assert.Equal(t, mockPayment, a)
+
+	_, err = u.Update(ctx, &models.Payment{ID: 2, Organisation: "org-a"})
+	assert.Equal(t, models.ErrNotFound, err, "payments of other organisations are not found")
+
+	_, err = u.Update(ctx, &models.Payment{ID: 1, Organisation: "org-b"})
+	assert.Equal(t, models.ErrNotFound, err, "payments cannot be moved to other organisations")
+	mockPaymentRepo.AssertExpectations(t)
+}
+
 func TestDelete(t *testing.T) {
 	mockPaymentRepo := new(mocks.Repository)
 	mockPayment := models.Payment{
@@ -106,7 +163,7 @@ func TestDelete(t *testing.T) {
 
 	mockPaymentRepo.On("Delete", mock.Anything, mock.AnythingOfType("int64")).Return(true, nil)
 
-	u := ucase.NewPayment(mockPaymentRepo, time.Second*2)
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
 
 	a, err := u.Delete(context.TODO(), mockPayment.ID)
 
@@ -114,3 +171,106 @@ func TestDelete(t *testing.T) {
 	assert.True(t, a)
 	mockPaymentRepo.AssertExpectations(t)
 }
+
+func TestRestore(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	deletedAt := time.Now()
+	mockPayment := models.Payment{
+		ID:        1,
+		PaymentID: "Hello",
+		Version:   2,
+		DeletedAt: &deletedAt,
+	}
+	restored := models.Payment{ID: 1, PaymentID: "Hello", Version: 3}
+
+	mockPaymentRepo.On("GetByID", mock.MatchedBy(payment.IncludesDeleted), mockPayment.ID).Return(&mockPayment, nil).Once()
+	mockPaymentRepo.On("Restore", mock.Anything, mockPayment.ID).Return(nil)
+	mockPaymentRepo.On("GetByID", mock.Anything, mockPayment.ID).Return(&restored, nil).Once()
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	a, err := u.Restore(context.TODO(), mockPayment.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &restored, a)
+	mockPaymentRepo.AssertExpectations(t)
+}
+
+func TestRestoreNotDeleted(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	mockPayment := models.Payment{
+		ID:        1,
+		PaymentID: "Hello",
+	}
+
+	mockPaymentRepo.On("GetByID", mock.Anything, mockPayment.ID).Return(&mockPayment, nil)
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	a, err := u.Restore(context.TODO(), mockPayment.ID)
+
+	assert.Equal(t, models.ErrConflict, err)
+	assert.Nil(t, a)
+	mockPaymentRepo.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func TestTransition(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	mockPayment := models.Payment{
+		ID:        1,
+		PaymentID: "Hello",
+		Status:    models.StatusCreated,
+	}
+
+	mockPaymentRepo.On("GetByID", mock.Anything, mockPayment.ID).Return(&mockPayment, nil)
+	mockPaymentRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*models.Payment"), mock.MatchedBy(func(c *models.StatusChange) bool {
+		return c.From == models.StatusCreated && c.To == models.StatusPending && c.Reason == "looks good"
+	})).Return(nil)
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	a, err := u.Transition(context.TODO(), mockPayment.ID, models.EventApprove, "looks good")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPending, a.Status)
+	mockPaymentRepo.AssertExpectations(t)
+}
+
+func TestTransitionNotAllowed(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	mockPayment := models.Payment{
+		ID:        1,
+		PaymentID: "Hello",
+		Status:    models.StatusSettled,
+	}
+
+	mockPaymentRepo.On("GetByID", mock.Anything, mockPayment.ID).Return(&mockPayment, nil)
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	a, err := u.Transition(context.TODO(), mockPayment.ID, models.EventCancel, "")
+
+	assert.Equal(t, models.ErrInvalidTransition, err)
+	assert.Nil(t, a)
+	mockPaymentRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+	mockPaymentRepo.AssertExpectations(t)
+}
+
+func TestStatusHistory(t *testing.T) {
+	mockPaymentRepo := new(mocks.Repository)
+	mockPayment := models.Payment{ID: 1, Status: models.StatusPending}
+	mockHistory := []*models.StatusChange{
+		{Payment: 1, From: models.StatusCreated, To: models.StatusPending, Event: models.EventApprove},
+	}
+
+	mockPaymentRepo.On("GetByID", mock.Anything, mockPayment.ID).Return(&mockPayment, nil)
+	mockPaymentRepo.On("FetchStatusHistory", mock.Anything, mockPayment.ID).Return(mockHistory, nil)
+
+	u := ucase.NewPayment(mockPaymentRepo, transaction.NewMemoryManager(), time.Second*2)
+
+	history, err := u.StatusHistory(context.TODO(), mockPayment.ID)
+
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	mockPaymentRepo.AssertExpectations(t)
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment"
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+type tracedPaymentUsecase struct {
+	next   payment.Usecase
+	tracer trace.Tracer
+}
+
+// NewTracedPayment decorates a payment use case wrapping every call in a span.
+func NewTracedPayment(next payment.Usecase, tracer trace.Tracer) payment.Usecase {
+	return &tracedPaymentUsecase{
+		next:   next,
+		tracer: tracer,
+	}
+}
+
+func (a *tracedPaymentUsecase) start(c context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return a.tracer.Start(c, "payment.Usecase/"+method, trace.WithAttributes(attrs...))
+}
+
+func (a *tracedPaymentUsecase) Fetch(c context.Context, cursor string, num int64) ([]*models.Payment, string, error) {
+	c, span := a.start(c, "Fetch")
+	res, next, err := a.next.Fetch(c, cursor, num)
+	tracing.End(span, err)
+
+	return res, next, err
+}
+
+func (a *tracedPaymentUsecase) GetByID(c context.Context, id int64) (*models.Payment, error) {
+	c, span := a.start(c, "GetByID", attribute.Int64("payment.id", id))
+	res, err := a.next.GetByID(c, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) Update(c context.Context, p *models.Payment) (*models.Payment, error) {
+	c, span := a.start(c, "Update", attribute.Int64("payment.id", p.ID))
+	res, err := a.next.Update(c, p)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) GetByPaymentID(c context.Context, name string) (*models.Payment, error) {
+	c, span := a.start(c, "GetByPaymentID")
+	res, err := a.next.GetByPaymentID(c, name)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) Store(c context.Context, p *models.Payment) (*models.Payment, error) {
+	c, span := a.start(c, "Store")
+	res, err := a.next.Store(c, p)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) Delete(c context.Context, id int64) (bool, error) {
+	c, span := a.start(c, "Delete", attribute.Int64("payment.id", id))
+	res, err := a.next.Delete(c, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) Transition(c context.Context, id int64, event models.StatusEvent, reason string) (*models.Payment, error) {
+	c, span := a.start(c, "Transition", attribute.Int64("payment.id", id), attribute.String("payment.event", string(event)))
+	res, err := a.next.Transition(c, id, event, reason)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) StatusHistory(c context.Context, id int64) ([]*models.StatusChange, error) {
+	c, span := a.start(c, "StatusHistory", attribute.Int64("payment.id", id))
+	res, err := a.next.StatusHistory(c, id)
+	tracing.End(span, err)
+
+	return res, err
+}
+
+func (a *tracedPaymentUsecase) Restore(c context.Context, id int64) (*models.Payment, error) {
+	c, span := a.start(c, "Restore", attribute.Int64("payment.id", id))
+	res, err := a.next.Restore(c, id)
+	tracing.End(span, err)
+
+	return res, err
+}
@@ -0,0 +1,46 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	models "github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/payment/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/payment/usecase"
+)
+
+func TestTracedPayment(t *testing.T) {
+	var inner trace.SpanContext
+	mockUCase := new(mocks.Payment)
+	mockUCase.On("GetByID", mock.Anything, int64(1)).Return(&models.Payment{ID: 1}, nil).
+		Run(func(args mock.Arguments) {
+			inner = trace.SpanContextFromContext(args.Get(0).(context.Context))
+		})
+	mockUCase.On("GetByID", mock.Anything, int64(2)).Return(nil, models.ErrNotFound)
+
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+	u := ucase.NewTracedPayment(mockUCase, tp.Tracer("test"))
+
+	_, err := u.GetByID(context.TODO(), 1)
+	assert.NoError(t, err)
+	_, err = u.GetByID(context.TODO(), 2)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	spans := sr.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "payment.Usecase/GetByID", spans[0].Name())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), inner.SpanID(), "the call must run within the span")
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, models.ErrNotFound.Error(), spans[1].Status().Description)
+	mockUCase.AssertExpectations(t)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval time between the removals of the buckets full again, which
+// behave like missing ones.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+type memoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore in-memory token buckets constructor, limiting clients per
+// instance.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token out of the bucket of key, created full on first use.
+func (s *memoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(limit.Requests)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * limit.rate()
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+		b.updated = now
+	}
+
+	var res Result
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = limit.duration(1 - b.tokens)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = limit.duration(capacity - b.tokens)
+	b.full = now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep removes the buckets full again, at most once every sweepInterval.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
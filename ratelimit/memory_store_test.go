@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStore().(*memoryStore)
+	s.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+
+	for remaining := 1; remaining >= 0; remaining-- {
+		res, err := s.Take(context.TODO(), "client-a", limit)
+		assert.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, remaining, res.Remaining)
+	}
+
+	res, err := s.Take(context.TODO(), "client-a", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+	assert.Equal(t, 2*time.Second, res.Reset)
+
+	res, _ = s.Take(context.TODO(), "client-b", limit)
+	assert.True(t, res.Allowed, "every client has its own bucket")
+
+	now = now.Add(time.Second)
+	res, _ = s.Take(context.TODO(), "client-a", limit)
+	assert.True(t, res.Allowed, "a token is added every second")
+	res, _ = s.Take(context.TODO(), "client-a", limit)
+	assert.False(t, res.Allowed)
+
+	now = now.Add(time.Hour)
+	res, _ = s.Take(context.TODO(), "client-a", limit)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 1, res.Remaining, "buckets hold at most Requests tokens")
+	assert.Len(t, s.buckets, 1, "full buckets are swept")
+}
@@ -0,0 +1,82 @@
+// Package ratelimit limits the rate of requests of each client with token
+// buckets kept on a pluggable store.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit token bucket holding up to Requests tokens, refilled at a rate of
+// Requests tokens every Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// IsZero reports whether the limit is unset, leaving requests unlimited.
+func (l Limit) IsZero() bool {
+	return l.Requests == 0
+}
+
+// String formats the limit the way ParseLimit reads it.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// rate tokens added to the bucket every second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// duration time taken to refill the given amount of tokens.
+func (l Limit) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate() * float64(time.Second))
+}
+
+// ParseLimit parses a limit written as requests/period, e.g. 10/1m, the
+// period defaulting to one unit when only the unit is given, as in 10/s.
+func ParseLimit(s string) (Limit, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "/", 2)
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("rate limit %q is not written as requests/period, e.g. 10/1m", s)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q requests must be a positive integer", s)
+	}
+
+	period := strings.TrimSpace(parts[1])
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q period must be a positive duration, e.g. 1m", s)
+	}
+
+	return Limit{Requests: requests, Period: d}, nil
+}
+
+// Result outcome of taking a token out of a bucket.
+type Result struct {
+	// Allowed whether a token was available, allowing the request.
+	Allowed bool
+	// Remaining tokens left in the bucket.
+	Remaining int
+	// RetryAfter time until a token is available, when not allowed.
+	RetryAfter time.Duration
+	// Reset time until the bucket is full again.
+	Reset time.Duration
+}
+
+// Store keeps the token bucket of every client. A store shared by every
+// instance, e.g. backed by Redis, limits clients across instances.
+type Store interface {
+	// Take takes a token out of the bucket of key, created full on first use.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,30 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/ratelimit"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := map[string]ratelimit.Limit{
+		"10/1m":    {Requests: 10, Period: time.Minute},
+		" 5 / s ":  {Requests: 5, Period: time.Second},
+		"100/h":    {Requests: 100, Period: time.Hour},
+		"3/500ms":  {Requests: 3, Period: 500 * time.Millisecond},
+		"1000/24h": {Requests: 1000, Period: 24 * time.Hour},
+	}
+	for s, expected := range tests {
+		l, err := ratelimit.ParseLimit(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, expected, l, s)
+	}
+
+	for _, s := range []string{"", "10", "0/1m", "-1/1m", "ten/1m", "10/forever", "10/-1m"} {
+		_, err := ratelimit.ParseLimit(s)
+		assert.Error(t, err, s)
+	}
+}
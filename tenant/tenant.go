@@ -0,0 +1,32 @@
+// Package tenant restricts calls to the organisation of the caller, every
+// payment outside of it being invisible.
+package tenant
+
+import (
+	"context"
+)
+
+type scopeKey struct{}
+
+// NewContext returns a copy of ctx restricted to the given organisation, an
+// empty one lifting any restriction.
+func NewContext(ctx context.Context, organisation string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, organisation)
+}
+
+// FromContext returns the organisation ctx is restricted to, empty when calls
+// may operate across every organisation.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	organisation, _ := ctx.Value(scopeKey{}).(string)
+
+	return organisation
+}
+
+// Allows reports whether ctx may operate on payments of the given organisation.
+func Allows(ctx context.Context, organisation string) bool {
+	scope := FromContext(ctx)
+	return scope == "" || scope == organisation
+}
@@ -0,0 +1,38 @@
+package testutil
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	// Registers the sqlite3 driver OpenSqlite opens databases with.
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/adriacidre/go-clean-arch/migration"
+)
+
+// OpenSqlite opens the SQLite database at dsn, ":memory:" for an in-memory
+// one, and migrates it up, closing it when the test finishes. A single
+// connection is kept, as in-memory databases are not shared between them.
+func OpenSqlite(t *testing.T, dsn string) *sql.DB {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	Migrate(t, db, "sqlite3")
+
+	return db
+}
+
+// Migrate applies every pending migration of the driver to the database.
+func Migrate(t *testing.T, db *sql.DB, driver string) {
+	m, err := migration.NewMigrator(db, driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
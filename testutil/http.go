@@ -0,0 +1,67 @@
+// Package testutil holds the fixtures shared by the tests of several
+// packages, such as signed bearer tokens and migrated databases. Only tests
+// import it.
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+)
+
+// Secret HS256 secret the tokens of Bearer are signed with.
+const Secret = "0123456789abcdef0123456789abcdef"
+
+// Verifier builds a verifier of the tokens signed by Bearer.
+func Verifier() *auth.Verifier {
+	v := auth.NewVerifier("", "")
+	v.SetSecret([]byte(Secret))
+
+	return v
+}
+
+// Bearer signs a token for a user of the given organisation and roles,
+// returning the Authorization header carrying it.
+func Bearer(t *testing.T, organisation string, roles ...string) map[string]string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "user-" + organisation,
+		"org":   organisation,
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(Secret))
+	require.NoError(t, err)
+
+	return map[string]string{echo.HeaderAuthorization: "Bearer " + token}
+}
+
+// DoJSON sends the given request body and headers, decoding the JSON
+// response into out.
+func DoJSON(t *testing.T, method, url, body string, headers map[string]string, out interface{}) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	if body != "" {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	if out != nil {
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(out))
+	}
+
+	return res
+}
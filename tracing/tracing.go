@@ -0,0 +1,108 @@
+// Package tracing sets up OpenTelemetry tracing, exporting spans to stdout or
+// an OTLP endpoint and propagating W3C trace context.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporters spans can be sent to.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Span attribute keys.
+const (
+	AttrDBSystem       = attribute.Key("db.system")
+	AttrDBStatement    = attribute.Key("db.statement")
+	AttrHTTPMethod     = attribute.Key("http.method")
+	AttrHTTPRoute      = attribute.Key("http.route")
+	AttrHTTPStatusCode = attribute.Key("http.status_code")
+)
+
+// Provider tracer provider along with the function flushing its pending spans.
+type Provider struct {
+	trace.TracerProvider
+	shutdown func(ctx context.Context) error
+}
+
+// Shutdown exports the pending spans and stops the provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.shutdown == nil {
+		return nil
+	}
+
+	return p.shutdown(ctx)
+}
+
+// NewProvider builds the tracer provider of the given exporter: none, stdout
+// writing to out, or otlp sending spans over HTTP to endpoint, e.g.
+// http://localhost:4318. It also installs the W3C trace context propagator.
+func NewProvider(ctx context.Context, exporter, endpoint, serviceName, version string, out io.Writer) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case "", ExporterNone:
+		return &Provider{TracerProvider: noop.NewTracerProvider()}, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(out))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(endpoint))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+		attribute.String("service.version", version),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+
+	return &Provider{TracerProvider: tp, shutdown: tp.Shutdown}, nil
+}
+
+// End records the error, if any, on the span and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Statement records a SQL statement run by the current span, if any. Spans
+// running several statements keep the last one as attribute and every one of
+// them as a "db.query" event.
+func Statement(ctx context.Context, query string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	statement := AttrDBStatement.String(query)
+	span.SetAttributes(statement)
+	span.AddEvent("db.query", trace.WithAttributes(statement))
+}
@@ -0,0 +1,119 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/adriacidre/go-clean-arch/tracing"
+)
+
+// collector in-process OTLP/HTTP collector handing over the spans it receives.
+func collector(t *testing.T) (*httptest.Server, <-chan *coltrace.ExportTraceServiceRequest) {
+	received := make(chan *coltrace.ExportTraceServiceRequest, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req := new(coltrace.ExportTraceServiceRequest)
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received <- req
+
+		res, _ := proto.Marshal(&coltrace.ExportTraceServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Write(res)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, received
+}
+
+func TestNewProviderOTLP(t *testing.T) {
+	srv, received := collector(t)
+
+	tp, err := tracing.NewProvider(context.Background(), tracing.ExporterOTLP, srv.URL, "payment", "test", nil)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "GET /payment/:id")
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	req := <-received
+	require.Len(t, req.ResourceSpans, 1)
+	rs := req.ResourceSpans[0]
+
+	attrs := make(map[string]string)
+	for _, kv := range rs.Resource.Attributes {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, "payment", attrs["service.name"])
+	assert.Equal(t, "test", attrs["service.version"])
+
+	require.Len(t, rs.ScopeSpans, 1)
+	require.Len(t, rs.ScopeSpans[0].Spans, 1)
+	assert.Equal(t, "GET /payment/:id", rs.ScopeSpans[0].Spans[0].Name)
+}
+
+func TestNewProviderStdout(t *testing.T) {
+	var out bytes.Buffer
+	tp, err := tracing.NewProvider(context.Background(), tracing.ExporterStdout, "", "payment", "test", &out)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "stdout-span")
+	span.End()
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	assert.Contains(t, out.String(), "stdout-span")
+}
+
+func TestNewProviderNone(t *testing.T) {
+	tp, err := tracing.NewProvider(context.Background(), tracing.ExporterNone, "", "payment", "test", nil)
+	require.NoError(t, err)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "ignored")
+	assert.False(t, span.IsRecording())
+	assert.NoError(t, tp.Shutdown(context.Background()))
+}
+
+func TestNewProviderUnknownExporter(t *testing.T) {
+	_, err := tracing.NewProvider(context.Background(), "zipkin", "", "payment", "test", nil)
+	assert.Error(t, err)
+}
+
+func TestEndAndStatement(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr))
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "query")
+	tracing.Statement(ctx, "SELECT 1")
+	tracing.Statement(ctx, "SELECT 2")
+	tracing.End(span, errors.New("boom"))
+
+	spans := sr.Ended()
+	require.Len(t, spans, 1)
+	assert.Contains(t, spans[0].Attributes(), tracing.AttrDBStatement.String("SELECT 2"))
+	assert.Len(t, spans[0].Events(), 3, "two statements and the recorded error")
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+}
@@ -0,0 +1,31 @@
+package transaction
+
+import (
+	"context"
+	"sync"
+)
+
+type memoryKey struct{}
+
+type memoryManager struct {
+	mu sync.Mutex
+}
+
+// NewMemoryManager manager for the in-memory repositories, running one unit
+// of work at a time. Nothing is rolled back when one fails, so the in-memory
+// repositories must check their own invariants, such as unique payment IDs.
+func NewMemoryManager() Manager {
+	return &memoryManager{}
+}
+
+// WithinTx runs fn once no other unit of work is running.
+func (m *memoryManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(memoryKey{}) == m {
+		return fn(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return fn(context.WithValue(ctx, memoryKey{}, m))
+}
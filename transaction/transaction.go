@@ -0,0 +1,123 @@
+// Package transaction runs several repository calls as a single unit of work,
+// the repositories joining the transaction found on the context.
+package transaction
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Manager runs units of work.
+type Manager interface {
+	// WithinTx runs fn within a transaction, committed when fn succeeds and
+	// rolled back otherwise. Calls made with the context given to fn join the
+	// transaction, and so do nested WithinTx calls.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// DB runs statements, either on the database or within a transaction.
+type DB interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// NewContext returns a copy of ctx carrying the given transaction.
+func NewContext(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// FromContext returns the transaction ctx carries, if any.
+func FromContext(ctx context.Context) (*sql.Tx, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+
+	return tx, ok
+}
+
+// Conn returns the transaction ctx carries, if any, or db otherwise.
+func Conn(ctx context.Context, db *sql.DB) DB {
+	if tx, ok := FromContext(ctx); ok {
+		return tx
+	}
+
+	return db
+}
+
+// Tx transaction begun by Begin. Joined transactions are left for whoever
+// began them to commit or roll back.
+type Tx struct {
+	*sql.Tx
+	joined bool
+}
+
+// Begin joins the transaction ctx carries, if any, or begins one on db.
+func Begin(ctx context.Context, db *sql.DB) (*Tx, error) {
+	if tx, ok := FromContext(ctx); ok {
+		return &Tx{Tx: tx, joined: true}, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx}, nil
+}
+
+// Commit commits the transaction, unless joined.
+func (t *Tx) Commit() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+// Rollback rolls the transaction back, unless joined. The error then making
+// the unit of work fail rolls the joined transaction back instead.
+func (t *Tx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
+
+type sqlManager struct {
+	db *sql.DB
+}
+
+// NewSQLManager manager running units of work within database transactions.
+func NewSQLManager(db *sql.DB) Manager {
+	return &sqlManager{db}
+}
+
+// WithinTx runs fn within a database transaction.
+func (m *sqlManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := FromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(NewContext(ctx, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package transaction_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/transaction"
+)
+
+func openSqlite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	_, err = db.Exec(`CREATE TABLE item (name varchar(16) NOT NULL UNIQUE)`)
+	require.NoError(t, err)
+
+	return db
+}
+
+func count(t *testing.T, db *sql.DB) int {
+	var n int
+	require.NoError(t, db.QueryRow(`SELECT count(*) FROM item`).Scan(&n))
+
+	return n
+}
+
+// insert inserts an item joining the transaction ctx carries, if any.
+func insert(ctx context.Context, db *sql.DB, name string) error {
+	tx, err := transaction.Begin(ctx, db)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `INSERT INTO item (name) VALUES (?)`, name); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func TestWithinTxCommits(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	m := transaction.NewSQLManager(db)
+
+	err := m.WithinTx(context.TODO(), func(ctx context.Context) error {
+		_, ok := transaction.FromContext(ctx)
+		assert.True(t, ok)
+		if err := insert(ctx, db, "a"); err != nil {
+			return err
+		}
+
+		return m.WithinTx(ctx, func(ctx context.Context) error {
+			return insert(ctx, db, "b")
+		})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count(t, db))
+}
+
+func TestWithinTxRollsBack(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+	m := transaction.NewSQLManager(db)
+
+	failure := errors.New("failure")
+	err := m.WithinTx(context.TODO(), func(ctx context.Context) error {
+		assert.NoError(t, insert(ctx, db, "a"))
+		assert.Error(t, insert(ctx, db, "a"), "a joined transaction is not rolled back by the failing call")
+
+		return failure
+	})
+	assert.Equal(t, failure, err)
+	assert.Equal(t, 0, count(t, db))
+
+	assert.Panics(t, func() {
+		_ = m.WithinTx(context.TODO(), func(ctx context.Context) error {
+			assert.NoError(t, insert(ctx, db, "a"))
+			panic("boom")
+		})
+	})
+	assert.Equal(t, 0, count(t, db))
+}
+
+func TestConn(t *testing.T) {
+	db := openSqlite(t)
+	defer db.Close()
+
+	assert.Equal(t, db, transaction.Conn(context.TODO(), db))
+
+	err := transaction.NewSQLManager(db).WithinTx(context.TODO(), func(ctx context.Context) error {
+		tx, _ := transaction.FromContext(ctx)
+		assert.Equal(t, tx, transaction.Conn(ctx, db))
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestMemoryManagerSerializes(t *testing.T) {
+	m := transaction.NewMemoryManager()
+	running := 0
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = m.WithinTx(context.TODO(), func(ctx context.Context) error {
+				mu.Lock()
+				running++
+				assert.Equal(t, 1, running)
+				mu.Unlock()
+
+				err := m.WithinTx(ctx, func(ctx context.Context) error { return nil })
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// ResponseError response struct representing an error.
+type ResponseError struct {
+	Message string `json:"message"`
+}
+
+// CreatedWebhook response struct representing a created webhook, the only
+// one carrying the secret its requests are signed with.
+type CreatedWebhook struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookHandler http handler for webhook use cases.
+type WebhookHandler struct {
+	Usecase webhook.Usecase
+}
+
+// NewWebhookHTTPHandler webhook http handler constructor, running the given
+// middleware, such as authentication, on every webhook route.
+func NewWebhookHTTPHandler(e *echo.Echo, us webhook.Usecase, m ...echo.MiddlewareFunc) {
+	handler := &WebhookHandler{
+		Usecase: us,
+	}
+	e.GET("/webhook", handler.FetchWebhook, m...)
+	e.POST("/webhook", handler.Store, m...)
+	e.DELETE("/webhook/:id", handler.Delete, m...)
+	e.GET("/webhook/:id/deliveries", handler.Deliveries, m...)
+	e.POST("/webhook/:id/deliveries/:delivery/redeliver", handler.Redeliver, m...)
+}
+
+// FetchWebhook handles listing the webhooks of the caller's organisation.
+func (h *WebhookHandler) FetchWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	list, err := h.Usecase.Fetch(ctx)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+// Store handles the webhook creation requests. Webhooks are created for the
+// caller's organisation unless the request names one.
+func (h *WebhookHandler) Store(c echo.Context) error {
+	var w models.Webhook
+
+	if err := c.Bind(&w); err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if w.Organisation == "" {
+		w.Organisation = tenant.FromContext(ctx)
+	}
+	if err := w.Validate(); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	secret, err := h.Usecase.Create(ctx, &w)
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusCreated, CreatedWebhook{Webhook: &w, Secret: secret})
+}
+
+// Delete handles webhook deletion requests.
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err = h.Usecase.Delete(ctx, id); err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Deliveries handles listing the latest deliveries of a webhook, up to the
+// num query parameter.
+func (h *WebhookHandler) Deliveries(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	id := int64(idP)
+	num, _ := strconv.Atoi(c.QueryParam("num"))
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	list, err := h.Usecase.Deliveries(ctx, id, int64(num))
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, list)
+}
+
+// Redeliver handles the requests to attempt a delivery again.
+func (h *WebhookHandler) Redeliver(c echo.Context) error {
+	idP, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input ID is not valid"})
+	}
+	deliveryP, err := strconv.Atoi(c.Param("delivery"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ResponseError{Message: "Input delivery is not valid"})
+	}
+
+	ctx := c.Request().Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	d, err := h.Usecase.Redeliver(ctx, int64(idP), int64(deliveryP))
+	if err != nil {
+		return c.JSON(getStatusCode(ctx, err), ResponseError{Message: err.Error()})
+	}
+
+	return c.JSON(http.StatusAccepted, d)
+}
+
+// getStatusCode based on the usecase output error calculates the http response
+// status code.
+func getStatusCode(ctx context.Context, err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+
+	status := http.StatusInternalServerError
+	if _, ok := err.(*auth.PermissionError); ok {
+		status = http.StatusForbidden
+	}
+	switch err {
+	case models.ErrNotFound:
+		status = http.StatusNotFound
+	case models.ErrWebhookURLNotAllowed:
+		status = http.StatusBadRequest
+	}
+
+	// Client errors are expected, only server ones are logged as errors.
+	if status >= http.StatusInternalServerError {
+		logging.FromContext(ctx).Error(err)
+	} else {
+		logging.FromContext(ctx).Debug(err)
+	}
+
+	return status
+}
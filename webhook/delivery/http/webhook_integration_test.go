@@ -0,0 +1,145 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/middleware"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox/relay"
+	outboxRepo "github.com/adriacidre/go-clean-arch/outbox/repository"
+	paymentRepo "github.com/adriacidre/go-clean-arch/payment/repository"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/testutil"
+	"github.com/adriacidre/go-clean-arch/webhook"
+	webhookHttp "github.com/adriacidre/go-clean-arch/webhook/delivery/http"
+	"github.com/adriacidre/go-clean-arch/webhook/dispatcher"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+	webhookUcase "github.com/adriacidre/go-clean-arch/webhook/usecase"
+)
+
+// receiver records the bodies and signatures POSTed to a webhook, failing
+// while fail is set.
+type receiver struct {
+	mu         sync.Mutex
+	fail       bool
+	bodies     [][]byte
+	signatures []string
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, body)
+	r.signatures = append(r.signatures, req.Header.Get(webhook.SignatureHeader))
+	if r.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func TestWebhookLifecycle(t *testing.T) {
+	rec := &receiver{fail: true}
+	receiverSrv := httptest.NewServer(rec)
+	defer receiverSrv.Close()
+
+	m := middleware.InitMiddleware()
+	m.JWTVerifier = testutil.Verifier()
+	hooks := webhookRepo.NewMemoryWebhook()
+	// The receiver listens on the loopback, only reachable once allowed.
+	guard, err := webhook.NewGuard([]string{"127.0.0.0/8"})
+	require.NoError(t, err)
+	e := echo.New()
+	webhookHttp.NewWebhookHTTPHandler(e, webhookUcase.NewWebhook(hooks, guard, time.Second*2), m.JWT, m.Tenant,
+		m.RequireScope(models.ScopeWebhooksRead, models.ScopeWebhooksWrite))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	orgA, orgB := testutil.Bearer(t, "org-a"), testutil.Bearer(t, "org-b")
+
+	res := testutil.DoJSON(t, echo.POST, srv.URL+"/webhook", `{"url":"not a url","event_types":["PaymentCreated"]}`, orgA, nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/webhook", `{"url":"https://example.com","event_types":["PaymentRefunded"]}`, orgA, nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/webhook", `{"url":"http://169.254.169.254/latest/meta-data","event_types":["PaymentCreated"]}`, orgA, nil)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	var created webhookHttp.CreatedWebhook
+	body := `{"url":"` + receiverSrv.URL + `","event_types":["PaymentCreated"]}`
+	res = testutil.DoJSON(t, echo.POST, srv.URL+"/webhook", body, orgA, &created)
+	require.Equal(t, http.StatusCreated, res.StatusCode)
+	assert.Equal(t, "org-a", created.Organisation)
+	assert.NotEmpty(t, created.Secret)
+
+	var list []models.Webhook
+	res = testutil.DoJSON(t, echo.GET, srv.URL+"/webhook", "", orgB, &list)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Len(t, list, 0)
+
+	// A payment created by org-a is relayed from the outbox to its webhook.
+	events := outboxRepo.NewMemoryOutbox()
+	_, err = paymentRepo.NewMemoryPayment(events).Store(tenant.NewContext(context.TODO(), "org-a"),
+		&models.Payment{Organisation: "org-a"})
+	require.NoError(t, err)
+	_, err = relay.NewRelay(events, dispatcher.NewPublisher(hooks), time.Second, 10).RelayPending(context.TODO())
+	require.NoError(t, err)
+
+	d := dispatcher.NewDispatcher(hooks, guard.Client(time.Second), time.Second, 10, 1)
+	succeeded, err := d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, succeeded)
+
+	url := srv.URL + "/webhook/" + strconv.FormatInt(created.ID, 10)
+	var deliveries []models.WebhookDelivery
+	res = testutil.DoJSON(t, echo.GET, url+"/deliveries", "", orgA, &deliveries)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, models.DeliveryDead, deliveries[0].Status)
+	assert.Equal(t, http.StatusServiceUnavailable, deliveries[0].ResponseStatus)
+
+	res = testutil.DoJSON(t, echo.GET, url+"/deliveries", "", orgB, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	redeliver := url + "/deliveries/" + strconv.FormatInt(deliveries[0].ID, 10) + "/redeliver"
+	res = testutil.DoJSON(t, echo.POST, redeliver, "", orgB, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	var redelivered models.WebhookDelivery
+	res = testutil.DoJSON(t, echo.POST, redeliver, "", orgA, &redelivered)
+	require.Equal(t, http.StatusAccepted, res.StatusCode)
+	assert.Equal(t, models.DeliveryPending, redelivered.Status)
+
+	rec.mu.Lock()
+	rec.fail = false
+	rec.mu.Unlock()
+	succeeded, err = d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+
+	rec.mu.Lock()
+	require.Len(t, rec.bodies, 2)
+	assert.NoError(t, webhook.Verify(created.Secret, rec.signatures[1], rec.bodies[1], time.Minute, time.Now()))
+	var event models.Event
+	assert.NoError(t, json.Unmarshal(rec.bodies[1], &event))
+	assert.Equal(t, models.PaymentCreated, event.Type)
+	assert.Equal(t, "org-a", event.Organisation)
+	rec.mu.Unlock()
+
+	res = testutil.DoJSON(t, echo.DELETE, url, "", orgB, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	res = testutil.DoJSON(t, echo.DELETE, url, "", orgA, nil)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	res = testutil.DoJSON(t, echo.GET, url+"/deliveries", "", orgA, nil)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,228 @@
+// Package dispatcher delivers the payment events to the webhooks subscribing
+// to them, at least once.
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// Shortest and longest delays between the attempts of a delivery.
+const (
+	MinBackoff = 10 * time.Second
+	MaxBackoff = time.Hour
+)
+
+// maxErrorLength longest delivery error recorded.
+const maxErrorLength = 1024
+
+// maxResponseLength longest response body read from a webhook, so that
+// connections can be reused.
+const maxResponseLength = 4096
+
+// Dispatcher polls for due deliveries and POSTs their events, signed with the
+// webhook secret, retrying the failed ones with an exponential backoff.
+// Deliveries failing maxAttempts times are left dead until redelivered. A
+// delivery made but not yet recorded when the dispatcher stops is made again,
+// so receivers should discard the events ids already seen.
+type Dispatcher struct {
+	repo        webhook.Repository
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int64
+	maxAttempts int
+	now         func() time.Time
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewDispatcher dispatcher constructor, polling every interval for up to
+// batchSize due deliveries and making them with the given client.
+func NewDispatcher(repo webhook.Repository, client *http.Client, interval time.Duration, batchSize int64, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		client:      client,
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+}
+
+// Start dispatches the due deliveries in the background until Shutdown.
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	d.done = make(chan struct{})
+	go func() {
+		defer close(d.done)
+		d.Run(ctx)
+	}()
+}
+
+// Shutdown stops the dispatcher started by Start, waiting for the batch being
+// delivered until ctx is done.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	if d.cancel == nil {
+		return nil
+	}
+
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run dispatches the due deliveries every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchPending(ctx); err != nil && ctx.Err() == nil {
+			logging.FromContext(ctx).WithError(err).Error("dispatching webhook deliveries")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchPending makes a batch of the deliveries due, returning how many
+// succeeded. Failed deliveries are rescheduled rather than reported as an
+// error.
+func (d *Dispatcher) DispatchPending(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.FetchDueDeliveries(ctx, d.now(), d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	hooks := make(map[int64]*models.Webhook)
+	succeeded := 0
+	for _, delivery := range deliveries {
+		if err = ctx.Err(); err != nil {
+			return succeeded, err
+		}
+
+		w, ok := hooks[delivery.Webhook]
+		if !ok {
+			w, err = d.repo.GetByID(ctx, delivery.Webhook)
+			if err != nil && err != models.ErrNotFound {
+				return succeeded, err
+			}
+			hooks[delivery.Webhook] = w
+		}
+
+		status, derr := d.deliver(ctx, w, delivery)
+		d.record(delivery, status, derr)
+		if derr != nil {
+			logging.FromContext(ctx).WithFields(logrus.Fields{
+				"webhook":  delivery.Webhook,
+				"delivery": delivery.ID,
+				"event":    delivery.Event,
+				"attempts": delivery.Attempts,
+				"status":   delivery.Status,
+			}).WithError(derr).Warn("webhook delivery failed")
+		}
+
+		if err = d.repo.UpdateDelivery(ctx, delivery); err != nil {
+			return succeeded, err
+		}
+		if derr == nil {
+			succeeded++
+		}
+	}
+
+	return succeeded, nil
+}
+
+// deliver POSTs the event of the given delivery to the webhook, returning the
+// response status, if any.
+func (d *Dispatcher) deliver(ctx context.Context, w *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	if w == nil {
+		return 0, models.ErrNotFound
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(w.Secret, d.now(), delivery.Payload))
+	req.Header.Set(webhook.EventHeader, string(delivery.EventType))
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(res.Body, maxResponseLength))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// record records on the delivery the outcome of an attempt, scheduling the
+// next one or leaving it dead when failed.
+func (d *Dispatcher) record(delivery *models.WebhookDelivery, status int, err error) {
+	now := d.now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.UpdatedAt = now
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = models.DeliveryDead
+	default:
+		delivery.NextAttemptAt = now.Add(backoff(delivery.Attempts))
+	}
+
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > maxErrorLength {
+			msg = msg[:maxErrorLength]
+		}
+		delivery.LastError = msg
+	}
+}
+
+// backoff delay before the next attempt of a delivery which failed the given
+// number of times, doubling on every failure.
+func backoff(attempts int) time.Duration {
+	d := MinBackoff
+	for i := 1; i < attempts && d < MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > MaxBackoff {
+		d = MaxBackoff
+	}
+
+	return d
+}
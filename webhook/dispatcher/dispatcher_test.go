@@ -0,0 +1,218 @@
+package dispatcher_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+	"github.com/adriacidre/go-clean-arch/webhook/dispatcher"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+// receiver records the requests made to a webhook, answering them with the
+// queued statuses and 200 once exhausted.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := ioutil.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// publish stores a webhook pointing at url and publishes a payment created
+// event to it, returning the webhook.
+func publish(t *testing.T, repo webhook.Repository, url string) *models.Webhook {
+	ctx := tenant.NewContext(context.TODO(), "org-a")
+	w := &models.Webhook{Organisation: "org-a", URL: url, EventTypes: []string{"PaymentCreated"}, Secret: "whsec_a"}
+	id, err := repo.Store(ctx, w)
+	require.NoError(t, err)
+	w.ID = id
+
+	e, err := models.NewPaymentEvent(models.PaymentCreated, &models.Payment{ID: 12, Organisation: "org-a"}, nil)
+	require.NoError(t, err)
+	e.ID = 7
+	require.NoError(t, dispatcher.NewPublisher(repo).Publish(context.TODO(), e))
+
+	return w
+}
+
+func TestPublishSchedulesSubscribedWebhooks(t *testing.T) {
+	repo := webhookRepo.NewMemoryWebhook()
+	orgA := tenant.NewContext(context.TODO(), "org-a")
+	subscribed, err := repo.Store(orgA, &models.Webhook{Organisation: "org-a", EventTypes: []string{"PaymentCreated"}})
+	require.NoError(t, err)
+	unsubscribed, err := repo.Store(orgA, &models.Webhook{Organisation: "org-a", EventTypes: []string{"PaymentDeleted"}})
+	require.NoError(t, err)
+	other, err := repo.Store(context.TODO(), &models.Webhook{Organisation: "org-b", EventTypes: []string{"PaymentCreated"}})
+	require.NoError(t, err)
+
+	p := dispatcher.NewPublisher(repo)
+	e := &models.Event{ID: 7, Type: models.PaymentCreated, Payment: 12, Organisation: "org-a"}
+	assert.NoError(t, p.Publish(context.TODO(), e))
+	assert.NoError(t, p.Publish(context.TODO(), e))
+
+	deliveries, err := repo.FetchDeliveries(orgA, subscribed, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 1, "publishing again schedules no further deliveries") {
+		assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+		assert.JSONEq(t, `{"id":7,"type":"PaymentCreated","payment":12,"organisation_id":"org-a","payload":null,
+			"created_at":"0001-01-01T00:00:00Z"}`, string(deliveries[0].Payload))
+	}
+	for _, id := range []int64{unsubscribed, other} {
+		deliveries, err = repo.FetchDeliveries(context.TODO(), id, 10)
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 0)
+	}
+}
+
+func TestDispatchPendingSignsRequests(t *testing.T) {
+	rec := &receiver{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	repo := webhookRepo.NewMemoryWebhook()
+	w := publish(t, repo, server.URL)
+
+	d := dispatcher.NewDispatcher(repo, server.Client(), time.Second, 10, 3)
+
+	succeeded, err := d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+
+	if assert.Len(t, rec.requests, 1) {
+		req := rec.requests[0]
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, "PaymentCreated", req.Header.Get(webhook.EventHeader))
+		assert.NoError(t, webhook.Verify(w.Secret, req.Header.Get(webhook.SignatureHeader), rec.bodies[0], time.Minute, time.Now()))
+		assert.Equal(t, models.ErrInvalidSignature,
+			webhook.Verify("whsec_b", req.Header.Get(webhook.SignatureHeader), rec.bodies[0], time.Minute, time.Now()))
+
+		id, err := strconv.ParseInt(req.Header.Get(webhook.DeliveryHeader), 10, 64)
+		assert.NoError(t, err)
+		delivery, err := repo.GetDelivery(context.TODO(), id)
+		assert.NoError(t, err)
+		assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	}
+
+	succeeded, err = d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, succeeded)
+	assert.Len(t, rec.requests, 1)
+}
+
+func TestDispatchPendingRetriesWithBackoff(t *testing.T) {
+	rec := &receiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	repo := webhookRepo.NewMemoryWebhook()
+	w := publish(t, repo, server.URL)
+
+	d := dispatcher.NewDispatcher(repo, server.Client(), time.Second, 10, 3)
+
+	succeeded, err := d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, succeeded)
+
+	deliveries, err := repo.FetchDeliveries(context.TODO(), w.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	failed := deliveries[0]
+	assert.Equal(t, models.DeliveryPending, failed.Status)
+	assert.Equal(t, 1, failed.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, failed.ResponseStatus)
+	assert.Equal(t, "unexpected status 500", failed.LastError)
+	assert.WithinDuration(t, time.Now().Add(dispatcher.MinBackoff), failed.NextAttemptAt, time.Second)
+
+	succeeded, err = d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, succeeded, "the delivery is not due before its backoff")
+
+	failed.NextAttemptAt = time.Now()
+	require.NoError(t, repo.UpdateDelivery(context.TODO(), failed))
+
+	succeeded, err = d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, succeeded)
+	delivery, err := repo.GetDelivery(context.TODO(), failed.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Empty(t, delivery.LastError)
+}
+
+func TestDispatchPendingDeadLetters(t *testing.T) {
+	rec := &receiver{statuses: []int{http.StatusBadGateway, http.StatusBadGateway}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	repo := webhookRepo.NewMemoryWebhook()
+	w := publish(t, repo, server.URL)
+
+	d := dispatcher.NewDispatcher(repo, server.Client(), time.Second, 10, 2)
+
+	for i := 0; i < 2; i++ {
+		_, err := d.DispatchPending(context.TODO())
+		assert.NoError(t, err)
+
+		deliveries, err := repo.FetchDeliveries(context.TODO(), w.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		deliveries[0].NextAttemptAt = time.Now()
+		require.NoError(t, repo.UpdateDelivery(context.TODO(), deliveries[0]))
+	}
+
+	deliveries, err := repo.FetchDeliveries(context.TODO(), w.ID, 10)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryDead, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+
+	succeeded, err := d.DispatchPending(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, succeeded)
+	assert.Len(t, rec.requests, 2, "dead deliveries are not attempted again")
+}
+
+func TestDispatcherShutdown(t *testing.T) {
+	rec := &receiver{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	repo := webhookRepo.NewMemoryWebhook()
+	publish(t, repo, server.URL)
+
+	d := dispatcher.NewDispatcher(repo, server.Client(), 10*time.Millisecond, 10, 3)
+	d.Start()
+
+	assert.Eventually(t, func() bool {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		return len(rec.requests) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, d.Shutdown(ctx))
+}
@@ -0,0 +1,65 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/outbox"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+type webhookPublisher struct {
+	repo webhook.Repository
+}
+
+// NewPublisher publisher scheduling a delivery of every event to each webhook
+// of its organisation subscribing to its type, for the Dispatcher to POST.
+// Publishing an event again schedules no further deliveries.
+func NewPublisher(repo webhook.Repository) outbox.Publisher {
+	return &webhookPublisher{repo}
+}
+
+// Publish schedules the deliveries of the given event.
+func (p *webhookPublisher) Publish(ctx context.Context, e *models.Event) error {
+	if e.Organisation == "" {
+		return nil
+	}
+
+	hooks, err := p.repo.Fetch(tenant.NewContext(ctx, e.Organisation))
+	if err != nil {
+		return err
+	}
+
+	var body []byte
+	for _, w := range hooks {
+		if !w.Subscribes(e.Type) {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		err = p.repo.StoreDelivery(ctx, &models.WebhookDelivery{
+			Webhook:       w.ID,
+			Event:         e.ID,
+			EventType:     e.Type,
+			Organisation:  e.Organisation,
+			Payload:       body,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// reservedNetworks networks not reachable from the internet beyond the
+// loopback, private, link-local and multicast ones.
+var reservedNetworks = []string{
+	"0.0.0.0/8",
+	"100.64.0.0/10",
+	"192.0.0.0/24",
+	"198.18.0.0/15",
+	"240.0.0.0/4",
+}
+
+// Guard keeps webhooks from reaching the service network: their URLs must be
+// https and their hosts resolve to public addresses only, unless allowed.
+type Guard struct {
+	hosts    map[string]bool
+	networks []*net.IPNet
+	reserved []*net.IPNet
+	resolver *net.Resolver
+	dialer   *net.Dialer
+}
+
+// NewGuard guard constructor, allowing the given hosts, named or given as IP
+// addresses or CIDR networks, to be reached over http and on any address.
+func NewGuard(allowed []string) (*Guard, error) {
+	g := &Guard{
+		hosts:    make(map[string]bool),
+		resolver: net.DefaultResolver,
+		dialer:   &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+	}
+	for _, host := range allowed {
+		if strings.Contains(host, "/") {
+			_, n, err := net.ParseCIDR(host)
+			if err != nil {
+				return nil, fmt.Errorf("webhook allowed host %q: %v", host, err)
+			}
+			g.networks = append(g.networks, n)
+			continue
+		}
+		g.hosts[strings.ToLower(host)] = true
+	}
+	for _, cidr := range reservedNetworks {
+		_, n, _ := net.ParseCIDR(cidr)
+		g.reserved = append(g.reserved, n)
+	}
+
+	return g, nil
+}
+
+// CheckURL checks the URL of a webhook before storing it, failing with
+// ErrWebhookURLNotAllowed when not https or resolving to a non public address.
+func (g *Guard) CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return models.ErrWebhookURLNotAllowed
+	}
+	if g.allows(u.Hostname()) {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return models.ErrWebhookURLNotAllowed
+	}
+
+	_, err = g.resolve(ctx, u.Hostname())
+	return err
+}
+
+// Client returns an http client reaching webhooks through the guard, which
+// does not follow redirects.
+func (g *Guard) Client(timeout time.Duration) *http.Client {
+	transport := &http.Transport{
+		DialContext:           g.DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &http.Client{
+		Transport: &guardedTransport{guard: g, next: transport},
+		Timeout:   timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// DialContext dials the given address when its host is allowed or resolves
+// to public addresses only. The addresses checked are the ones dialed, so the
+// host cannot be resolved again to another one in between.
+func (g *Guard) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if g.allows(host) {
+		return g.dialer.DialContext(ctx, network, address)
+	}
+
+	ips, err := g.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, ip := range ips {
+		var conn net.Conn
+		conn, err = g.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+	}
+
+	return nil, err
+}
+
+// allows tells whether the given host may be reached over http and on any
+// address.
+func (g *Guard) allows(host string) bool {
+	if g.hosts[strings.ToLower(host)] {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range g.networks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolve resolves the given host, failing with ErrWebhookURLNotAllowed when
+// it cannot be resolved or any of its addresses is not public.
+func (g *Guard) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		addrs, err := g.resolver.LookupIPAddr(ctx, host)
+		if err != nil || len(addrs) == 0 {
+			return nil, models.ErrWebhookURLNotAllowed
+		}
+		ips = ips[:0]
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	for _, ip := range ips {
+		if !g.public(ip) {
+			return nil, models.ErrWebhookURLNotAllowed
+		}
+	}
+
+	return ips, nil
+}
+
+// public tells whether the given address is reachable from the internet.
+func (g *Guard) public(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range g.reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// guardedTransport refuses plain http requests to hosts not allowed.
+type guardedTransport struct {
+	guard *Guard
+	next  http.RoundTripper
+}
+
+func (t *guardedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" && !t.guard.allows(req.URL.Hostname()) {
+		return nil, models.ErrWebhookURLNotAllowed
+	}
+
+	return t.next.RoundTrip(req)
+}
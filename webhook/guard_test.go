@@ -0,0 +1,67 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+func TestGuardCheckURL(t *testing.T) {
+	g, err := webhook.NewGuard([]string{"hooks.internal", "10.1.0.0/16"})
+	require.NoError(t, err)
+
+	for _, url := range []string{"http://hooks.internal/payment", "http://10.1.2.3:8080", "https://8.8.8.8/hook"} {
+		assert.NoError(t, g.CheckURL(context.TODO(), url), url)
+	}
+	for _, url := range []string{
+		"http://8.8.8.8/hook",
+		"https://localhost/hook",
+		"https://127.0.0.1/hook",
+		"https://[::1]/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://192.168.1.1",
+		"https://10.2.0.1",
+		"https://100.64.0.1",
+		"not a url",
+	} {
+		assert.Equal(t, models.ErrWebhookURLNotAllowed, g.CheckURL(context.TODO(), url), url)
+	}
+
+	_, err = webhook.NewGuard([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestGuardClient(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/hook", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	g, err := webhook.NewGuard(nil)
+	require.NoError(t, err)
+	_, err = g.Client(time.Second).Post(server.URL, "application/json", nil)
+	assert.Error(t, err, "loopback addresses are not dialed")
+	_, err = g.DialContext(context.TODO(), "tcp", server.Listener.Addr().String())
+	assert.Equal(t, models.ErrWebhookURLNotAllowed, err)
+	assert.Equal(t, 0, calls)
+
+	g, err = webhook.NewGuard([]string{"127.0.0.0/8"})
+	require.NoError(t, err)
+	res, err := g.Client(time.Second).Post(server.URL+"/redirect", "application/json", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusFound, res.StatusCode, "redirects are not followed")
+	assert.Equal(t, 1, calls)
+}
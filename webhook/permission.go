@@ -0,0 +1,12 @@
+package webhook
+
+// Permissions authorising the webhook use cases.
+const (
+	PermissionRead  = "webhooks:read"
+	PermissionWrite = "webhooks:write"
+)
+
+// Permissions every permission authorising a webhook use case.
+func Permissions() []string {
+	return []string{PermissionRead, PermissionWrite}
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Repository repository interface to interact with stored webhooks and their
+// deliveries, restricted to the organisation of the caller.
+type Repository interface {
+	Fetch(ctx context.Context) ([]*models.Webhook, error)
+	GetByID(ctx context.Context, id int64) (*models.Webhook, error)
+	Store(ctx context.Context, w *models.Webhook) (int64, error)
+	Delete(ctx context.Context, id int64) error
+	StoreDelivery(ctx context.Context, d *models.WebhookDelivery) error
+	FetchDeliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	FetchDueDeliveries(ctx context.Context, now time.Time, num int64) ([]*models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+type memoryWebhook struct {
+	mu             sync.Mutex
+	lastID         int64
+	webhooks       []models.Webhook
+	lastDeliveryID int64
+	deliveries     []models.WebhookDelivery
+}
+
+// NewMemoryWebhook in-memory webhooks constructor, meant for local
+// development and tests as nothing is persisted across restarts.
+func NewMemoryWebhook() webhook.Repository {
+	return &memoryWebhook{}
+}
+
+// Fetch lists the webhooks of the caller's organisation.
+func (m *memoryWebhook) Fetch(ctx context.Context) ([]*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*models.Webhook, 0)
+	for _, w := range m.webhooks {
+		if tenant.Allows(ctx, w.Organisation) {
+			w := w
+			result = append(result, &w)
+		}
+	}
+
+	return result, nil
+}
+
+// GetByID gets a webhook of the caller's organisation.
+func (m *memoryWebhook) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, w := range m.webhooks {
+		if w.ID == id && tenant.Allows(ctx, w.Organisation) {
+			return &w, nil
+		}
+	}
+
+	return nil, models.ErrNotFound
+}
+
+// Store stores the given webhook.
+func (m *memoryWebhook) Store(ctx context.Context, w *models.Webhook) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	stored := *w
+	stored.ID = m.lastID
+	stored.EventTypes = append([]string(nil), w.EventTypes...)
+	m.webhooks = append(m.webhooks, stored)
+
+	return stored.ID, nil
+}
+
+// Delete deletes a webhook of the caller's organisation along with its
+// deliveries.
+func (m *memoryWebhook) Delete(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, w := range m.webhooks {
+		if w.ID != id || !tenant.Allows(ctx, w.Organisation) {
+			continue
+		}
+		m.webhooks = append(m.webhooks[:i], m.webhooks[i+1:]...)
+
+		deliveries := m.deliveries[:0]
+		for _, d := range m.deliveries {
+			if d.Webhook != id {
+				deliveries = append(deliveries, d)
+			}
+		}
+		m.deliveries = deliveries
+
+		return nil
+	}
+
+	return models.ErrNotFound
+}
+
+// StoreDelivery stores the given delivery, unless the event was already
+// delivered to the webhook.
+func (m *memoryWebhook) StoreDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, stored := range m.deliveries {
+		if stored.Webhook == d.Webhook && stored.Event == d.Event {
+			return nil
+		}
+	}
+	m.lastDeliveryID++
+	d.ID = m.lastDeliveryID
+	m.deliveries = append(m.deliveries, *d)
+
+	return nil
+}
+
+// FetchDeliveries lists up to num deliveries of a webhook of the caller's
+// organisation, newest first.
+func (m *memoryWebhook) FetchDeliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*models.WebhookDelivery, 0)
+	for i := len(m.deliveries) - 1; i >= 0 && int64(len(result)) < num; i-- {
+		d := m.deliveries[i]
+		if d.Webhook == webhook && tenant.Allows(ctx, d.Organisation) {
+			result = append(result, &d)
+		}
+	}
+
+	return result, nil
+}
+
+// GetDelivery gets a delivery of the caller's organisation.
+func (m *memoryWebhook) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, d := range m.deliveries {
+		if d.ID == id && tenant.Allows(ctx, d.Organisation) {
+			return &d, nil
+		}
+	}
+
+	return nil, models.ErrNotFound
+}
+
+// FetchDueDeliveries lists up to num pending deliveries due by now, oldest
+// first.
+func (m *memoryWebhook) FetchDueDeliveries(ctx context.Context, now time.Time, num int64) ([]*models.WebhookDelivery, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]*models.WebhookDelivery, 0)
+	for _, d := range m.deliveries {
+		if int64(len(result)) == num {
+			break
+		}
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) {
+			d := d
+			result = append(result, &d)
+		}
+	}
+
+	return result, nil
+}
+
+// UpdateDelivery records the outcome of the last attempt of a delivery.
+func (m *memoryWebhook) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.deliveries {
+		stored := &m.deliveries[i]
+		if stored.ID != d.ID || !tenant.Allows(ctx, stored.Organisation) {
+			continue
+		}
+		stored.Status = d.Status
+		stored.Attempts = d.Attempts
+		stored.NextAttemptAt = d.NextAttemptAt
+		stored.LastError = d.LastError
+		stored.ResponseStatus = d.ResponseStatus
+		stored.UpdatedAt = d.UpdatedAt
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+func TestMemoryWebhook(t *testing.T) {
+	a := webhookRepo.NewMemoryWebhook()
+	orgA := tenant.NewContext(context.TODO(), "org-a")
+	orgB := tenant.NewContext(context.TODO(), "org-b")
+	now := time.Now()
+
+	id, err := a.Store(orgA, &models.Webhook{Organisation: "org-a", EventTypes: []string{"PaymentCreated"}})
+	assert.NoError(t, err)
+	_, err = a.Store(orgB, &models.Webhook{Organisation: "org-b", EventTypes: []string{"PaymentCreated"}})
+	assert.NoError(t, err)
+
+	list, err := a.Fetch(orgA)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, id, list[0].ID)
+	}
+	_, err = a.GetByID(orgB, id)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	for _, event := range []int64{1, 2, 2} {
+		d := &models.WebhookDelivery{Webhook: id, Event: event, Organisation: "org-a",
+			Status: models.DeliveryPending, NextAttemptAt: now}
+		assert.NoError(t, a.StoreDelivery(orgA, d))
+	}
+
+	due, err := a.FetchDueDeliveries(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, due, 2) {
+		due[0].Status = models.DeliverySucceeded
+		due[0].Attempts = 1
+		assert.NoError(t, a.UpdateDelivery(orgA, due[0]))
+	}
+
+	deliveries, err := a.FetchDeliveries(orgA, id, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, int64(2), deliveries[0].Event)
+		assert.Equal(t, models.DeliverySucceeded, deliveries[1].Status)
+	}
+
+	assert.Equal(t, models.ErrNotFound, a.Delete(orgB, id))
+	assert.NoError(t, a.Delete(orgA, id))
+	deliveries, err = a.FetchDeliveries(orgA, id, 10)
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 0)
+}
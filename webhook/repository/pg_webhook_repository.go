@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+type pgWebhook struct {
+	Conn *sql.DB
+}
+
+// NewPgWebhook postgres webhooks constructor.
+func NewPgWebhook(Conn *sql.DB) webhook.Repository {
+	return &pgWebhook{Conn}
+}
+
+// Fetch lists the webhooks of the caller's organisation.
+func (m *pgWebhook) Fetch(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE ($1::text = '' OR organisation = $1::text) ORDER BY id`
+
+	return fetchWebhooks(ctx, m.Conn, query, tenant.FromContext(ctx))
+}
+
+// GetByID gets a webhook of the caller's organisation.
+func (m *pgWebhook) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE id = $1 AND ($2::text = '' OR organisation = $2::text)`
+
+	return firstWebhook(fetchWebhooks(ctx, m.Conn, query, id, tenant.FromContext(ctx)))
+}
+
+// Store stores the given webhook.
+func (m *pgWebhook) Store(ctx context.Context, w *models.Webhook) (int64, error) {
+	query := `INSERT INTO webhook (organisation, url, event_types, secret, created_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`
+
+	var id int64
+	tracing.Statement(ctx, query)
+	err := m.Conn.QueryRowContext(ctx, query, w.Organisation, w.URL,
+		strings.Join(w.EventTypes, eventTypeSeparator), w.Secret, w.CreatedAt).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Delete deletes a webhook of the caller's organisation along with its
+// deliveries.
+func (m *pgWebhook) Delete(ctx context.Context, id int64) error {
+	return deleteWebhook(ctx, m.Conn,
+		`DELETE FROM webhook_delivery WHERE webhook = $1 AND ($2::text = '' OR organisation = $2::text)`,
+		`DELETE FROM webhook WHERE id = $1 AND ($2::text = '' OR organisation = $2::text)`,
+		id, tenant.FromContext(ctx))
+}
+
+// StoreDelivery stores the given delivery, unless the event was already
+// delivered to the webhook.
+func (m *pgWebhook) StoreDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `INSERT INTO webhook_delivery (webhook, event, event_type, organisation, payload, status,
+		attempts, next_attempt_at, last_error, response_status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (webhook, event) DO NOTHING`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Webhook, d.Event, d.EventType, d.Organisation, string(d.Payload), d.Status,
+		d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+// FetchDeliveries lists up to num deliveries of a webhook of the caller's
+// organisation, newest first.
+func (m *pgWebhook) FetchDeliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE webhook = $1 AND ($2::text = '' OR organisation = $2::text) ORDER BY id DESC LIMIT $3`
+
+	return fetchDeliveries(ctx, m.Conn, query, webhook, tenant.FromContext(ctx), num)
+}
+
+// GetDelivery gets a delivery of the caller's organisation.
+func (m *pgWebhook) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE id = $1 AND ($2::text = '' OR organisation = $2::text)`
+
+	return firstDelivery(fetchDeliveries(ctx, m.Conn, query, id, tenant.FromContext(ctx)))
+}
+
+// FetchDueDeliveries lists up to num pending deliveries due by now, oldest
+// first.
+func (m *pgWebhook) FetchDueDeliveries(ctx context.Context, now time.Time, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE status = $1 AND next_attempt_at <= $2 ORDER BY id LIMIT $3`
+
+	return fetchDeliveries(ctx, m.Conn, query, models.DeliveryPending, now, num)
+}
+
+// UpdateDelivery records the outcome of the last attempt of a delivery.
+func (m *pgWebhook) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `UPDATE webhook_delivery SET status=$1, attempts=$2, next_attempt_at=$3, last_error=$4, response_status=$5, updated_at=$6
+		WHERE id = $7 AND ($8::text = '' OR organisation = $8::text)`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.UpdatedAt,
+		d.ID, tenant.FromContext(ctx))
+	return err
+}
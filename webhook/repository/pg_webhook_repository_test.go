@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+func TestPgStoreWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	w := &models.Webhook{Organisation: "org-a", URL: "https://example.com/hook",
+		EventTypes: []string{"PaymentCreated"}, Secret: "whsec_a", CreatedAt: time.Now()}
+	mock.ExpectQuery("INSERT INTO webhook \\(organisation, url, event_types, secret, created_at\\) (.+) RETURNING id").
+		WithArgs("org-a", "https://example.com/hook", "PaymentCreated", "whsec_a", w.CreatedAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+	a := webhookRepo.NewPgWebhook(db)
+
+	id, err := a.Store(context.TODO(), w)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+}
+
+func TestPgFetchDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(deliveryColumns).
+		AddRow(deliveryRow(2, models.DeliveryDead, 10)...).
+		AddRow(deliveryRow(1, models.DeliverySucceeded, 1)...)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_delivery WHERE webhook = \\$1 AND \\(\\$2::text = '' OR organisation = \\$2::text\\) ORDER BY id DESC LIMIT \\$3").
+		WithArgs(1, "org-a", int64(10)).WillReturnRows(rows)
+
+	a := webhookRepo.NewPgWebhook(db)
+
+	list, err := a.FetchDeliveries(tenant.NewContext(context.TODO(), "org-a"), 1, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, models.DeliveryDead, list[0].Status)
+		assert.Equal(t, models.DeliverySucceeded, list[1].Status)
+	}
+}
+
+func TestPgStoreDeliveryIgnoresDuplicates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO webhook_delivery (.+) ON CONFLICT \\(webhook, event\\) DO NOTHING").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	a := webhookRepo.NewPgWebhook(db)
+
+	err = a.StoreDelivery(context.TODO(), &models.WebhookDelivery{Webhook: 1, Event: 7})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+type sqliteWebhook struct {
+	Conn *sql.DB
+}
+
+// NewSqliteWebhook sqlite webhooks constructor.
+func NewSqliteWebhook(Conn *sql.DB) webhook.Repository {
+	return &sqliteWebhook{Conn}
+}
+
+// Fetch lists the webhooks of the caller's organisation.
+func (m *sqliteWebhook) Fetch(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE ` + organisationFilter + ` ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchWebhooks(ctx, m.Conn, query, org, org)
+}
+
+// GetByID gets a webhook of the caller's organisation.
+func (m *sqliteWebhook) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	return firstWebhook(fetchWebhooks(ctx, m.Conn, query, id, org, org))
+}
+
+// Store stores the given webhook.
+func (m *sqliteWebhook) Store(ctx context.Context, w *models.Webhook) (int64, error) {
+	query := `INSERT INTO webhook (organisation, url, event_types, secret, created_at) VALUES (?, ?, ?, ?, ?)`
+
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, w.Organisation, w.URL,
+		strings.Join(w.EventTypes, eventTypeSeparator), w.Secret, w.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Delete deletes a webhook of the caller's organisation along with its
+// deliveries.
+func (m *sqliteWebhook) Delete(ctx context.Context, id int64) error {
+	org := tenant.FromContext(ctx)
+	return deleteWebhook(ctx, m.Conn,
+		`DELETE FROM webhook_delivery WHERE webhook = ? AND `+organisationFilter,
+		`DELETE FROM webhook WHERE id = ? AND `+organisationFilter,
+		id, org, org)
+}
+
+// StoreDelivery stores the given delivery, unless the event was already
+// delivered to the webhook.
+func (m *sqliteWebhook) StoreDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `INSERT INTO webhook_delivery (webhook, event, event_type, organisation, payload, status,
+		attempts, next_attempt_at, last_error, response_status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (webhook, event) DO NOTHING`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Webhook, d.Event, d.EventType, d.Organisation, string(d.Payload), d.Status,
+		d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+// FetchDeliveries lists up to num deliveries of a webhook of the caller's
+// organisation, newest first.
+func (m *sqliteWebhook) FetchDeliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE webhook = ? AND ` + organisationFilter + ` ORDER BY id DESC LIMIT ?`
+
+	org := tenant.FromContext(ctx)
+	return fetchDeliveries(ctx, m.Conn, query, webhook, org, org, num)
+}
+
+// GetDelivery gets a delivery of the caller's organisation.
+func (m *sqliteWebhook) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	return firstDelivery(fetchDeliveries(ctx, m.Conn, query, id, org, org))
+}
+
+// FetchDueDeliveries lists up to num pending deliveries due by now, oldest
+// first.
+func (m *sqliteWebhook) FetchDueDeliveries(ctx context.Context, now time.Time, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?`
+
+	return fetchDeliveries(ctx, m.Conn, query, models.DeliveryPending, now, num)
+}
+
+// UpdateDelivery records the outcome of the last attempt of a delivery.
+func (m *sqliteWebhook) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `UPDATE webhook_delivery SET status=?, attempts=?, next_attempt_at=?, last_error=?, response_status=?, updated_at=?
+		WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.UpdatedAt,
+		d.ID, org, org)
+	return err
+}
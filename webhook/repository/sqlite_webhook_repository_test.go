@@ -0,0 +1,60 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/testutil"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+func TestSqliteWebhookRoundTrip(t *testing.T) {
+	db := testutil.OpenSqlite(t, ":memory:")
+
+	a := webhookRepo.NewSqliteWebhook(db)
+	ctx := tenant.NewContext(context.TODO(), "org-a")
+	now := time.Now()
+
+	id, err := a.Store(ctx, &models.Webhook{Organisation: "org-a", URL: "https://example.com/hook",
+		EventTypes: []string{"PaymentCreated", "PaymentDeleted"}, Secret: "whsec_a", CreatedAt: now})
+	assert.NoError(t, err)
+
+	d := &models.WebhookDelivery{Webhook: id, Event: 7, EventType: models.PaymentCreated, Organisation: "org-a",
+		Payload: json.RawMessage(`{"id":7}`), Status: models.DeliveryPending, NextAttemptAt: now, CreatedAt: now, UpdatedAt: now}
+	assert.NoError(t, a.StoreDelivery(ctx, d))
+	assert.NoError(t, a.StoreDelivery(ctx, d))
+
+	list, err := a.FetchDueDeliveries(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.JSONEq(t, `{"id":7}`, string(list[0].Payload))
+		list[0].Status = models.DeliveryDead
+		list[0].Attempts = 10
+		list[0].ResponseStatus = 500
+		assert.NoError(t, a.UpdateDelivery(ctx, list[0]))
+
+		dead, err := a.GetDelivery(ctx, list[0].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.DeliveryDead, dead.Status)
+		assert.Equal(t, 500, dead.ResponseStatus)
+
+		_, err = a.GetDelivery(tenant.NewContext(context.TODO(), "org-b"), list[0].ID)
+		assert.Equal(t, models.ErrNotFound, err)
+	}
+
+	list, err = a.FetchDueDeliveries(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+
+	assert.Equal(t, models.ErrNotFound, a.Delete(tenant.NewContext(context.TODO(), "org-b"), id))
+	assert.NoError(t, a.Delete(ctx, id))
+	list, err = a.FetchDeliveries(ctx, id, 10)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+}
@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/tracing"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// organisationFilter restricts a statement to the organisation of the caller,
+// taking it twice as arguments, an empty one matching every organisation.
+const organisationFilter = `(? = '' OR organisation = ?)`
+
+// webhookColumns columns selected when fetching webhooks, in scan order.
+const webhookColumns = `id,organisation,url,event_types,secret,created_at`
+
+// deliveryColumns columns selected when fetching deliveries, in scan order.
+const deliveryColumns = `id,webhook,event,event_type,organisation,payload,status,attempts,
+	next_attempt_at,last_error,response_status,created_at,updated_at`
+
+// eventTypeSeparator separates the event types of a webhook in its
+// event_types column.
+const eventTypeSeparator = ","
+
+type mysqlWebhook struct {
+	Conn *sql.DB
+}
+
+// NewMysqlWebhook mysql webhooks constructor.
+func NewMysqlWebhook(Conn *sql.DB) webhook.Repository {
+	return &mysqlWebhook{Conn}
+}
+
+// fetchWebhooks runs the given webhooks query, scanning webhookColumns rows.
+func fetchWebhooks(ctx context.Context, conn *sql.DB, query string, args ...interface{}) ([]*models.Webhook, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.Webhook, 0)
+	for rows.Next() {
+		w := new(models.Webhook)
+		var eventTypes string
+		err = rows.Scan(
+			&w.ID,
+			&w.Organisation,
+			&w.URL,
+			&eventTypes,
+			&w.Secret,
+			&w.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil, err
+		}
+		w.EventTypes = strings.Split(eventTypes, eventTypeSeparator)
+		result = append(result, w)
+	}
+
+	return result, nil
+}
+
+// fetchDeliveries runs the given deliveries query, scanning deliveryColumns
+// rows.
+func fetchDeliveries(ctx context.Context, conn *sql.DB, query string, args ...interface{}) ([]*models.WebhookDelivery, error) {
+	tracing.Statement(ctx, query)
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		logging.FromContext(ctx).Error(err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*models.WebhookDelivery, 0)
+	for rows.Next() {
+		d := new(models.WebhookDelivery)
+		var payload string
+		err = rows.Scan(
+			&d.ID,
+			&d.Webhook,
+			&d.Event,
+			&d.EventType,
+			&d.Organisation,
+			&payload,
+			&d.Status,
+			&d.Attempts,
+			&d.NextAttemptAt,
+			&d.LastError,
+			&d.ResponseStatus,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).Error(err)
+			return nil, err
+		}
+		d.Payload = json.RawMessage(payload)
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+// firstWebhook returns the first of the given webhooks, ErrNotFound if none.
+func firstWebhook(list []*models.Webhook, err error) (*models.Webhook, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+// firstDelivery returns the first of the given deliveries, ErrNotFound if
+// none.
+func firstDelivery(list []*models.WebhookDelivery, err error) (*models.WebhookDelivery, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, models.ErrNotFound
+	}
+
+	return list[0], nil
+}
+
+// deleteWebhook runs the given statements deleting the deliveries of a
+// webhook and then the webhook itself within a transaction, ErrNotFound when
+// there is no such webhook.
+func deleteWebhook(ctx context.Context, conn *sql.DB, deliveries, hook string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	tracing.Statement(ctx, deliveries)
+	if _, err = tx.ExecContext(ctx, deliveries, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	tracing.Statement(ctx, hook)
+	res, err := tx.ExecContext(ctx, hook, args...)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	affect, err := res.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if affect != 1 {
+		_ = tx.Rollback()
+		return models.ErrNotFound
+	}
+
+	return tx.Commit()
+}
+
+// Fetch lists the webhooks of the caller's organisation.
+func (m *mysqlWebhook) Fetch(ctx context.Context) ([]*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE ` + organisationFilter + ` ORDER BY id`
+
+	org := tenant.FromContext(ctx)
+	return fetchWebhooks(ctx, m.Conn, query, org, org)
+}
+
+// GetByID gets a webhook of the caller's organisation.
+func (m *mysqlWebhook) GetByID(ctx context.Context, id int64) (*models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + `
+  						FROM webhook WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	return firstWebhook(fetchWebhooks(ctx, m.Conn, query, id, org, org))
+}
+
+// Store stores the given webhook.
+func (m *mysqlWebhook) Store(ctx context.Context, w *models.Webhook) (int64, error) {
+	query := `INSERT webhook SET organisation=? , url=? , event_types=? , secret=? , created_at=?`
+
+	tracing.Statement(ctx, query)
+	res, err := m.Conn.ExecContext(ctx, query, w.Organisation, w.URL,
+		strings.Join(w.EventTypes, eventTypeSeparator), w.Secret, w.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.LastInsertId()
+}
+
+// Delete deletes a webhook of the caller's organisation along with its
+// deliveries.
+func (m *mysqlWebhook) Delete(ctx context.Context, id int64) error {
+	org := tenant.FromContext(ctx)
+	return deleteWebhook(ctx, m.Conn,
+		`DELETE FROM webhook_delivery WHERE webhook = ? AND `+organisationFilter,
+		`DELETE FROM webhook WHERE id = ? AND `+organisationFilter,
+		id, org, org)
+}
+
+// StoreDelivery stores the given delivery, unless the event was already
+// delivered to the webhook.
+func (m *mysqlWebhook) StoreDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `INSERT webhook_delivery SET webhook=? , event=? , event_type=? , organisation=? , payload=? , status=? ,
+		attempts=? , next_attempt_at=? , last_error=? , response_status=? , created_at=? , updated_at=?
+		ON DUPLICATE KEY UPDATE id=id`
+
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Webhook, d.Event, d.EventType, d.Organisation, string(d.Payload), d.Status,
+		d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.CreatedAt, d.UpdatedAt)
+	return err
+}
+
+// FetchDeliveries lists up to num deliveries of a webhook of the caller's
+// organisation, newest first.
+func (m *mysqlWebhook) FetchDeliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE webhook = ? AND ` + organisationFilter + ` ORDER BY id DESC LIMIT ?`
+
+	org := tenant.FromContext(ctx)
+	return fetchDeliveries(ctx, m.Conn, query, webhook, org, org, num)
+}
+
+// GetDelivery gets a delivery of the caller's organisation.
+func (m *mysqlWebhook) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	return firstDelivery(fetchDeliveries(ctx, m.Conn, query, id, org, org))
+}
+
+// FetchDueDeliveries lists up to num pending deliveries due by now, oldest
+// first.
+func (m *mysqlWebhook) FetchDueDeliveries(ctx context.Context, now time.Time, num int64) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + `
+  						FROM webhook_delivery WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ?`
+
+	return fetchDeliveries(ctx, m.Conn, query, models.DeliveryPending, now, num)
+}
+
+// UpdateDelivery records the outcome of the last attempt of a delivery.
+func (m *mysqlWebhook) UpdateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	query := `UPDATE webhook_delivery SET status=? , attempts=? , next_attempt_at=? , last_error=? , response_status=? , updated_at=?
+		WHERE id = ? AND ` + organisationFilter
+
+	org := tenant.FromContext(ctx)
+	tracing.Statement(ctx, query)
+	_, err := m.Conn.ExecContext(ctx, query, d.Status, d.Attempts, d.NextAttemptAt, d.LastError, d.ResponseStatus, d.UpdatedAt,
+		d.ID, org, org)
+	return err
+}
@@ -0,0 +1,195 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	webhookRepo "github.com/adriacidre/go-clean-arch/webhook/repository"
+)
+
+var (
+	webhookColumns  = []string{"id", "organisation", "url", "event_types", "secret", "created_at"}
+	deliveryColumns = []string{"id", "webhook", "event", "event_type", "organisation", "payload", "status", "attempts",
+		"next_attempt_at", "last_error", "response_status", "created_at", "updated_at"}
+)
+
+// deliveryRow builds a delivery row matching deliveryColumns.
+func deliveryRow(id int64, status models.DeliveryStatus, attempts int) []driver.Value {
+	return []driver.Value{id, 1, 7, string(models.PaymentCreated), "org-a", `{"id":7}`, string(status), attempts,
+		time.Now(), "", 0, time.Now(), time.Now()}
+}
+
+func TestFetchWebhooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows(webhookColumns).
+		AddRow(1, "org-a", "https://example.com/hook", "PaymentCreated,PaymentDeleted", "whsec_a", time.Now())
+	mock.ExpectQuery("SELECT (.+) FROM webhook WHERE \\(\\? = '' OR organisation = \\?\\) ORDER BY id").
+		WithArgs("org-a", "org-a").WillReturnRows(rows)
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	list, err := a.Fetch(tenant.NewContext(context.TODO(), "org-a"))
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, []string{"PaymentCreated", "PaymentDeleted"}, list[0].EventTypes)
+		assert.Equal(t, "whsec_a", list[0].Secret)
+	}
+}
+
+func TestGetWebhookByIDNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT (.+) FROM webhook WHERE id = \\? AND (.+)").
+		WithArgs(1, "org-b", "org-b").WillReturnRows(sqlmock.NewRows(webhookColumns))
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	w, err := a.GetByID(tenant.NewContext(context.TODO(), "org-b"), 1)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.Nil(t, w)
+}
+
+func TestStoreWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	w := &models.Webhook{Organisation: "org-a", URL: "https://example.com/hook",
+		EventTypes: []string{"PaymentCreated", "PaymentDeleted"}, Secret: "whsec_a", CreatedAt: time.Now()}
+	mock.ExpectExec("INSERT webhook SET organisation=\\? , url=\\? , event_types=\\? (.+)").
+		WithArgs("org-a", "https://example.com/hook", "PaymentCreated,PaymentDeleted", "whsec_a", w.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	id, err := a.Store(context.TODO(), w)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM webhook_delivery WHERE webhook = \\? AND (.+)").
+		WithArgs(3, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM webhook WHERE id = \\? AND (.+)").
+		WithArgs(3, "org-a", "org-a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	err = a.Delete(tenant.NewContext(context.TODO(), "org-a"), 3)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteWebhookNotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM webhook_delivery (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM webhook WHERE (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	err = a.Delete(context.TODO(), 3)
+	assert.Equal(t, models.ErrNotFound, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	d := &models.WebhookDelivery{Webhook: 1, Event: 7, EventType: models.PaymentCreated, Organisation: "org-a",
+		Payload: json.RawMessage(`{"id":7}`), Status: models.DeliveryPending, NextAttemptAt: now, CreatedAt: now, UpdatedAt: now}
+	mock.ExpectExec("INSERT webhook_delivery SET (.+) ON DUPLICATE KEY UPDATE id=id").
+		WithArgs(int64(1), int64(7), string(models.PaymentCreated), "org-a", `{"id":7}`, string(models.DeliveryPending),
+			0, now, "", 0, now, now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	err = a.StoreDelivery(context.TODO(), d)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFetchDueDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	rows := sqlmock.NewRows(deliveryColumns).
+		AddRow(deliveryRow(1, models.DeliveryPending, 0)...).
+		AddRow(deliveryRow(2, models.DeliveryPending, 4)...)
+	mock.ExpectQuery("SELECT (.+) FROM webhook_delivery WHERE status = \\? AND next_attempt_at <= \\? ORDER BY id LIMIT \\?").
+		WithArgs(string(models.DeliveryPending), now, int64(10)).WillReturnRows(rows)
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	list, err := a.FetchDueDeliveries(context.TODO(), now, 10)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, 4, list[1].Attempts)
+		assert.Equal(t, models.PaymentCreated, list[1].EventType)
+		assert.JSONEq(t, `{"id":7}`, string(list[1].Payload))
+	}
+}
+
+func TestUpdateDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	d := &models.WebhookDelivery{ID: 2, Status: models.DeliveryDead, Attempts: 10, NextAttemptAt: now,
+		LastError: "unexpected status 500", ResponseStatus: 500, UpdatedAt: now}
+	mock.ExpectExec("UPDATE webhook_delivery SET status=\\? , attempts=\\? (.+) WHERE id = \\? AND (.+)").
+		WithArgs(string(models.DeliveryDead), 10, now, "unexpected status 500", 500, now, int64(2), "", "").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	a := webhookRepo.NewMysqlWebhook(db)
+
+	err = a.UpdateDelivery(context.TODO(), d)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Headers set on every webhook request.
+const (
+	SignatureHeader = "X-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// Sign signs the body sent at t with secret, as found on the SignatureHeader:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<body>">".
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	return "t=" + timestamp + ",v1=" + signature(secret, timestamp, body)
+}
+
+// Verify checks the given SignatureHeader value signs body with secret and
+// was made less than tolerance before now, so that captured requests cannot
+// be replayed later on.
+func Verify(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var timestamp, sig string
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return models.ErrInvalidSignature
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			sig = kv[1]
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sig == "" {
+		return models.ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return models.ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(sig), []byte(signature(secret, timestamp, body))) {
+		return models.ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// signature hex encoded HMAC-SHA256 of the timestamp and body.
+func signature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
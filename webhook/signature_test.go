@@ -0,0 +1,41 @@
+package webhook_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":7}`)
+	header := webhook.Sign("whsec_a", now, body)
+
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="))
+	assert.NoError(t, webhook.Verify("whsec_a", header, body, time.Minute, now.Add(30*time.Second)))
+
+	tests := map[string]struct {
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+	}{
+		"wrong secret":    {"whsec_b", header, body, now},
+		"tampered body":   {"whsec_a", header, []byte(`{"id":8}`), now},
+		"replayed":        {"whsec_a", header, body, now.Add(2 * time.Minute)},
+		"missing sig":     {"whsec_a", "t=1700000000", body, now},
+		"malformed":       {"whsec_a", "garbage", body, now},
+		"empty":           {"whsec_a", "", body, now},
+		"other timestamp": {"whsec_a", strings.Replace(header, "t=1700000000", "t=1700000001", 1), body, now},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, models.ErrInvalidSignature, webhook.Verify(tc.secret, tc.header, tc.body, time.Minute, tc.now))
+		})
+	}
+}
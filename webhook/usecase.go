@@ -0,0 +1,16 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/models"
+)
+
+// Usecase webhook usecase interface
+type Usecase interface {
+	Create(ctx context.Context, w *models.Webhook) (string, error)
+	Fetch(ctx context.Context) ([]*models.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	Deliveries(ctx context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhook int64, delivery int64) (*models.WebhookDelivery, error)
+}
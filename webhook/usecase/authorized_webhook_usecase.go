@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+type authorizedWebhookUsecase struct {
+	next   webhook.Usecase
+	policy *auth.Policy
+}
+
+// NewAuthorizedWebhook decorates a webhook use case checking the caller was
+// granted the permission of every call by the given policy.
+func NewAuthorizedWebhook(next webhook.Usecase, policy *auth.Policy) webhook.Usecase {
+	return &authorizedWebhookUsecase{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (a *authorizedWebhookUsecase) Create(c context.Context, w *models.Webhook) (string, error) {
+	if err := a.policy.Authorize(c, webhook.PermissionWrite); err != nil {
+		return "", err
+	}
+
+	return a.next.Create(c, w)
+}
+
+func (a *authorizedWebhookUsecase) Fetch(c context.Context) ([]*models.Webhook, error) {
+	if err := a.policy.Authorize(c, webhook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return a.next.Fetch(c)
+}
+
+func (a *authorizedWebhookUsecase) Delete(c context.Context, id int64) error {
+	if err := a.policy.Authorize(c, webhook.PermissionWrite); err != nil {
+		return err
+	}
+
+	return a.next.Delete(c, id)
+}
+
+func (a *authorizedWebhookUsecase) Deliveries(c context.Context, id int64, num int64) ([]*models.WebhookDelivery, error) {
+	if err := a.policy.Authorize(c, webhook.PermissionRead); err != nil {
+		return nil, err
+	}
+
+	return a.next.Deliveries(c, id, num)
+}
+
+func (a *authorizedWebhookUsecase) Redeliver(c context.Context, id int64, delivery int64) (*models.WebhookDelivery, error) {
+	if err := a.policy.Authorize(c, webhook.PermissionWrite); err != nil {
+		return nil, err
+	}
+
+	return a.next.Redeliver(c, id, delivery)
+}
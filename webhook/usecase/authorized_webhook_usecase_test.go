@@ -0,0 +1,50 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/adriacidre/go-clean-arch/auth"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/webhook/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/webhook/usecase"
+)
+
+func TestAuthorizedWebhook(t *testing.T) {
+	mockUCase := new(mocks.Webhook)
+	mockUCase.On("Create", mock.Anything, mock.AnythingOfType("*models.Webhook")).Return("whsec_", nil)
+	mockUCase.On("Fetch", mock.Anything).Return([]*models.Webhook{}, nil)
+	mockUCase.On("Deliveries", mock.Anything, int64(1), int64(10)).Return([]*models.WebhookDelivery{}, nil)
+
+	u := ucase.NewAuthorizedWebhook(mockUCase, auth.NewPolicy(map[string][]string{
+		"operator": {"webhooks:read", "webhooks:write"},
+		"auditor":  {"webhooks:read"},
+	}))
+	as := func(role string) context.Context {
+		return auth.NewContext(context.TODO(), &auth.Principal{Roles: []string{role}})
+	}
+	denied := &auth.PermissionError{Permission: "webhooks:write"}
+
+	_, err := u.Create(as("operator"), &models.Webhook{})
+	assert.NoError(t, err)
+	_, err = u.Create(as("auditor"), &models.Webhook{})
+	assert.Equal(t, denied, err)
+	assert.Equal(t, denied, u.Delete(as("auditor"), 1))
+	_, err = u.Redeliver(as("auditor"), 1, 2)
+	assert.Equal(t, denied, err)
+
+	_, err = u.Fetch(as("auditor"))
+	assert.NoError(t, err)
+	_, err = u.Deliveries(as("auditor"), 1, 10)
+	assert.NoError(t, err)
+	_, err = u.Fetch(as("approver"))
+	assert.Equal(t, &auth.PermissionError{Permission: "webhooks:read"}, err)
+
+	mockUCase.AssertNumberOfCalls(t, "Create", 1)
+	mockUCase.AssertNumberOfCalls(t, "Fetch", 1)
+	mockUCase.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	mockUCase.AssertNotCalled(t, "Redeliver", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/adriacidre/go-clean-arch/logging"
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+)
+
+// secretLength random bytes of a webhook signing secret.
+const secretLength = 32
+
+// secretPrefix prefixes webhook signing secrets, so that they are easily told
+// apart from API key tokens.
+const secretPrefix = "whsec_"
+
+// defaultDeliveries deliveries listed when the caller does not ask for a
+// number.
+const defaultDeliveries = 10
+
+type webhookUsecase struct {
+	repo           webhook.Repository
+	guard          *webhook.Guard
+	contextTimeout time.Duration
+}
+
+// NewWebhook constructor for the webhook use case, storing only the webhooks
+// whose URL the guard allows.
+func NewWebhook(r webhook.Repository, guard *webhook.Guard, timeout time.Duration) webhook.Usecase {
+	return &webhookUsecase{
+		repo:           r,
+		guard:          guard,
+		contextTimeout: timeout,
+	}
+}
+
+// Create stores the given webhook, which must belong to the caller's
+// organisation and be allowed by the guard, returning the secret its requests
+// are signed with.
+func (a *webhookUsecase) Create(c context.Context, w *models.Webhook) (string, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if !tenant.Allows(ctx, w.Organisation) {
+		return "", models.ErrNotFound
+	}
+	if err := a.guard.CheckURL(ctx, w.URL); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, secretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	w.Secret = secretPrefix + hex.EncodeToString(b)
+	w.CreatedAt = time.Now()
+	id, err := a.repo.Store(ctx, w)
+	if err != nil {
+		return "", err
+	}
+
+	w.ID = id
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"webhook":     id,
+		"event_types": w.EventTypes,
+	}).Info("webhook created")
+	return w.Secret, nil
+}
+
+// Fetch lists the webhooks of the caller's organisation.
+func (a *webhookUsecase) Fetch(c context.Context) ([]*models.Webhook, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	return a.repo.Fetch(ctx)
+}
+
+// Delete deletes a webhook of the caller's organisation, which is not
+// delivered any event afterwards.
+func (a *webhookUsecase) Delete(c context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if err := a.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithField("webhook", id).Info("webhook deleted")
+	return nil
+}
+
+// Deliveries lists up to num deliveries of a webhook of the caller's
+// organisation, newest first.
+func (a *webhookUsecase) Deliveries(c context.Context, webhook int64, num int64) ([]*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	if num <= 0 {
+		num = defaultDeliveries
+	}
+	if _, err := a.repo.GetByID(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return a.repo.FetchDeliveries(ctx, webhook, num)
+}
+
+// Redeliver schedules a delivery of a webhook of the caller's organisation to
+// be attempted again right away, dead ones getting a fresh set of attempts.
+func (a *webhookUsecase) Redeliver(c context.Context, webhook int64, delivery int64) (*models.WebhookDelivery, error) {
+	ctx, cancel := context.WithTimeout(c, a.contextTimeout)
+	defer cancel()
+
+	d, err := a.repo.GetDelivery(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	if d.Webhook != webhook {
+		return nil, models.ErrNotFound
+	}
+
+	now := time.Now()
+	d.Status = models.DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+	if err = a.repo.UpdateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"webhook":  webhook,
+		"delivery": delivery,
+	}).Info("webhook delivery scheduled")
+	return d, nil
+}
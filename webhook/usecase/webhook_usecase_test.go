@@ -0,0 +1,105 @@
+package usecase_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/adriacidre/go-clean-arch/models"
+	"github.com/adriacidre/go-clean-arch/tenant"
+	"github.com/adriacidre/go-clean-arch/webhook"
+	"github.com/adriacidre/go-clean-arch/webhook/mocks"
+	ucase "github.com/adriacidre/go-clean-arch/webhook/usecase"
+)
+
+// guard allows example.com, which is not resolved offline.
+func guard(t *testing.T) *webhook.Guard {
+	g, err := webhook.NewGuard([]string{"example.com"})
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestCreate(t *testing.T) {
+	mockWebhookRepo := new(mocks.Repository)
+	mockWebhookRepo.On("Store", mock.Anything, mock.AnythingOfType("*models.Webhook")).Return(int64(3), nil).Once()
+
+	u := ucase.NewWebhook(mockWebhookRepo, guard(t), time.Second*2)
+	w := &models.Webhook{Organisation: "org-a", URL: "https://example.com/hook", EventTypes: []string{"PaymentCreated"}}
+
+	secret, err := u.Create(tenant.NewContext(context.TODO(), "org-a"), w)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), w.ID)
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+	assert.Equal(t, secret, w.Secret)
+	assert.False(t, w.CreatedAt.IsZero())
+
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestCreateForAnotherOrganisation(t *testing.T) {
+	mockWebhookRepo := new(mocks.Repository)
+
+	u := ucase.NewWebhook(mockWebhookRepo, guard(t), time.Second*2)
+
+	_, err := u.Create(tenant.NewContext(context.TODO(), "org-b"), &models.Webhook{Organisation: "org-a"})
+	assert.Equal(t, models.ErrNotFound, err)
+	mockWebhookRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+}
+
+func TestCreateURLNotAllowed(t *testing.T) {
+	mockWebhookRepo := new(mocks.Repository)
+
+	u := ucase.NewWebhook(mockWebhookRepo, guard(t), time.Second*2)
+
+	for _, url := range []string{"http://hooks.example.org/hook", "https://127.0.0.1/hook", "https://169.254.169.254/latest/meta-data", "https://10.0.0.8"} {
+		_, err := u.Create(tenant.NewContext(context.TODO(), "org-a"), &models.Webhook{Organisation: "org-a", URL: url})
+		assert.Equal(t, models.ErrWebhookURLNotAllowed, err, url)
+	}
+	mockWebhookRepo.AssertNotCalled(t, "Store", mock.Anything, mock.Anything)
+}
+
+func TestDeliveries(t *testing.T) {
+	mockWebhookRepo := new(mocks.Repository)
+	mockWebhookRepo.On("GetByID", mock.Anything, int64(3)).Return(&models.Webhook{ID: 3}, nil).Once()
+	mockWebhookRepo.On("FetchDeliveries", mock.Anything, int64(3), int64(10)).
+		Return([]*models.WebhookDelivery{{ID: 1, Webhook: 3}}, nil).Once()
+	mockWebhookRepo.On("GetByID", mock.Anything, int64(4)).Return(nil, models.ErrNotFound).Once()
+
+	u := ucase.NewWebhook(mockWebhookRepo, guard(t), time.Second*2)
+
+	list, err := u.Deliveries(context.TODO(), 3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	_, err = u.Deliveries(context.TODO(), 4, 10)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	mockWebhookRepo.AssertExpectations(t)
+}
+
+func TestRedeliver(t *testing.T) {
+	mockWebhookRepo := new(mocks.Repository)
+	dead := &models.WebhookDelivery{ID: 5, Webhook: 3, Status: models.DeliveryDead, Attempts: 10,
+		NextAttemptAt: time.Now().Add(-time.Hour)}
+	mockWebhookRepo.On("GetDelivery", mock.Anything, int64(5)).Return(dead, nil)
+	mockWebhookRepo.On("UpdateDelivery", mock.Anything, dead).Return(nil).Once()
+
+	u := ucase.NewWebhook(mockWebhookRepo, guard(t), time.Second*2)
+
+	d, err := u.Redeliver(context.TODO(), 3, 5)
+	require.NoError(t, err)
+	assert.Equal(t, models.DeliveryPending, d.Status)
+	assert.Equal(t, 0, d.Attempts)
+	assert.WithinDuration(t, time.Now(), d.NextAttemptAt, time.Second)
+
+	_, err = u.Redeliver(context.TODO(), 4, 5)
+	assert.Equal(t, models.ErrNotFound, err)
+
+	mockWebhookRepo.AssertExpectations(t)
+}